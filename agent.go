@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"google.golang.org/genai"
+
+	"github.com/ginkida/gokin-sdk/tasks"
 )
 
 // AgentConfig holds the agent's configuration.
@@ -25,16 +27,16 @@ type AgentConfig struct {
 
 // AgentProgress tracks agent execution progress.
 type AgentProgress struct {
-	AgentID            string
-	AgentType          AgentType
-	CurrentStep        int
-	TotalSteps         int
-	CurrentAction      string
-	StartTime          time.Time
-	Elapsed            time.Duration
-	EstimatedRemaining time.Duration
-	ToolsUsed          []string
-	Status             AgentStatus
+	AgentID            string        `json:"agent_id"`
+	AgentType          AgentType     `json:"agent_type,omitempty"`
+	CurrentStep        int           `json:"current_step"`
+	TotalSteps         int           `json:"total_steps"`
+	CurrentAction      string        `json:"current_action,omitempty"`
+	StartTime          time.Time     `json:"start_time"`
+	Elapsed            time.Duration `json:"elapsed"`
+	EstimatedRemaining time.Duration `json:"estimated_remaining"`
+	ToolsUsed          []string      `json:"tools_used,omitempty"`
+	Status             AgentStatus   `json:"status"`
 }
 
 // AgentResult represents the result of an agent's execution.
@@ -74,18 +76,52 @@ type Agent struct {
 	// Context injection
 	pinnedContext string
 
+	// History compaction at turn boundaries
+	compactor HistoryCompactor
+
 	// Scratchpad
 	scratchpad string
 
+	// Conversation binding
+	session      *Session
+	sessionStore *SessionStore
+
+	// Automatic checkpointing
+	checkpointCfg  CheckpointConfig
+	lastCheckpoint string
+
+	// Human interaction, exposed to tools through the run context
+	interaction Interaction
+
+	// Background tasks, stopped by Close
+	tasksCtx  context.Context
+	tasks     *tasks.Manager
+	stopTasks context.CancelFunc
+
+	// Review mode: file changes wait for approval
+	reviewMode bool
+	approver   ChangeApprover
+
+	// Steering: injected messages, pause/resume and stop
+	control agentControl
+
+	// Event stream
+	onEvent  func(Event)
+	events   *eventSink
+	eventsMu sync.Mutex
+
 	// Tools tracking
 	toolsUsed []string
 	toolsMu   sync.Mutex
 
 	// Plan execution
-	planner        *Planner
-	activePlan     *PlanLifecycle
-	planningMode   bool
-	onPlanApproved func(summary string)
+	planner          *Planner
+	verifier         Verifier
+	workDir          string
+	activePlan       *PlanLifecycle
+	planningMode     bool
+	onPlanApproved   func(summary string)
+	onPlanTransition func(from, to PlanLifecycleState)
 }
 
 // NewAgent creates a new agent with the given name, client, and tool registry.
@@ -114,6 +150,13 @@ func NewAgent(name string, client Client, registry *Registry, opts ...AgentOptio
 		opt(a)
 	}
 
+	if a.verifier == nil {
+		a.verifier = NewToolVerifier(a.executor, a.workDir)
+	}
+	if a.tasksCtx != nil {
+		a.startTasks(a.tasksCtx)
+	}
+
 	return a, nil
 }
 
@@ -140,23 +183,24 @@ func (a *Agent) buildSystemPrompt() string {
 
 // Run executes the agent with the given message and returns the result.
 func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
-	start := time.Now()
-
-	// Configure client for this agent (deferred from NewAgent so each
-	// cloned client gets its own tools/systemInstruction).
-	if systemPrompt := a.buildSystemPrompt(); systemPrompt != "" {
-		a.client.SetSystemInstruction(systemPrompt)
-	}
-	if a.registry != nil {
-		a.client.SetTools(a.registry.GeminiTools())
-	}
+	ctx, end := a.startControl(a.interactionContext(ctx))
+	defer end()
+	return a.finishRun(a.stopped(a.run(ctx, message)))
+}
 
-	// Initialize progress
-	a.initProgress(start)
+func (a *Agent) run(ctx context.Context, message string) (*AgentResult, error) {
+	start := time.Now()
+	a.prepareRun(start)
 
 	// Plan-driven execution if planner is configured
 	if a.planner != nil {
-		return a.runWithPlan(ctx, message)
+		result, err := a.runWithPlan(ctx, message)
+		if a.session != nil && result != nil {
+			a.session.AddUserMessage(message)
+			a.session.AddModelResponse(genai.NewContentFromText(result.Text, "model"))
+			a.saveSession()
+		}
+		return result, err
 	}
 
 	// Apply overall timeout
@@ -166,13 +210,9 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 		defer cancel()
 	}
 
-	history := make([]*genai.Content, 0)
-	turns := 0
-	maxTurns := a.config.MaxTurns
-	bonusTurns := 0
-	stuckCount := 0
-	lastToolError := ""
-	lastToolName := ""
+	// Continue the bound session, if any; new entries are synced back to it
+	history := a.sessionHistory()
+	synced := len(history)
 
 	// Initial message
 	stream, err := a.client.SendMessageWithHistory(ctx, history, message)
@@ -183,6 +223,37 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 	// Add user message to history
 	history = append(history, genai.NewContentFromText(message, "user"))
 
+	return a.runLoop(ctx, stream, history, synced, 0, start)
+}
+
+// prepareRun configures the client and resets progress for a new run.
+func (a *Agent) prepareRun(start time.Time) {
+	// Configure client for this agent (deferred from NewAgent so each
+	// cloned client gets its own tools/systemInstruction).
+	if systemPrompt := a.buildSystemPrompt(); systemPrompt != "" {
+		a.client.SetSystemInstruction(systemPrompt)
+	}
+	if a.registry != nil {
+		a.client.SetTools(a.registry.GeminiTools())
+	}
+
+	// Initialize progress
+	a.initProgress(start)
+}
+
+// runLoop drives the agentic loop from an in-flight response stream.
+// history must already contain the message that produced stream; entries
+// from synced onward are appended to the bound session as the run goes.
+func (a *Agent) runLoop(ctx context.Context, stream *StreamResponse, history []*genai.Content, synced, turns int, start time.Time) (*AgentResult, error) {
+	defer func() { a.syncSession(history, synced) }()
+
+	maxTurns := a.config.MaxTurns
+	bonusTurns := 0
+	stuckCount := 0
+	lastToolError := ""
+	lastToolName := ""
+	wrappingUp := false // a graceful stop asked the model for a summary
+
 	for turns < maxTurns+bonusTurns {
 		turns++
 
@@ -190,7 +261,7 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 		a.updateProgress(turns, maxTurns+bonusTurns, lastToolName, start)
 
 		// Collect the response
-		resp, err := stream.Collect(ctx)
+		resp, err := a.collect(ctx, stream, turns)
 		if err != nil {
 			return &AgentResult{
 				Turns:    turns,
@@ -206,10 +277,31 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 
 		// Build model content for history
 		modelContent := buildModelContent(resp)
+		if wrappingUp {
+			// No tools run after a stop; keep only the summary
+			modelContent = genai.NewContentFromText(resp.Text, "model")
+		}
 		history = append(history, modelContent)
 
 		// If no function calls, we're done
-		if len(resp.FunctionCalls) == 0 {
+		if len(resp.FunctionCalls) == 0 || wrappingUp {
+			// Messages injected while the model was answering continue the run
+			if !wrappingUp && !a.stopRequested() {
+				if msgs := a.takeInjected(); len(msgs) > 0 {
+					message := strings.Join(msgs, "\n\n")
+					a.emit(Event{Type: EventInjected, Text: message})
+					stream, err = a.client.SendMessageWithHistory(ctx, history, message)
+					if err != nil {
+						return &AgentResult{
+							Turns:    turns,
+							Duration: time.Since(start),
+							Error:    err,
+						}, err
+					}
+					history = append(history, genai.NewContentFromText(message, "user"))
+					continue
+				}
+			}
 			a.setProgressStatus(AgentStatusCompleted)
 			return &AgentResult{
 				Text:     resp.Text,
@@ -239,6 +331,9 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 				intervention := buildLoopRecoveryIntervention(fc.Name, exactCount)
 				interventionContent := genai.NewContentFromText(intervention, "user")
 				history = append(history, interventionContent)
+				a.emit(Event{Type: EventLoopDetected, Intervention: &InterventionEvent{
+					Tool: fc.Name, Count: exactCount, Message: intervention,
+				}})
 
 				// Reset exact count
 				a.callHistoryMu.Lock()
@@ -265,6 +360,9 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 				)
 				interventionContent := genai.NewContentFromText(intervention, "user")
 				history = append(history, interventionContent)
+				a.emit(Event{Type: EventLoopDetected, Intervention: &InterventionEvent{
+					Tool: fc.Name, Count: broadCount, Message: intervention,
+				}})
 
 				// Reset broad count
 				a.callHistoryMu.Lock()
@@ -283,8 +381,18 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 		}
 
 		if loopDetected {
+			var steering string
+			steering, wrappingUp, err = a.steer(ctx)
+			if err != nil {
+				return &AgentResult{
+					Turns:    turns,
+					Duration: time.Since(start),
+					Error:    err,
+				}, err
+			}
+
 			// Get new response after intervention
-			stream, err = a.client.SendMessageWithHistory(ctx, history, "")
+			stream, err = a.client.SendMessageWithHistory(ctx, history, steering)
 			if err != nil {
 				return &AgentResult{
 					Turns:    turns,
@@ -292,6 +400,9 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 					Error:    err,
 				}, err
 			}
+			if steering != "" {
+				history = append(history, genai.NewContentFromText(steering, "user"))
+			}
 			continue
 		}
 
@@ -301,6 +412,9 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 		}
 
 		// Execute tools
+		for _, fc := range resp.FunctionCalls {
+			a.emit(Event{Type: EventToolCallStart, Tool: &ToolEvent{ID: fc.ID, Name: fc.Name, Args: fc.Args}})
+		}
 		results, err := a.executor.Execute(ctx, resp.FunctionCalls)
 		if err != nil {
 			return &AgentResult{
@@ -309,6 +423,11 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 				Error:    err,
 			}, err
 		}
+		for j, funcResp := range results {
+			if j < len(resp.FunctionCalls) {
+				a.emitToolEnd(resp.FunctionCalls[j], funcResp)
+			}
+		}
 
 		// Track tool usage
 		for _, fc := range resp.FunctionCalls {
@@ -338,6 +457,12 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 						intervention := a.reflector.BuildIntervention(funcResp.Name, args, reflection, errMsg)
 						interventionContent := genai.NewContentFromText(intervention, "user")
 						history = append(history, interventionContent)
+						a.emit(Event{Type: EventReflection, Intervention: &InterventionEvent{
+							Tool:       funcResp.Name,
+							Category:   reflection.Category,
+							Suggestion: reflection.Suggestion,
+							Message:    intervention,
+						}})
 					}
 				}
 			}
@@ -376,6 +501,12 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 				success := delegationErr == nil && delegationResult != nil && delegationResult.Error == nil
 
 				a.delegation.RecordOutcome("", decision.TargetType, decision.Reason, success, delegationDuration, "")
+				a.emit(Event{Type: EventDelegation, Delegation: &DelegationEvent{
+					TargetType: decision.TargetType,
+					Reason:     decision.Reason,
+					Success:    success,
+					Duration:   delegationDuration,
+				}})
 
 				if success && delegationResult.Text != "" {
 					// Inject delegation result into history
@@ -389,8 +520,32 @@ func (a *Agent) Run(ctx context.Context, message string) (*AgentResult, error) {
 			}
 		}
 
-		// Send function responses back to the model
-		stream, err = a.client.SendFunctionResponse(ctx, history, results)
+		synced = a.syncSession(history, synced)
+		a.maybeCheckpoint(history, turns)
+		history, synced = a.compactHistory(ctx, history, synced)
+
+		// Turn boundary: apply pause, injected messages and stop requests
+		var steering string
+		steering, wrappingUp, err = a.steer(ctx)
+		if err != nil {
+			return &AgentResult{
+				Turns:    turns,
+				Duration: time.Since(start),
+				Error:    err,
+			}, err
+		}
+
+		if steering != "" {
+			// History already ends with the tool results; the steering
+			// message follows them, so the model reacts to both
+			stream, err = a.client.SendMessageWithHistory(ctx, history, steering)
+			if err == nil {
+				history = append(history, genai.NewContentFromText(steering, "user"))
+			}
+		} else {
+			// Send function responses back to the model
+			stream, err = a.client.SendFunctionResponse(ctx, history, results)
+		}
 		if err != nil {
 			return &AgentResult{
 				Turns:    turns,
@@ -432,7 +587,9 @@ func (a *Agent) runWithPlan(ctx context.Context, message string) (*AgentResult,
 
 	// Create lifecycle and transition: draft → approved → executing
 	lifecycle := NewPlanLifecycle(generateID(), tree)
+	a.attachLifecycle(lifecycle)
 	a.activePlan = lifecycle
+	a.emitPlanCreated(lifecycle.PlanID, tree)
 
 	if err := lifecycle.TransitionTo(PlanStateApproved); err != nil {
 		return nil, fmt.Errorf("plan approval failed: %w", err)
@@ -446,9 +603,21 @@ func (a *Agent) runWithPlan(ctx context.Context, message string) (*AgentResult,
 		return nil, fmt.Errorf("plan execution start failed: %w", err)
 	}
 
-	const maxReplans = 3
-	replans := 0
-	var outputs []string
+	return a.executePlan(ctx, message, lifecycle, start)
+}
+
+// executePlan runs ready nodes of an executing plan until it completes or
+// runs out of replans. Outputs of nodes that already completed (e.g. before
+// a resume) are included in the result.
+func (a *Agent) executePlan(ctx context.Context, message string, lifecycle *PlanLifecycle, start time.Time) (*AgentResult, error) {
+	tree := lifecycle.Tree
+
+	maxReplans := a.planner.config.MaxReplans
+	if maxReplans <= 0 {
+		maxReplans = 3
+	}
+	replans := lifecycle.ReplanCount
+	outputs := completedOutputs(tree)
 
 	for {
 		readyNodes := a.planner.GetReadyNodes(tree)
@@ -458,10 +627,36 @@ func (a *Agent) runWithPlan(ctx context.Context, message string) (*AgentResult,
 
 		replanNeeded := false
 		for _, node := range readyNodes {
+			// Pause and stop take effect between nodes
+			if err := a.waitIfPaused(ctx); err != nil {
+				return &AgentResult{
+					Text:     strings.Join(outputs, "\n"),
+					Turns:    replans,
+					Duration: time.Since(start),
+					Error:    err,
+				}, err
+			}
+			if a.stopRequested() {
+				if ctx.Err() == nil {
+					return a.wrapUpPlan(ctx, message, tree, outputs, replans, start)
+				}
+				// Same result and error as a stopped conversation run
+				a.setProgressStatus(AgentStatusCancelled)
+				return &AgentResult{
+					Text:     strings.Join(outputs, "\n"),
+					Turns:    replans,
+					Duration: time.Since(start),
+					Error:    ErrAgentStopped,
+				}, ErrAgentStopped
+			}
+
 			node.Status = PlanNodeRunning
+			a.emitPlanNode(lifecycle.PlanID, node)
 
 			result := a.executeNode(ctx, node)
 			a.planner.RecordResult(tree, node.ID, result)
+			a.emitPlanNode(lifecycle.PlanID, node)
+			a.maybeCheckpointPlan(message, lifecycle)
 
 			if result.Success {
 				if result.Output != "" {
@@ -471,50 +666,42 @@ func (a *Agent) runWithPlan(ctx context.Context, message string) (*AgentResult,
 			}
 
 			// Node failed — attempt reflection and replan
+			reason := result.Error
 			if a.reflector != nil {
-				reflection := a.reflector.Analyze(ctx, "plan_node", nil, result.Error)
+				toolName := "plan_node"
+				var toolArgs map[string]any
+				if node.Action != nil && node.Action.ToolName != "" {
+					toolName = node.Action.ToolName
+					toolArgs = node.Action.ToolArgs
+				}
+				reflection := a.reflector.Analyze(ctx, toolName, toolArgs, result.Error)
 				if reflection.Matched {
 					outputs = append(outputs, fmt.Sprintf("[Recovery: %s]", reflection.Suggestion))
+					reason += "\nSuggested recovery: " + reflection.Suggestion
 				}
 			}
 
 			if replans < maxReplans {
 				if err := lifecycle.TransitionTo(PlanStateFailed); err != nil {
-					break // can't transition — skip replan
-				}
-				if err := lifecycle.RequestReplan(result.Error); err != nil {
-					slog.Warn("plan replan request failed", "error", err)
+					return a.failPlan(tree, message, outputs, replans, start,
+						fmt.Errorf("plan node %s failed: %s (cannot replan: %w)", node.ID, result.Error, err))
 				}
-				if err := lifecycle.TransitionTo(PlanStateApproved); err != nil {
-					slog.Warn("plan transition to approved failed", "error", err)
-				}
-				if err := lifecycle.TransitionTo(PlanStateExecuting); err != nil {
-					slog.Warn("plan transition to executing failed", "error", err)
-				}
-
-				newTree, rerr := a.planner.BuildPlan(ctx, PlanGoal{
-					Description: message + "\nPrevious error: " + result.Error,
-				})
-				if rerr == nil {
-					tree = newTree
-					lifecycle.Tree = newTree
+				newTree, err := a.replan(ctx, lifecycle, tree, node, message, reason)
+				if err != nil {
+					return a.failPlan(tree, message, outputs, replans, start, err)
 				}
+				tree = newTree
 				replans++
 				replanNeeded = true
 				break // restart loop with fresh ready nodes
 			}
 
 			// Exhausted replans
-			a.setProgressStatus(AgentStatusFailed)
 			if err := lifecycle.TransitionTo(PlanStateFailed); err != nil {
 				slog.Warn("plan transition to failed state failed", "error", err)
 			}
-			return &AgentResult{
-				Text:     strings.Join(outputs, "\n"),
-				Turns:    replans,
-				Duration: time.Since(start),
-				Error:    fmt.Errorf("plan node %s failed after %d replans: %s", node.ID, maxReplans, result.Error),
-			}, nil
+			return a.failPlan(tree, message, outputs, replans, start,
+				fmt.Errorf("plan node %s failed after %d replans: %s", node.ID, maxReplans, result.Error))
 		}
 
 		if !replanNeeded {
@@ -530,6 +717,7 @@ func (a *Agent) runWithPlan(ctx context.Context, message string) (*AgentResult,
 	if err := lifecycle.TransitionTo(PlanStateCompleted); err != nil {
 		slog.Warn("plan transition to completed failed", "error", err)
 	}
+	a.planner.RecordOutcome(tree, message, true, time.Since(start))
 	a.setProgressStatus(AgentStatusCompleted)
 
 	return &AgentResult{
@@ -539,6 +727,93 @@ func (a *Agent) runWithPlan(ctx context.Context, message string) (*AgentResult,
 	}, nil
 }
 
+// failPlan ends a failed plan run. The result and the returned error carry
+// the same failure.
+func (a *Agent) failPlan(tree *PlanTree, message string, outputs []string, replans int, start time.Time, err error) (*AgentResult, error) {
+	a.planner.RecordOutcome(tree, message, false, time.Since(start))
+	a.setProgressStatus(AgentStatusFailed)
+	return &AgentResult{
+		Text:     strings.Join(outputs, "\n"),
+		Turns:    replans,
+		Duration: time.Since(start),
+		Error:    err,
+	}, err
+}
+
+// wrapUpPlan ends a plan run stopped gracefully. Like a stopped
+// conversation run, the model is asked to summarize the progress, and the
+// summary becomes the result text.
+func (a *Agent) wrapUpPlan(ctx context.Context, message string, tree *PlanTree, outputs []string, replans int, start time.Time) (*AgentResult, error) {
+	progress := a.planner.Summary(tree)
+	if len(outputs) > 0 {
+		progress += "\n" + strings.Join(outputs, "\n")
+	}
+	history := []*genai.Content{
+		genai.NewContentFromText(message, "user"),
+		genai.NewContentFromText(progress, "model"),
+	}
+
+	stream, err := a.client.SendMessageWithHistory(ctx, history, wrapUpPrompt)
+	var resp *Response
+	if err == nil {
+		resp, err = a.collect(ctx, stream, replans+1)
+	}
+	if err != nil {
+		return &AgentResult{
+			Text:     strings.Join(outputs, "\n"),
+			Turns:    replans,
+			Duration: time.Since(start),
+			Error:    err,
+		}, err
+	}
+
+	if resp.Text != "" && a.config.OnText != nil {
+		a.config.OnText(resp.Text)
+	}
+	a.setProgressStatus(AgentStatusCompleted)
+	return &AgentResult{
+		Text:     resp.Text,
+		Turns:    replans,
+		Duration: time.Since(start),
+	}, nil
+}
+
+// replan repairs the plan after node failed and moves the lifecycle back to
+// executing. The failed node's siblings are regenerated in place so completed
+// work is kept; if that is not possible the whole plan is rebuilt. If the
+// plan can be neither repaired nor rebuilt, the lifecycle is left failed.
+func (a *Agent) replan(ctx context.Context, lifecycle *PlanLifecycle, tree *PlanTree, node *PlanNode, goal, reason string) (*PlanTree, error) {
+	if err := lifecycle.RequestReplan(reason); err != nil {
+		slog.Warn("plan replan request failed", "error", err)
+	}
+
+	if err := a.planner.Replan(ctx, tree, node.ID, goal, reason); err != nil {
+		newTree, rerr := a.planner.BuildPlan(ctx, PlanGoal{
+			Description: goal + "\nPrevious error: " + reason,
+		})
+		if rerr != nil {
+			if terr := lifecycle.TransitionTo(PlanStateFailed); terr != nil {
+				slog.Warn("plan transition to failed state failed", "error", terr)
+			}
+			return nil, fmt.Errorf("replan after node %s failed: %w (rebuild: %v)", node.ID, err, rerr)
+		}
+		tree = newTree
+		lifecycle.SetTree(newTree)
+	}
+	a.emitPlanCreated(lifecycle.PlanID, tree)
+
+	if err := lifecycle.TransitionTo(PlanStateApproved); err != nil {
+		slog.Warn("plan transition to approved failed", "error", err)
+	}
+	if a.onPlanApproved != nil {
+		a.onPlanApproved(a.planner.Summary(tree))
+	}
+	if err := lifecycle.TransitionTo(PlanStateExecuting); err != nil {
+		slog.Warn("plan transition to executing failed", "error", err)
+	}
+	return tree, nil
+}
+
 // executeNode runs a single plan node and returns its result.
 func (a *Agent) executeNode(ctx context.Context, node *PlanNode) *PlanResult {
 	if node.Action == nil {
@@ -561,13 +836,23 @@ func (a *Agent) executeNode(ctx context.Context, node *PlanNode) *PlanResult {
 		return &PlanResult{Output: result.Text, Success: true}
 	}
 
+	// Tool path: run the named tool directly with validated arguments
+	if node.Action.Type == ActionToolCall && node.Action.ToolName != "" {
+		return a.executeToolAction(ctx, node.Action)
+	}
+
+	// Verify path: run a concrete check instead of asking the LLM
+	if node.Action.Type == ActionVerify && node.Action.Verify != nil && a.verifier != nil {
+		return a.verifier.Verify(ctx, node.Action.Verify)
+	}
+
 	// Direct execution: send prompt to LLM and handle tool calls
 	stream, err := a.client.SendMessage(ctx, node.Action.Prompt)
 	if err != nil {
 		return &PlanResult{Error: err.Error(), Success: false}
 	}
 
-	resp, err := stream.Collect(ctx)
+	resp, err := a.collect(ctx, stream, 0)
 	if err != nil {
 		return &PlanResult{Error: err.Error(), Success: false}
 	}
@@ -578,10 +863,18 @@ func (a *Agent) executeNode(ctx context.Context, node *PlanNode) *PlanResult {
 	}
 
 	// Execute function calls
+	for _, fc := range resp.FunctionCalls {
+		a.emit(Event{Type: EventToolCallStart, Tool: &ToolEvent{ID: fc.ID, Name: fc.Name, Args: fc.Args}})
+	}
 	results, err := a.executor.Execute(ctx, resp.FunctionCalls)
 	if err != nil {
 		return &PlanResult{Error: err.Error(), Success: false}
 	}
+	for j, fr := range results {
+		if j < len(resp.FunctionCalls) {
+			a.emitToolEnd(resp.FunctionCalls[j], fr)
+		}
+	}
 
 	// Check results for errors
 	var toolOutputs []string
@@ -607,6 +900,42 @@ func (a *Agent) executeNode(ctx context.Context, node *PlanNode) *PlanResult {
 	return &PlanResult{Output: output, Success: true}
 }
 
+// executeToolAction runs a planned tool call through the registry.
+func (a *Agent) executeToolAction(ctx context.Context, action *PlannedAction) *PlanResult {
+	tool, ok := a.registry.Get(action.ToolName)
+	if !ok {
+		return &PlanResult{Error: fmt.Sprintf("unknown tool: %s", action.ToolName), Success: false}
+	}
+
+	args := action.ToolArgs
+	if args == nil {
+		args = map[string]any{}
+	}
+	if err := ValidateToolArgs(tool.Declaration(), args); err != nil {
+		return &PlanResult{Error: fmt.Sprintf("invalid arguments for %s: %s", action.ToolName, err), Success: false}
+	}
+
+	if a.config.OnToolCall != nil {
+		a.config.OnToolCall(action.ToolName, args)
+	}
+
+	a.emit(Event{Type: EventToolCallStart, Tool: &ToolEvent{Name: action.ToolName, Args: args}})
+	result := a.executor.executeTool(ctx, &genai.FunctionCall{Name: action.ToolName, Args: args})
+	a.trackToolUsed(action.ToolName)
+	a.emit(Event{Type: EventToolCallEnd, Tool: &ToolEvent{
+		Name:    action.ToolName,
+		Args:    args,
+		Result:  map[string]any{"content": result.Content},
+		Error:   result.Error,
+		Success: result.Success,
+	}})
+
+	if !result.Success {
+		return &PlanResult{Output: result.Content, Error: result.Error, Success: false}
+	}
+	return &PlanResult{Output: result.Content, Success: true}
+}
+
 // --- Progress tracking ---
 
 func (a *Agent) initProgress(start time.Time) {
@@ -621,7 +950,6 @@ func (a *Agent) initProgress(start time.Time) {
 
 func (a *Agent) updateProgress(turn, totalTurns int, currentAction string, start time.Time) {
 	a.progressMu.Lock()
-	defer a.progressMu.Unlock()
 
 	a.progress.CurrentStep = turn
 	a.progress.TotalSteps = totalTurns
@@ -642,15 +970,22 @@ func (a *Agent) updateProgress(turn, totalTurns int, currentAction string, start
 	if a.onProgress != nil {
 		a.onProgress(a.progress)
 	}
+	progress := a.progress
+	a.progressMu.Unlock()
+
+	a.emit(Event{Type: EventProgress, Progress: &progress})
 }
 
 func (a *Agent) setProgressStatus(status AgentStatus) {
 	a.progressMu.Lock()
-	defer a.progressMu.Unlock()
 	a.progress.Status = status
 	if a.onProgress != nil {
 		a.onProgress(a.progress)
 	}
+	progress := a.progress
+	a.progressMu.Unlock()
+
+	a.emit(Event{Type: EventProgress, Progress: &progress})
 }
 
 func (a *Agent) trackToolUsed(name string) {
@@ -720,6 +1055,18 @@ func buildLoopRecoveryIntervention(toolName string, count int) string {
 		sb.WriteString("- Use 'read' to verify the current file content\n")
 		sb.WriteString("- Check if the old_string matches exactly\n")
 		sb.WriteString("- Try 'write' to replace the entire file\n")
+	case "apply_patch":
+		sb.WriteString("- Use 'read' to verify the current file content\n")
+		sb.WriteString("- Check the per-hunk failure report and fix the failing hunks\n")
+		sb.WriteString("- Use dry_run=true to check the patch before applying it\n")
+	case "lsp", "lsp_rename":
+		sb.WriteString("- Give the symbol's name as written on the line, with the correct 1-based line\n")
+		sb.WriteString("- Check that a language server for the file's language is installed\n")
+		sb.WriteString("- Fall back to 'grep' to locate the symbol\n")
+	case "go_symbols", "go_edit":
+		sb.WriteString("- Use 'go_symbols' to list the file's declarations and their exact names\n")
+		sb.WriteString("- Name methods as 'Type.Method' when several types share the method\n")
+		sb.WriteString("- Use dry_run=true to check the edit before applying it\n")
 	case "write":
 		sb.WriteString("- Use 'read' to check the current content\n")
 		sb.WriteString("- Verify the file path is correct\n")
@@ -0,0 +1,203 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// LastCheckpoint returns the path of the most recent automatic checkpoint,
+// or "" if none has been written.
+func (a *Agent) LastCheckpoint() string {
+	return a.lastCheckpoint
+}
+
+// Resume continues a run from a checkpoint file written by
+// automatic checkpointing. History, scratchpad, tools used and shared
+// memory are restored; plan runs pick up at the first node that had not
+// completed.
+func (a *Agent) Resume(ctx context.Context, checkpointPath string) (*AgentResult, error) {
+	ctx, end := a.startControl(a.interactionContext(ctx))
+	defer end()
+	return a.finishRun(a.stopped(a.resumeCheckpoint(ctx, checkpointPath)))
+}
+
+func (a *Agent) resumeCheckpoint(ctx context.Context, checkpointPath string) (*AgentResult, error) {
+	cp, err := LoadAgentCheckpoint(checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+	history, err := RestoreFromAgentCheckpoint(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	a.scratchpad = cp.AgentState.Scratchpad
+	a.toolsMu.Lock()
+	a.toolsUsed = append([]string(nil), cp.AgentState.ToolsUsed...)
+	a.toolsMu.Unlock()
+	if a.config.Memory != nil {
+		for _, entry := range cp.SharedMemory {
+			if _, ok := a.config.Memory.ReadEntry(entry.Key); !ok {
+				a.config.Memory.WriteWithTTL(entry.Key, entry.Value, entry.Type, entry.Source, entry.TTL)
+			}
+		}
+	}
+
+	start := time.Now()
+	a.prepareRun(start)
+
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	if cp.PlanState != nil && a.planner != nil {
+		restorePlanTree(cp.PlanState)
+		var lifecycle *PlanLifecycle
+		if cp.Lifecycle != nil {
+			lifecycle = RestorePlanLifecycle(cp.Lifecycle, cp.PlanState)
+		} else {
+			lifecycle = NewPlanLifecycle(generateID(), cp.PlanState)
+			lifecycle.State = PlanStateExecuting
+		}
+		a.attachLifecycle(lifecycle)
+		a.activePlan = lifecycle
+		return a.executePlan(ctx, cp.Message, lifecycle, start)
+	}
+
+	// Checkpoints are taken after a tool batch, before its results were
+	// sent, so continue by sending them; otherwise prompt the model directly.
+	var stream *StreamResponse
+	if results := pendingFunctionResponses(history); len(results) > 0 {
+		stream, err = a.client.SendFunctionResponse(ctx, history, results)
+	} else {
+		const prompt = "Continue from where you left off."
+		stream, err = a.client.SendMessageWithHistory(ctx, history, prompt)
+		history = append(history, genai.NewContentFromText(prompt, "user"))
+	}
+	if err != nil {
+		a.setProgressStatus(AgentStatusFailed)
+		return nil, fmt.Errorf("resume failed: %w", err)
+	}
+	return a.runLoop(ctx, stream, history, len(history), cp.TurnNumber, start)
+}
+
+// maybeCheckpoint saves the conversation after a batch of tool results
+// if automatic checkpointing is due.
+func (a *Agent) maybeCheckpoint(history []*genai.Content, turns int) {
+	cfg := a.checkpointCfg
+	if !cfg.Enabled {
+		return
+	}
+	reason := "tool_batch"
+	if !cfg.AfterToolBatch {
+		if cfg.Interval <= 0 || turns%cfg.Interval != 0 {
+			return
+		}
+		reason = "interval"
+	}
+	a.writeCheckpoint(history, turns, nil, "", reason)
+}
+
+// maybeCheckpointPlan saves the plan tree and lifecycle after a node has
+// run if automatic checkpointing is due.
+func (a *Agent) maybeCheckpointPlan(message string, lifecycle *PlanLifecycle) {
+	cfg := a.checkpointCfg
+	if !cfg.Enabled {
+		return
+	}
+	done := 0
+	lifecycle.Tree.mu.RLock()
+	for _, node := range lifecycle.Tree.nodeIndex {
+		if node.Status != PlanNodePending {
+			done++
+		}
+	}
+	lifecycle.Tree.mu.RUnlock()
+
+	reason := "tool_batch"
+	if !cfg.AfterToolBatch {
+		if cfg.Interval <= 0 || done%cfg.Interval != 0 {
+			return
+		}
+		reason = "interval"
+	}
+	a.writeCheckpoint(nil, done, lifecycle, message, reason)
+}
+
+func (a *Agent) writeCheckpoint(history []*genai.Content, turns int, lifecycle *PlanLifecycle, message, reason string) {
+	a.toolsMu.Lock()
+	toolsUsed := append([]string(nil), a.toolsUsed...)
+	a.toolsMu.Unlock()
+
+	var tree *PlanTree
+	if lifecycle != nil {
+		tree = lifecycle.Tree
+	}
+	cp, err := SaveAgentCheckpoint(a.name, history, turns, a.config.MaxTurns,
+		toolsUsed, a.scratchpad, a.config.Memory, tree, reason, "")
+	if err != nil {
+		slog.Warn("failed to create checkpoint", "agent", a.name, "error", err)
+		return
+	}
+	cp.Message = message
+	if lifecycle != nil {
+		cp.Lifecycle = lifecycle.Snapshot()
+	}
+
+	dir := a.checkpointCfg.Directory
+	if err := saveCheckpointToDisk(cp, dir); err != nil {
+		slog.Warn("failed to save checkpoint", "agent", a.name, "error", err)
+		return
+	}
+	a.lastCheckpoint = checkpointFilePath(dir, cp.ID)
+
+	if a.checkpointCfg.MaxCheckpoints > 0 {
+		if err := CleanupAgentCheckpoints(dir, a.name, a.checkpointCfg.MaxCheckpoints); err != nil {
+			slog.Warn("failed to clean up checkpoints", "dir", dir, "error", err)
+		}
+	}
+}
+
+// pendingFunctionResponses returns the tool results of the last tool
+// exchange in history.
+func pendingFunctionResponses(history []*genai.Content) []*genai.FunctionResponse {
+	for i := len(history) - 1; i >= 0; i-- {
+		var results []*genai.FunctionResponse
+		for _, part := range history[i].Parts {
+			if part.FunctionResponse != nil {
+				results = append(results, part.FunctionResponse)
+			}
+		}
+		if len(results) > 0 {
+			return results
+		}
+		if history[i].Role == "model" {
+			return nil
+		}
+	}
+	return nil
+}
+
+// completedOutputs collects the outputs of nodes that already completed.
+func completedOutputs(tree *PlanTree) []string {
+	var outputs []string
+	var walk func(node *PlanNode)
+	walk = func(node *PlanNode) {
+		if node.Status == PlanNodeCompleted && node.Result != nil && node.Result.Output != "" {
+			outputs = append(outputs, node.Result.Output)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	if tree.Root != nil {
+		walk(tree.Root)
+	}
+	return outputs
+}
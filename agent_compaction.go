@@ -0,0 +1,44 @@
+package sdk
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/genai"
+)
+
+// HistoryCompactor shrinks a run's conversation history when it grows too
+// large. *context.ContextManager implements it.
+type HistoryCompactor interface {
+	// Optimize returns history, or a shorter replacement such as a summary
+	// of older messages followed by the recent ones.
+	Optimize(ctx context.Context, history []*genai.Content) ([]*genai.Content, error)
+
+	// EstimateHistoryTokens estimates the tokens history takes up.
+	EstimateHistoryTokens(history []*genai.Content) int
+}
+
+// compactHistory runs the compactor, if any, at a turn boundary and reports
+// a compaction as EventSummarization. Entries before synced are already in
+// the session, so the returned position is the end of the new history.
+func (a *Agent) compactHistory(ctx context.Context, history []*genai.Content, synced int) ([]*genai.Content, int) {
+	if a.compactor == nil {
+		return history, synced
+	}
+	compacted, err := a.compactor.Optimize(ctx, history)
+	if err != nil {
+		slog.Warn("history compaction failed", "error", err)
+		return history, synced
+	}
+	if len(compacted) == len(history) {
+		return history, synced
+	}
+
+	a.emit(Event{Type: EventSummarization, Summarization: &SummarizationEvent{
+		MessagesBefore: len(history),
+		MessagesAfter:  len(compacted),
+		TokensBefore:   a.compactor.EstimateHistoryTokens(history),
+		TokensAfter:    a.compactor.EstimateHistoryTokens(compacted),
+	}})
+	return compacted, len(compacted)
+}
@@ -0,0 +1,189 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrAgentStopped is returned by a run that was stopped with Stop(false).
+var ErrAgentStopped = errors.New("agent stopped")
+
+// wrapUpPrompt is sent to the model when a run is stopped gracefully.
+const wrapUpPrompt = "STOP REQUESTED: The user has asked you to stop now. Do not call any more tools. " +
+	"Reply with a short summary of what you have done, what is left unfinished, " +
+	"and anything the user should check."
+
+type stopMode int
+
+const (
+	stopNone stopMode = iota
+	stopGraceful
+	stopNow
+)
+
+// agentControl holds steering requests for an agent's runs.
+type agentControl struct {
+	mu      sync.Mutex
+	inbox   []string
+	resumed chan struct{} // closed by Unpause; nil while not paused
+	stop    stopMode
+	cancel  context.CancelFunc // cancels the active run
+}
+
+// Inject queues a user message. It is added to the conversation before
+// the next model turn of the active run, or of the next run if none is
+// active. A run whose model has just finished answering continues with
+// the injected message instead of returning.
+func (a *Agent) Inject(message string) {
+	a.control.mu.Lock()
+	a.control.inbox = append(a.control.inbox, message)
+	a.control.mu.Unlock()
+}
+
+// Pause holds the agent at the next turn boundary, after the current
+// model response and tool calls finish, until Unpause or Stop is called.
+func (a *Agent) Pause() {
+	a.control.mu.Lock()
+	defer a.control.mu.Unlock()
+	if a.control.resumed == nil {
+		a.control.resumed = make(chan struct{})
+	}
+}
+
+// Unpause releases a paused agent.
+func (a *Agent) Unpause() {
+	a.control.mu.Lock()
+	defer a.control.mu.Unlock()
+	a.resumeLocked()
+}
+
+func (a *Agent) resumeLocked() {
+	if a.control.resumed != nil {
+		close(a.control.resumed)
+		a.control.resumed = nil
+	}
+}
+
+// Paused reports whether the agent has been paused.
+func (a *Agent) Paused() bool {
+	a.control.mu.Lock()
+	defer a.control.mu.Unlock()
+	return a.control.resumed != nil
+}
+
+// Stop ends the active run. A graceful stop lets in-flight tool calls, or
+// the running plan node, finish and then asks the model to wrap up with a
+// summary, which becomes the result text. Otherwise the run is cancelled
+// immediately and returns ErrAgentStopped. Stop also releases a paused
+// agent. It has no effect when no run is active.
+func (a *Agent) Stop(graceful bool) {
+	a.control.mu.Lock()
+	defer a.control.mu.Unlock()
+	if a.control.cancel == nil {
+		return
+	}
+	a.resumeLocked()
+	if graceful {
+		if a.control.stop == stopNone {
+			a.control.stop = stopGraceful
+		}
+		return
+	}
+	a.control.stop = stopNow
+	a.control.cancel()
+}
+
+// startControl makes ctx cancellable by Stop for the duration of a run.
+// The returned function must be called when the run ends.
+func (a *Agent) startControl(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	a.control.mu.Lock()
+	a.control.cancel = cancel
+	a.control.stop = stopNone
+	a.control.mu.Unlock()
+
+	return ctx, func() {
+		a.control.mu.Lock()
+		a.control.cancel = nil
+		a.control.mu.Unlock()
+		cancel()
+	}
+}
+
+// stopped reports a run cancelled by Stop(false) as ErrAgentStopped.
+func (a *Agent) stopped(result *AgentResult, err error) (*AgentResult, error) {
+	a.control.mu.Lock()
+	now := a.control.stop == stopNow
+	a.control.mu.Unlock()
+	if !now {
+		return result, err
+	}
+	if errors.Is(err, context.Canceled) {
+		err = ErrAgentStopped
+	}
+	if result != nil && errors.Is(result.Error, context.Canceled) {
+		result.Error = ErrAgentStopped
+	}
+	return result, err
+}
+
+// stopRequested reports whether Stop was called during the active run.
+func (a *Agent) stopRequested() bool {
+	a.control.mu.Lock()
+	defer a.control.mu.Unlock()
+	return a.control.stop != stopNone
+}
+
+// takeInjected drains the queued messages.
+func (a *Agent) takeInjected() []string {
+	a.control.mu.Lock()
+	defer a.control.mu.Unlock()
+	msgs := a.control.inbox
+	a.control.inbox = nil
+	return msgs
+}
+
+// waitIfPaused blocks while the agent is paused.
+func (a *Agent) waitIfPaused(ctx context.Context) error {
+	a.control.mu.Lock()
+	resumed := a.control.resumed
+	a.control.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+
+	a.emit(Event{Type: EventPaused})
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	a.emit(Event{Type: EventResumed})
+	return nil
+}
+
+// steer applies steering requests at a turn boundary: it waits while the
+// agent is paused, then returns the user message to send next, made of
+// injected messages and, for a graceful stop, the wrap-up request, which
+// comes last. wrapUp reports the latter. The caller sends message after
+// everything already in history, such as tool results.
+func (a *Agent) steer(ctx context.Context) (message string, wrapUp bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	if err := a.waitIfPaused(ctx); err != nil {
+		return "", false, err
+	}
+	var parts []string
+	for _, msg := range a.takeInjected() {
+		parts = append(parts, msg)
+		a.emit(Event{Type: EventInjected, Text: msg})
+	}
+	if a.stopRequested() {
+		parts = append(parts, wrapUpPrompt)
+		wrapUp = true
+	}
+	return strings.Join(parts, "\n\n"), wrapUp, nil
+}
@@ -0,0 +1,256 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// EventType identifies the kind of an agent run event.
+type EventType string
+
+const (
+	EventText           EventType = "text"            // Streamed text delta
+	EventToolCallStart  EventType = "tool_call_start" // A tool is about to run
+	EventToolCallEnd    EventType = "tool_call_end"   // A tool finished
+	EventReflection     EventType = "reflection"      // Reflection injected a recovery hint
+	EventLoopDetected   EventType = "loop_detected"   // Loop detection injected an intervention
+	EventDelegation     EventType = "delegation"      // Work was delegated to a sub-agent
+	EventPlanCreated    EventType = "plan_created"    // A plan was built or rebuilt
+	EventPlanTransition EventType = "plan_transition" // The plan lifecycle changed state
+	EventPlanNode       EventType = "plan_node"       // A plan node changed status
+	EventSummarization  EventType = "summarization"   // History was compacted
+	EventUsage          EventType = "usage"           // Token usage for one model response
+	EventProgress       EventType = "progress"        // Progress snapshot
+	EventInjected       EventType = "injected"        // A message from Inject was added to the conversation
+	EventPaused         EventType = "paused"          // The run is held at a turn boundary by Pause
+	EventResumed        EventType = "resumed"         // A paused run continued
+	EventDone           EventType = "done"            // The run finished
+)
+
+// Event is a single observation from an agent run. Exactly one payload
+// field is set, matching Type. Events are JSON-serializable so they can be
+// forwarded to UIs as-is.
+type Event struct {
+	Type      EventType `json:"type"`
+	AgentID   string    `json:"agent_id"`
+	Timestamp time.Time `json:"timestamp"`
+
+	Text          string              `json:"text,omitempty"`
+	Tool          *ToolEvent          `json:"tool,omitempty"`
+	Intervention  *InterventionEvent  `json:"intervention,omitempty"`
+	Delegation    *DelegationEvent    `json:"delegation,omitempty"`
+	Plan          *PlanEvent          `json:"plan,omitempty"`
+	Summarization *SummarizationEvent `json:"summarization,omitempty"`
+	Usage         *UsageEvent         `json:"usage,omitempty"`
+	Progress      *AgentProgress      `json:"progress,omitempty"`
+	Result        *ResultEvent        `json:"result,omitempty"`
+}
+
+// ToolEvent describes a tool call. Result and Error are only set on
+// EventToolCallEnd.
+type ToolEvent struct {
+	ID      string         `json:"id,omitempty"`
+	Name    string         `json:"name"`
+	Args    map[string]any `json:"args,omitempty"`
+	Result  map[string]any `json:"result,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Success bool           `json:"success"`
+}
+
+// InterventionEvent describes a message injected into the conversation by
+// reflection or loop detection.
+type InterventionEvent struct {
+	Tool       string `json:"tool"`
+	Count      int    `json:"count,omitempty"`      // Repeated calls (loop detection)
+	Category   string `json:"category,omitempty"`   // Error category (reflection)
+	Suggestion string `json:"suggestion,omitempty"` // Recovery suggestion (reflection)
+	Message    string `json:"message"`
+}
+
+// DelegationEvent describes a delegation to a sub-agent.
+type DelegationEvent struct {
+	TargetType AgentType     `json:"target_type"`
+	Reason     string        `json:"reason"`
+	Success    bool          `json:"success"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// PlanEvent describes a plan creation, lifecycle transition or node change.
+type PlanEvent struct {
+	PlanID  string             `json:"plan_id,omitempty"`
+	From    PlanLifecycleState `json:"from,omitempty"`
+	To      PlanLifecycleState `json:"to,omitempty"`
+	NodeID  string             `json:"node_id,omitempty"`
+	Status  PlanNodeStatus     `json:"status,omitempty"`
+	Error   string             `json:"error,omitempty"`
+	Summary string             `json:"summary,omitempty"`
+	Tree    *PlanTreeView      `json:"tree,omitempty"`
+}
+
+// SummarizationEvent describes a history compaction by the agent's
+// HistoryCompactor. Token counts are the compactor's estimates.
+type SummarizationEvent struct {
+	MessagesBefore int `json:"messages_before"`
+	MessagesAfter  int `json:"messages_after"`
+	TokensBefore   int `json:"tokens_before"`
+	TokensAfter    int `json:"tokens_after"`
+}
+
+// UsageEvent reports token usage for one model response.
+type UsageEvent struct {
+	Turn         int `json:"turn"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// ResultEvent is the final outcome of a run.
+type ResultEvent struct {
+	Text     string        `json:"text,omitempty"`
+	Turns    int           `json:"turns"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RunStream runs the agent like Run but reports progress as typed events.
+// The channel is closed after the final EventDone, which is delivered even
+// when ctx is cancelled; consumers must read until the channel is closed.
+// Only one stream can be active per agent at a time.
+func (a *Agent) RunStream(ctx context.Context, message string) (<-chan Event, error) {
+	a.eventsMu.Lock()
+	if a.events != nil {
+		a.eventsMu.Unlock()
+		return nil, fmt.Errorf("agent %s is already streaming a run", a.name)
+	}
+	ch := make(chan Event, 64)
+	a.events = &eventSink{ctx: ctx, ch: ch}
+	a.eventsMu.Unlock()
+
+	go func() {
+		a.Run(ctx, message)
+
+		a.eventsMu.Lock()
+		a.events = nil
+		a.eventsMu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// eventSink delivers events to a RunStream consumer.
+type eventSink struct {
+	ctx context.Context
+	ch  chan Event
+}
+
+// emit stamps ev and delivers it to the event handler and the active
+// stream, if any. Delivery to a stream blocks until the consumer reads
+// or the run's context is done; EventDone is always delivered, since it
+// is the last event before the stream closes.
+func (a *Agent) emit(ev Event) {
+	a.eventsMu.Lock()
+	sink := a.events
+	a.eventsMu.Unlock()
+	if sink == nil && a.onEvent == nil {
+		return
+	}
+
+	ev.AgentID = a.name
+	ev.Timestamp = time.Now()
+	if a.onEvent != nil {
+		a.onEvent(ev)
+	}
+	if sink != nil {
+		if ev.Type == EventDone {
+			sink.ch <- ev
+			return
+		}
+		select {
+		case sink.ch <- ev:
+		case <-sink.ctx.Done():
+		}
+	}
+}
+
+// finishRun emits EventDone for a completed run and passes its result through.
+func (a *Agent) finishRun(result *AgentResult, err error) (*AgentResult, error) {
+	done := &ResultEvent{}
+	if result != nil {
+		done.Text = result.Text
+		done.Turns = result.Turns
+		done.Duration = result.Duration
+		if result.Error != nil {
+			done.Error = result.Error.Error()
+		}
+	}
+	if err != nil {
+		done.Error = err.Error()
+	}
+	a.emit(Event{Type: EventDone, Result: done})
+	return result, err
+}
+
+// collect gathers a model response, emitting text deltas and usage.
+func (a *Agent) collect(ctx context.Context, stream *StreamResponse, turn int) (*Response, error) {
+	resp, err := stream.CollectWith(ctx, func(chunk ResponseChunk) {
+		if chunk.Text != "" {
+			a.emit(Event{Type: EventText, Text: chunk.Text})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.InputTokens > 0 || resp.OutputTokens > 0 {
+		a.emit(Event{Type: EventUsage, Usage: &UsageEvent{
+			Turn:         turn,
+			InputTokens:  resp.InputTokens,
+			OutputTokens: resp.OutputTokens,
+		}})
+	}
+	return resp, nil
+}
+
+// emitToolEnd reports the outcome of a tool call from its function response.
+func (a *Agent) emitToolEnd(fc *genai.FunctionCall, resp *genai.FunctionResponse) {
+	errMsg := extractErrorFromFuncResponse(resp)
+	a.emit(Event{Type: EventToolCallEnd, Tool: &ToolEvent{
+		ID:      fc.ID,
+		Name:    fc.Name,
+		Args:    fc.Args,
+		Result:  resp.Response,
+		Error:   errMsg,
+		Success: errMsg == "",
+	}})
+}
+
+// attachLifecycle forwards lifecycle transitions to the transition
+// callback and the event stream.
+func (a *Agent) attachLifecycle(lifecycle *PlanLifecycle) {
+	planID := lifecycle.PlanID
+	lifecycle.OnTransition(func(from, to PlanLifecycleState) {
+		if a.onPlanTransition != nil {
+			a.onPlanTransition(from, to)
+		}
+		a.emit(Event{Type: EventPlanTransition, Plan: &PlanEvent{PlanID: planID, From: from, To: to}})
+	})
+}
+
+// emitPlanCreated reports a newly built plan tree.
+func (a *Agent) emitPlanCreated(planID string, tree *PlanTree) {
+	a.emit(Event{Type: EventPlanCreated, Plan: &PlanEvent{
+		PlanID:  planID,
+		Summary: a.planner.Summary(tree),
+		Tree:    tree.View(),
+	}})
+}
+
+// emitPlanNode reports a plan node status change.
+func (a *Agent) emitPlanNode(planID string, node *PlanNode) {
+	ev := &PlanEvent{PlanID: planID, NodeID: node.ID, Status: node.Status}
+	if node.Result != nil {
+		ev.Error = node.Result.Error
+	}
+	a.emit(Event{Type: EventPlanNode, Plan: ev})
+}
@@ -1,6 +1,11 @@
 package sdk
 
-import "time"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
 
 // AgentOption configures an Agent.
 type AgentOption func(*Agent)
@@ -92,6 +97,22 @@ func WithScratchpad(initial string) AgentOption {
 	}
 }
 
+// WithSession binds the agent to a conversation session. Each Run continues
+// from the session's history and appends its turns, tool calls and results
+// to it, so follow-up messages keep their context.
+func WithSession(s *Session) AgentOption {
+	return func(a *Agent) {
+		a.session = s
+	}
+}
+
+// WithSessionStore persists the bound session after every change.
+func WithSessionStore(store *SessionStore) AgentOption {
+	return func(a *Agent) {
+		a.sessionStore = store
+	}
+}
+
 // WithPlanner attaches a planner for plan-driven execution.
 func WithPlanner(p *Planner) AgentOption {
 	return func(a *Agent) {
@@ -99,6 +120,103 @@ func WithPlanner(p *Planner) AgentOption {
 	}
 }
 
+// WithPlanTransitionCallback sets a callback invoked on every plan lifecycle
+// state change, including the failed → draft → approved → executing cycle
+// that follows a replan.
+func WithPlanTransitionCallback(fn func(from, to PlanLifecycleState)) AgentOption {
+	return func(a *Agent) {
+		a.onPlanTransition = fn
+	}
+}
+
+// WithVerifier replaces the verifier used for verify plan nodes.
+// By default verify checks run through the agent's own tool registry.
+func WithVerifier(v Verifier) AgentOption {
+	return func(a *Agent) {
+		a.verifier = v
+	}
+}
+
+// WithWorkDir sets the directory the agent's tools work in. Relative paths
+// in verify checks are resolved against it; it should match the directory
+// the registry's tools were created with.
+func WithWorkDir(dir string) AgentOption {
+	return func(a *Agent) {
+		a.workDir = dir
+	}
+}
+
+// WithCheckpointing enables automatic checkpoints during runs. Checkpoints
+// are written to cfg.Directory (default: a temp directory) and can be
+// passed to Agent.Resume.
+func WithCheckpointing(cfg CheckpointConfig) AgentOption {
+	return func(a *Agent) {
+		cfg.Enabled = true
+		if cfg.Interval <= 0 && !cfg.AfterToolBatch {
+			cfg.Interval = DefaultCheckpointConfig().Interval
+		}
+		if cfg.Directory == "" {
+			cfg.Directory = filepath.Join(os.TempDir(), "gokin-checkpoints")
+		}
+		a.checkpointCfg = cfg
+	}
+}
+
+// WithEventHandler adds a handler that receives every event of every run,
+// in addition to any RunStream consumer. Handlers added by earlier options
+// are kept and called first.
+func WithEventHandler(fn func(Event)) AgentOption {
+	return func(a *Agent) {
+		if prev := a.onEvent; prev != nil {
+			a.onEvent = func(ev Event) {
+				prev(ev)
+				fn(ev)
+			}
+			return
+		}
+		a.onEvent = fn
+	}
+}
+
+// WithInteraction routes the agent's human interactions (ask_user questions,
+// permission prompts) through i.
+func WithInteraction(i Interaction) AgentOption {
+	return func(a *Agent) {
+		a.interaction = i
+	}
+}
+
+// WithReviewMode makes every file change by write, edit, batch and
+// apply_patch wait for approval. approver receives each change with its
+// diff; when nil, changes are confirmed through the agent's Interaction,
+// and without one they are rejected. Tools with their own approver set via
+// SetApprover keep using it.
+func WithReviewMode(approver ChangeApprover) AgentOption {
+	return func(a *Agent) {
+		a.reviewMode = true
+		a.approver = approver
+	}
+}
+
+// WithHistoryCompactor shrinks the conversation history at turn
+// boundaries once it grows too large, e.g. with a *context.ContextManager.
+// Each compaction is reported as an EventSummarization.
+func WithHistoryCompactor(c HistoryCompactor) AgentOption {
+	return func(a *Agent) {
+		a.compactor = c
+	}
+}
+
+// WithBackgroundTasks gives the agent a task manager for background
+// commands and hands it to the registered tools that take one (bash,
+// task_output, task_stop). Running tasks are stopped when ctx is done or
+// when the agent is closed; see Agent.Close.
+func WithBackgroundTasks(ctx context.Context) AgentOption {
+	return func(a *Agent) {
+		a.tasksCtx = ctx
+	}
+}
+
 // WithPlanApprovalCallback sets a callback for plan approval notifications.
 func WithPlanApprovalCallback(fn func(string)) AgentOption {
 	return func(a *Agent) {
@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/genai"
+)
+
+// Chat sends a message as the next turn of the agent's conversation.
+// If no session is bound yet, a new one is created, so repeated calls to
+// Chat keep the full context, including earlier tool calls and results.
+func (a *Agent) Chat(ctx context.Context, message string) (*AgentResult, error) {
+	if a.session == nil {
+		a.session = NewSession("")
+	}
+	return a.Run(ctx, message)
+}
+
+// Session returns the session the agent is bound to, or nil.
+func (a *Agent) Session() *Session {
+	return a.session
+}
+
+// sessionHistory returns the history a run should start from.
+func (a *Agent) sessionHistory() []*genai.Content {
+	if a.session == nil {
+		return make([]*genai.Content, 0)
+	}
+	return trimToTurnBoundary(a.session.GetHistory())
+}
+
+// syncSession appends history entries added since synced to the session
+// and persists it. It returns the new synced position.
+func (a *Agent) syncSession(history []*genai.Content, synced int) int {
+	if a.session == nil || synced >= len(history) {
+		return synced
+	}
+	for _, content := range history[synced:] {
+		a.session.AddContent(content)
+	}
+	a.saveSession()
+	return len(history)
+}
+
+// saveSession persists the bound session if a store is configured.
+func (a *Agent) saveSession() {
+	if a.session == nil || a.sessionStore == nil {
+		return
+	}
+	if err := a.sessionStore.Save(a.session); err != nil {
+		slog.Warn("failed to save session", "session", a.session.ID(), "error", err)
+	}
+}
+
+// trimToTurnBoundary drops entries before the first user text turn.
+// Session trimming can cut a function call away from its response or leave
+// a model reply first, and providers reject histories that do not start
+// with a user message.
+func trimToTurnBoundary(history []*genai.Content) []*genai.Content {
+	for i, content := range history {
+		if content.Role == "user" && !hasToolParts(content) {
+			return history[i:]
+		}
+	}
+	return make([]*genai.Content, 0)
+}
+
+func hasToolParts(content *genai.Content) bool {
+	for _, part := range content.Parts {
+		if part.FunctionCall != nil || part.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package sdk
+
+import (
+	"context"
+
+	"github.com/ginkida/gokin-sdk/tasks"
+)
+
+// taskManagerSetter is implemented by tools that run background commands
+// (bash, task_output, task_stop).
+type taskManagerSetter interface {
+	SetTaskManager(m *tasks.Manager)
+}
+
+// startTasks creates the agent's task manager, tied to parent and to
+// Close, and hands it to the registry's tools.
+func (a *Agent) startTasks(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	a.tasks = tasks.NewManagerWithContext(ctx, a.workDir)
+	a.stopTasks = cancel
+	for _, tool := range a.registry.List() {
+		if t, ok := tool.(taskManagerSetter); ok {
+			t.SetTaskManager(a.tasks)
+		}
+	}
+}
+
+// Tasks returns the manager of the agent's background tasks, or nil
+// without WithBackgroundTasks.
+func (a *Agent) Tasks() *tasks.Manager {
+	return a.tasks
+}
+
+// Close stops the agent's background tasks and waits for them to exit,
+// until ctx is done. The agent should not be run afterwards.
+func (a *Agent) Close(ctx context.Context) error {
+	if a.tasks == nil {
+		return nil
+	}
+	a.stopTasks()
+	return a.tasks.Shutdown(ctx)
+}
@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChangeApprover decides whether a pending file change may be applied. It
+// receives the summary of the change, with its unified diff in
+// summary.Diff, before any file is touched. Returning false rejects the
+// change.
+//
+// Tools that modify files (write, edit, batch, apply_patch, lsp_rename)
+// accept an approver through SetApprover and otherwise use the one carried
+// by the run context; see WithReviewMode.
+type ChangeApprover func(ctx context.Context, summary *ExecutionSummary) (bool, error)
+
+type changeApproverKey struct{}
+
+// ContextWithChangeApprover returns a context carrying approver.
+func ContextWithChangeApprover(ctx context.Context, approver ChangeApprover) context.Context {
+	return context.WithValue(ctx, changeApproverKey{}, approver)
+}
+
+// ChangeApproverFromContext returns the ChangeApprover carried by ctx, or nil.
+func ChangeApproverFromContext(ctx context.Context) ChangeApprover {
+	approver, _ := ctx.Value(changeApproverKey{}).(ChangeApprover)
+	return approver
+}
+
+// InteractionApprover asks i to confirm each change, showing its diff. The
+// default answer is no.
+func InteractionApprover(i Interaction) ChangeApprover {
+	return func(ctx context.Context, summary *ExecutionSummary) (bool, error) {
+		question := fmt.Sprintf("%s %s?", summary.Action, summary.Target)
+		if summary.Diff != "" {
+			question = fmt.Sprintf("%s\n\nApply this change?", summary.Diff)
+		}
+		return i.Confirm(ctx, question, false)
+	}
+}
+
+// rejectChanges is the review-mode approver used when the agent has no way
+// to ask anyone.
+func rejectChanges(ctx context.Context, summary *ExecutionSummary) (bool, error) {
+	return false, fmt.Errorf("review mode: no approver or interaction configured")
+}
+
+// reviewApprover returns the approver that review mode installs, or nil when
+// review mode is off.
+func (a *Agent) reviewApprover() ChangeApprover {
+	if !a.reviewMode {
+		return nil
+	}
+	if a.approver != nil {
+		return a.approver
+	}
+	if a.interaction != nil {
+		return InteractionApprover(a.interaction)
+	}
+	return rejectChanges
+}
@@ -12,8 +12,10 @@ import (
 
 // GrepResult represents cached grep search results.
 type GrepResult struct {
+	Root      string // directory or file searched
 	Matches   []GrepMatch
 	FileCount int
+	Truncated bool // collection stopped at a match limit
 	CachedAt  time.Time
 }
 
@@ -26,6 +28,7 @@ type GrepMatch struct {
 
 // GlobResult represents cached glob search results.
 type GlobResult struct {
+	Root     string // directory searched
 	Files    []string
 	CachedAt time.Time
 }
@@ -135,6 +138,14 @@ func GrepKey(pattern, path, glob string, caseInsensitive bool, contextLines int)
 	return hex.EncodeToString(hash[:])
 }
 
+// GrepQueryKey generates a cache key for a grep query. pattern is the final
+// regular expression, so flags such as case-insensitivity are part of it.
+func GrepQueryKey(pattern, path, glob, fileType string, multiline bool) string {
+	data := fmt.Sprintf("grepq:%s:%s:%s:%s:%v", pattern, path, glob, fileType, multiline)
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
 // GlobKey generates a cache key for glob operations.
 func GlobKey(pattern, path string) string {
 	data := fmt.Sprintf("glob:%s:%s", pattern, path)
@@ -242,6 +253,44 @@ func (c *SearchCache) InvalidateByDir(dir string) {
 	}
 }
 
+// InvalidateByRoot invalidates all entries whose search root contains path
+// or lies below it. Unlike InvalidateByPath it also catches files that did
+// not match before, so it suits created files and edits that may add
+// matches.
+func (c *SearchCache) InvalidateByRoot(path string) {
+	if !c.enabled {
+		return
+	}
+
+	var keys []string
+	c.grepCache.Remove(func(key string, r GrepResult) bool {
+		if isWithin(r.Root, path) || isWithin(path, r.Root) {
+			keys = append(keys, key)
+			return true
+		}
+		return false
+	})
+	c.globCache.Remove(func(key string, r GlobResult) bool {
+		if isWithin(r.Root, path) || isWithin(path, r.Root) {
+			keys = append(keys, key)
+			return true
+		}
+		return false
+	})
+	for _, key := range keys {
+		c.removeKeyFromIndex(key)
+	}
+}
+
+// isWithin reports whether path is root or lies below it.
+func isWithin(root, path string) bool {
+	if root == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && (rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))))
+}
+
 // Clear clears all cached entries.
 func (c *SearchCache) Clear() {
 	c.grepCache.Clear()
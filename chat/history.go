@@ -6,8 +6,14 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	sdk "github.com/ginkida/gokin-sdk"
 )
 
+// workDirTagPrefix marks the tag that records a session's working directory
+// in a SessionBackend.
+const workDirTagPrefix = "workdir:"
+
 // HistoryEntry represents a saved history entry.
 type HistoryEntry struct {
 	Role      string    `json:"role"`
@@ -26,6 +32,7 @@ type HistoryFile struct {
 // HistoryManager manages session history persistence.
 type HistoryManager struct {
 	dataDir string
+	backend sdk.SessionBackend // full session states; nil means one file per session
 }
 
 // NewHistoryManager creates a new history manager.
@@ -46,6 +53,19 @@ func NewHistoryManager() (*HistoryManager, error) {
 	}, nil
 }
 
+// NewHistoryManagerWithBackend creates a history manager that keeps full
+// session states in backend instead of one file per session, so listing
+// and searching sessions does not scan a directory. A session's WorkDir is
+// stored as a "workdir:<dir>" tag.
+func NewHistoryManagerWithBackend(backend sdk.SessionBackend) (*HistoryManager, error) {
+	m, err := NewHistoryManager()
+	if err != nil {
+		return nil, err
+	}
+	m.backend = backend
+	return m, nil
+}
+
 // Save saves a session history to disk.
 func (m *HistoryManager) Save(session *Session) error {
 	history := session.GetHistory()
@@ -155,8 +175,21 @@ func getSessionsDir() (string, error) {
 	return filepath.Join(homeDir, ".local", "share", "gokin", "sessions"), nil
 }
 
-// SaveFull saves a complete session state including all content.
+// SaveFull saves a complete session state including all content. With a
+// backend, it fails with sdk.ErrSessionConflict if the session was saved
+// by another writer since it was loaded.
 func (m *HistoryManager) SaveFull(session *Session) error {
+	if m.backend != nil {
+		state := session.GetState()
+		if err := m.putState(state); err != nil {
+			return err
+		}
+		session.mu.Lock()
+		session.savedVersion = state.Version
+		session.mu.Unlock()
+		return nil
+	}
+
 	sessionsDir, err := getSessionsDir()
 	if err != nil {
 		return err
@@ -182,6 +215,10 @@ func (m *HistoryManager) SaveFull(session *Session) error {
 
 // LoadFull loads a complete session state.
 func (m *HistoryManager) LoadFull(sessionID string) (*SessionState, error) {
+	if m.backend != nil {
+		return m.getState(sessionID)
+	}
+
 	sessionsDir, err := getSessionsDir()
 	if err != nil {
 		return nil, err
@@ -204,6 +241,18 @@ func (m *HistoryManager) LoadFull(sessionID string) (*SessionState, error) {
 
 // ListSessions returns information about all saved sessions.
 func (m *HistoryManager) ListSessions() ([]SessionInfo, error) {
+	if m.backend != nil {
+		page, err := m.backend.Query(sdk.SessionQuery{})
+		if err != nil {
+			return nil, err
+		}
+		sessions := make([]SessionInfo, 0, len(page.Sessions))
+		for _, meta := range page.Sessions {
+			sessions = append(sessions, sessionInfoFromMeta(meta))
+		}
+		return sessions, nil
+	}
+
 	sessionsDir, err := getSessionsDir()
 	if err != nil {
 		return nil, err
@@ -246,6 +295,10 @@ func (m *HistoryManager) ListSessions() ([]SessionInfo, error) {
 
 // DeleteSession deletes a saved session.
 func (m *HistoryManager) DeleteSession(sessionID string) error {
+	if m.backend != nil {
+		return m.backend.Delete(sessionID)
+	}
+
 	sessionsDir, err := getSessionsDir()
 	if err != nil {
 		return err
@@ -254,3 +307,124 @@ func (m *HistoryManager) DeleteSession(sessionID string) error {
 	filename := filepath.Join(sessionsDir, sessionID+".json")
 	return os.Remove(filename)
 }
+
+// QuerySessions returns metadata for the saved sessions matching q, most
+// recently active first. Titles are the session summaries. Without a
+// backend, every session file is read.
+func (m *HistoryManager) QuerySessions(q sdk.SessionQuery) (*sdk.SessionPage, error) {
+	if m.backend != nil {
+		return m.backend.Query(q)
+	}
+
+	sessions, err := m.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []sdk.SessionMeta
+	for _, info := range sessions {
+		state, err := m.LoadFull(info.ID)
+		if err != nil {
+			continue // Skip invalid files
+		}
+		meta := stateMeta(state)
+		if q.Match(&meta, strings.ToLower(historyText(state.History))) {
+			matches = append(matches, meta)
+		}
+	}
+	return q.Page(matches), nil
+}
+
+// putState stores a full session state in the backend. The history goes
+// into the record's messages, where backends index its text; the rest of
+// the state is stored alongside. The write fails with sdk.ErrSessionConflict
+// if the stored session changed since state.SavedVersion.
+func (m *HistoryManager) putState(state *SessionState) error {
+	messages, err := json.Marshal(state.History)
+	if err != nil {
+		return err
+	}
+	rest := *state
+	rest.History = nil
+	data, err := json.Marshal(&rest)
+	if err != nil {
+		return err
+	}
+
+	return m.backend.Put(&sdk.SessionRecord{
+		SessionMeta: stateMeta(state),
+		Messages:    messages,
+		State:       data,
+		Text:        historyText(state.History),
+	}, state.SavedVersion)
+}
+
+// getState loads a full session state from the backend.
+func (m *HistoryManager) getState(sessionID string) (*SessionState, error) {
+	rec, err := m.backend.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var state SessionState
+	if len(rec.State) > 0 {
+		if err := json.Unmarshal(rec.State, &state); err != nil {
+			return nil, err
+		}
+	}
+	if len(rec.Messages) > 0 {
+		if err := json.Unmarshal(rec.Messages, &state.History); err != nil {
+			return nil, err
+		}
+	}
+	state.ID = rec.ID
+	state.SavedVersion = rec.Version
+	return &state, nil
+}
+
+// stateMeta describes a session state for a SessionBackend.
+func stateMeta(state *SessionState) sdk.SessionMeta {
+	meta := sdk.SessionMeta{
+		ID:           state.ID,
+		Title:        state.Summary,
+		CreatedAt:    state.StartTime,
+		UpdatedAt:    state.LastActive,
+		MessageCount: len(state.History),
+		Version:      state.Version,
+	}
+	if state.WorkDir != "" {
+		meta.Tags = []string{workDirTagPrefix + state.WorkDir}
+	}
+	return meta
+}
+
+// sessionInfoFromMeta converts backend metadata to a SessionInfo.
+func sessionInfoFromMeta(meta sdk.SessionMeta) SessionInfo {
+	info := SessionInfo{
+		ID:           meta.ID,
+		StartTime:    meta.CreatedAt,
+		LastActive:   meta.UpdatedAt,
+		Summary:      meta.Title,
+		MessageCount: meta.MessageCount,
+	}
+	for _, tag := range meta.Tags {
+		if dir, ok := strings.CutPrefix(tag, workDirTagPrefix); ok {
+			info.WorkDir = dir
+		}
+	}
+	return info
+}
+
+// historyText joins the text parts of a serialized history for search.
+func historyText(history []SerializedContent) string {
+	var sb strings.Builder
+	for _, content := range history {
+		for _, part := range content.Parts {
+			if part.Type == "text" && strings.TrimSpace(part.Text) != "" {
+				sb.WriteString(part.Text)
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return sb.String()
+}
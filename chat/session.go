@@ -39,6 +39,7 @@ type Session struct {
 	tokenCounts       []int               // tokens per message
 	totalTokens       int                 // cached total
 	version           int64               // version for optimistic concurrency control
+	savedVersion      int64               // version last loaded from or saved to a backend
 	onChange          ChangeHandler
 	scratchpad        string
 	mu                sync.RWMutex
@@ -349,11 +350,25 @@ func (s *Session) GetState() *SessionState {
 		TokenCounts:       make([]int, len(s.tokenCounts)),
 		TotalTokens:       s.totalTokens,
 		Version:           s.version,
+		SavedVersion:      s.savedVersion,
 		Scratchpad:        s.scratchpad,
 		SystemInstruction: s.SystemInstruction,
 	}
 	copy(state.TokenCounts, s.tokenCounts)
 
+	if len(s.Branches) > 0 {
+		state.Branches = make(map[string]*SessionState, len(s.Branches))
+		for name, branch := range s.Branches {
+			state.Branches[name] = branch.GetState()
+		}
+	}
+	if len(s.Checkpoints) > 0 {
+		state.Checkpoints = make(map[string]int, len(s.Checkpoints))
+		for name, idx := range s.Checkpoints {
+			state.Checkpoints[name] = idx
+		}
+	}
+
 	// Generate summary
 	state.Summary = state.GenerateSummary()
 
@@ -382,9 +397,29 @@ func (s *Session) RestoreFromState(state *SessionState) error {
 	copy(s.tokenCounts, state.TokenCounts)
 	s.totalTokens = state.TotalTokens
 	s.version = state.Version
+	s.savedVersion = state.SavedVersion
 	s.scratchpad = state.Scratchpad
 	s.SystemInstruction = state.SystemInstruction
 
+	s.Branches = nil
+	if len(state.Branches) > 0 {
+		s.Branches = make(map[string]*Session, len(state.Branches))
+		for name, bs := range state.Branches {
+			branch := &Session{}
+			if err := branch.RestoreFromState(bs); err != nil {
+				return err
+			}
+			s.Branches[name] = branch
+		}
+	}
+	s.Checkpoints = nil
+	if len(state.Checkpoints) > 0 {
+		s.Checkpoints = make(map[string]int, len(state.Checkpoints))
+		for name, idx := range state.Checkpoints {
+			s.Checkpoints[name] = idx
+		}
+	}
+
 	return nil
 }
 
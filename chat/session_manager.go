@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	sdk "github.com/ginkida/gokin-sdk"
 	"github.com/ginkida/gokin-sdk/logging"
 )
 
@@ -30,6 +31,9 @@ type SessionManagerConfig struct {
 	AutoLoad        bool          // Auto-load last session on startup
 	MaxSessionAge   time.Duration // Maximum age for sessions before cleanup (default: 30 days)
 	MaxSessionCount int           // Maximum number of sessions to keep (default: 50)
+
+	// Backend stores sessions instead of one file per session (optional)
+	Backend sdk.SessionBackend
 }
 
 // DefaultSessionManagerConfig returns default configuration.
@@ -45,7 +49,13 @@ func DefaultSessionManagerConfig() SessionManagerConfig {
 
 // NewSessionManager creates a new session manager.
 func NewSessionManager(session *Session, config SessionManagerConfig) (*SessionManager, error) {
-	historyMgr, err := NewHistoryManager()
+	var historyMgr *HistoryManager
+	var err error
+	if config.Backend != nil {
+		historyMgr, err = NewHistoryManagerWithBackend(config.Backend)
+	} else {
+		historyMgr, err = NewHistoryManager()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create history manager: %w", err)
 	}
@@ -17,9 +17,14 @@ type SessionState struct {
 	TokenCounts       []int               `json:"token_counts,omitempty"`
 	TotalTokens       int                 `json:"total_tokens"`
 	Version           int64               `json:"version"`
+	SavedVersion      int64               `json:"saved_version,omitempty"` // version last loaded from or saved to a backend
 	Summary           string              `json:"summary,omitempty"`
 	Scratchpad        string              `json:"scratchpad,omitempty"`
 	SystemInstruction string              `json:"system_instruction,omitempty"`
+
+	// Branch tree and named checkpoints
+	Branches    map[string]*SessionState `json:"branches,omitempty"`
+	Checkpoints map[string]int           `json:"checkpoints,omitempty"`
 }
 
 // SerializedContent represents a serializable conversation content.
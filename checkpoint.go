@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"google.golang.org/genai"
@@ -12,22 +13,24 @@ import (
 
 // AgentCheckpoint captures a full snapshot of agent state for save/restore.
 type AgentCheckpoint struct {
-	ID            string                    `json:"id"`
-	AgentState    *SerializedAgentState     `json:"agent_state"`
-	SharedMemory  map[string]*SharedEntry   `json:"shared_memory,omitempty"`
-	PlanState     *PlanTree                 `json:"plan_state,omitempty"`
-	Timestamp     time.Time                 `json:"timestamp"`
-	TriggerReason string                    `json:"trigger_reason"`
-	TurnNumber    int                       `json:"turn_number"`
+	ID            string                  `json:"id"`
+	AgentState    *SerializedAgentState   `json:"agent_state"`
+	SharedMemory  map[string]*SharedEntry `json:"shared_memory,omitempty"`
+	PlanState     *PlanTree               `json:"plan_state,omitempty"`
+	Lifecycle     *PlanLifecycleSnapshot  `json:"lifecycle,omitempty"`
+	Message       string                  `json:"message,omitempty"`
+	Timestamp     time.Time               `json:"timestamp"`
+	TriggerReason string                  `json:"trigger_reason"`
+	TurnNumber    int                     `json:"turn_number"`
 }
 
 // SerializedAgentState holds the serializable parts of agent execution state.
 type SerializedAgentState struct {
-	History   []SerializedContent `json:"history"`
-	MaxTurns  int                 `json:"max_turns"`
-	TurnCount int                 `json:"turn_count"`
-	ToolsUsed []string            `json:"tools_used,omitempty"`
-	Scratchpad string             `json:"scratchpad,omitempty"`
+	History    []SerializedContent `json:"history"`
+	MaxTurns   int                 `json:"max_turns"`
+	TurnCount  int                 `json:"turn_count"`
+	ToolsUsed  []string            `json:"tools_used,omitempty"`
+	Scratchpad string              `json:"scratchpad,omitempty"`
 }
 
 // CheckpointConfig configures automatic checkpointing behavior.
@@ -38,6 +41,10 @@ type CheckpointConfig struct {
 	// Interval is the number of turns between auto-checkpoints.
 	Interval int
 
+	// AfterToolBatch saves a checkpoint after every batch of tool results
+	// (or every executed plan node), regardless of Interval.
+	AfterToolBatch bool
+
 	// Directory is where checkpoint files are saved.
 	Directory string
 
@@ -69,7 +76,8 @@ func SaveAgentCheckpoint(
 	dir string,
 ) (*AgentCheckpoint, error) {
 	cp := &AgentCheckpoint{
-		ID: fmt.Sprintf("%s-%s", agentName, generateID()),
+		// Timestamp keeps checkpoint files in creation order for cleanup.
+		ID: fmt.Sprintf("%s-%d-%s", agentName, time.Now().UnixNano(), generateID()),
 		AgentState: &SerializedAgentState{
 			History:    SerializeHistory(history),
 			MaxTurns:   maxTurns,
@@ -141,18 +149,56 @@ func ListCheckpoints(dir string) ([]string, error) {
 	return matches, nil
 }
 
+// ListAgentCheckpoints lists the checkpoint files in dir that were written
+// by the agent named agentName, oldest first.
+func ListAgentCheckpoints(dir, agentName string) ([]string, error) {
+	files, err := ListCheckpoints(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// IDs are "<agent>-<unix nanos>-<random>", see SaveAgentCheckpoint
+	own := regexp.MustCompile(`^checkpoint-` + regexp.QuoteMeta(agentName) + `-\d+-[0-9a-f]+\.json$`)
+	var matches []string
+	for _, f := range files {
+		if own.MatchString(filepath.Base(f)) {
+			matches = append(matches, f)
+		}
+	}
+	return matches, nil
+}
+
 // CleanupOldCheckpoints removes old checkpoints keeping only the most recent maxKeep.
+// It prunes every checkpoint in dir; use CleanupAgentCheckpoints when dir
+// is shared with other agents.
 func CleanupOldCheckpoints(dir string, maxKeep int) error {
 	files, err := ListCheckpoints(dir)
 	if err != nil {
 		return err
 	}
+	return removeOldest(files, maxKeep)
+}
+
+// CleanupAgentCheckpoints removes the agent's old checkpoints in dir,
+// keeping only its most recent maxKeep. Other agents' checkpoints are left
+// alone.
+func CleanupAgentCheckpoints(dir, agentName string, maxKeep int) error {
+	files, err := ListAgentCheckpoints(dir, agentName)
+	if err != nil {
+		return err
+	}
+	return removeOldest(files, maxKeep)
+}
+
+// --- internal ---
 
+// removeOldest removes all but the last maxKeep of files, which are sorted
+// oldest first.
+func removeOldest(files []string, maxKeep int) error {
 	if len(files) <= maxKeep {
 		return nil
 	}
 
-	// Remove oldest (files are sorted by glob, oldest first)
 	toRemove := files[:len(files)-maxKeep]
 	for _, f := range toRemove {
 		if err := os.Remove(f); err != nil {
@@ -163,20 +209,68 @@ func CleanupOldCheckpoints(dir string, maxKeep int) error {
 	return nil
 }
 
-// --- internal ---
-
+// saveCheckpointToDisk writes cp atomically: a crash leaves either the
+// complete checkpoint or no file, never a truncated one.
 func saveCheckpointToDisk(cp *AgentCheckpoint, dir string) error {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	filename := fmt.Sprintf("checkpoint-%s.json", cp.ID)
-	path := filepath.Join(dir, filename)
+	path := checkpointFilePath(dir, cp.ID)
 
 	data, err := json.MarshalIndent(cp, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal checkpoint: %w", err)
 	}
 
-	return os.WriteFile(path, data, 0644)
+	// The temp name does not match ListCheckpoints, so a leftover is never
+	// mistaken for a checkpoint.
+	tmp, err := os.CreateTemp(dir, ".checkpoint-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func checkpointFilePath(dir, id string) string {
+	return filepath.Join(dir, fmt.Sprintf("checkpoint-%s.json", id))
+}
+
+// restorePlanTree rebuilds the node index of a deserialized plan tree.
+// Nodes that were running when the checkpoint was taken are reset to
+// pending so they run again.
+func restorePlanTree(tree *PlanTree) {
+	tree.nodeIndex = make(map[string]*PlanNode)
+	tree.TotalNodes = 0
+	var walk func(node *PlanNode)
+	walk = func(node *PlanNode) {
+		if node.Status == PlanNodeRunning {
+			node.Status = PlanNodePending
+		}
+		tree.nodeIndex[node.ID] = node
+		tree.TotalNodes++
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	if tree.Root != nil {
+		walk(tree.Root)
+	}
 }
@@ -24,6 +24,7 @@ type Config struct {
 	Semantic    SemanticConfig    `yaml:"semantic"`
 	Contract    ContractConfig    `yaml:"contract"`
 	MCP         MCPConfig         `yaml:"mcp"`
+	LSP         LSPConfig         `yaml:"lsp"`
 	Update      UpdateConfig      `yaml:"update"`
 
 	// Runtime version information
@@ -342,6 +343,21 @@ type MCPServerConfig struct {
 	ToolPrefix  string            `yaml:"tool_prefix,omitempty"` // Prefix for tool names
 }
 
+// LSPConfig holds language server settings.
+type LSPConfig struct {
+	Enabled bool              `yaml:"enabled"` // Enable/disable the lsp and lsp_rename tools
+	Servers []LSPServerConfig `yaml:"servers"` // Language servers; empty uses built-in defaults
+}
+
+// LSPServerConfig holds configuration for a single language server.
+type LSPServerConfig struct {
+	Name      string            `yaml:"name"`           // Unique identifier
+	Command   string            `yaml:"command"`        // Server executable, speaking LSP over stdio
+	Args      []string          `yaml:"args,omitempty"` // Command arguments
+	Env       map[string]string `yaml:"env,omitempty"`  // Additional env vars
+	Languages []string          `yaml:"languages"`      // Language IDs served, e.g. "go", "python"
+}
+
 // UpdateConfig holds self-update settings.
 type UpdateConfig struct {
 	Enabled           bool          `yaml:"enabled"`            // Enable/disable auto-update system
@@ -424,8 +440,13 @@ func DefaultConfig() *Config {
 				"contract_propose":     "allow",
 				"contract_verify":      "allow",
 				"contract_status":      "allow",
+				"lsp":                  "allow",
+				"go_symbols":           "allow",
 				"write":                "ask",
 				"edit":                 "ask",
+				"apply_patch":          "ask",
+				"lsp_rename":           "ask",
+				"go_edit":              "ask",
 				"bash":                 "ask",
 				"ssh":                  "ask",
 			},
@@ -507,6 +528,10 @@ func DefaultConfig() *Config {
 			Enabled: false,
 			Servers: []MCPServerConfig{},
 		},
+		LSP: LSPConfig{
+			Enabled: false,
+			Servers: []LSPServerConfig{},
+		},
 		Update: UpdateConfig{
 			Enabled:           true,
 			AutoCheck:         true,
@@ -76,6 +76,8 @@ type ContextManager struct {
 	threshold float64 // 0.0-1.0, triggers summarization
 }
 
+var _ sdk.HistoryCompactor = (*ContextManager)(nil)
+
 // NewContextManager creates a new context manager.
 func NewContextManager(client sdk.Client, opts ...ContextOption) *ContextManager {
 	cm := &ContextManager{
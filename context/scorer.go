@@ -48,12 +48,13 @@ type MessageScorer struct {
 // NewMessageScorer creates a new message scorer.
 func NewMessageScorer() *MessageScorer {
 	ms := &MessageScorer{
-		criticalTools: []string{"edit", "write", "bash", "git_commit"},
+		criticalTools: []string{"edit", "apply_patch", "write", "bash", "git_commit"},
 		verboseTools:  []string{"grep", "glob", "tree", "list_dir", "read", "git_log", "env", "task_output"},
 		criticalToolsMap: map[string]bool{
-			"write": true,
-			"edit":  true,
-			"bash":  true,
+			"write":       true,
+			"edit":        true,
+			"apply_patch": true,
+			"bash":        true,
 		},
 		verboseToolsMap: map[string]bool{
 			"read":        true,
@@ -51,6 +51,7 @@ func main() {
 		sdk.WithSystemPrompt("You are a coding assistant that plans before acting."),
 		sdk.WithMaxTurns(30),
 		sdk.WithPlanner(planner),
+		sdk.WithWorkDir(workDir),
 		sdk.WithPlanApprovalCallback(func(summary string) {
 			fmt.Printf("\n--- Plan ---\n%s\n--- End Plan ---\n\n", summary)
 		}),
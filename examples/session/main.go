@@ -11,7 +11,6 @@ import (
 	sdk "github.com/ginkida/gokin-sdk"
 	"github.com/ginkida/gokin-sdk/provider/gemini"
 	"github.com/ginkida/gokin-sdk/tools"
-	"google.golang.org/genai"
 )
 
 func main() {
@@ -44,10 +43,12 @@ func main() {
 	storeDir := filepath.Join(os.TempDir(), "gokin-sdk-sessions")
 	store := sdk.NewSessionStore(storeDir)
 
-	// Create an agent
+	// Create an agent bound to the session; every turn is saved to the store
 	agent, err := sdk.NewAgent("assistant", client, registry,
 		sdk.WithSystemPrompt("You are a helpful assistant. Keep your responses concise."),
 		sdk.WithMaxTurns(10),
+		sdk.WithSession(session),
+		sdk.WithSessionStore(store),
 		sdk.WithOnText(func(text string) {
 			fmt.Print(text)
 		}),
@@ -67,16 +68,15 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Add to session history
-	session.AddUserMessage("What Go files are in the current directory?")
-	session.AddModelResponse(genai.NewContentFromText(result.Text, "model"))
-
-	// Save session
-	if err := store.Save(session); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
-	} else {
-		fmt.Printf("\n\nSession saved (%d messages)\n", session.Len())
+	// Follow-up turn continues the same conversation
+	fmt.Println("\n\n--- Turn 2 ---")
+	fmt.Println("User: Which of those files is the largest?")
+	fmt.Print("Assistant: ")
+	if _, err := agent.Run(ctx, "Which of those files is the largest?"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+	fmt.Printf("\n\nSession saved (%d messages)\n", session.Len())
 
 	// List saved sessions
 	sessions, _ := store.List()
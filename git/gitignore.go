@@ -3,6 +3,7 @@ package git
 import (
 	"bufio"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -23,6 +24,7 @@ type pattern struct {
 type GitIgnore struct {
 	workDir      string
 	patterns     []pattern
+	extra        []pattern // added by AddPattern; kept across Load and applied last
 	mu           sync.RWMutex
 	loaded       bool
 	resultCache  map[string]bool // path -> isIgnored cache
@@ -63,6 +65,9 @@ func (g *GitIgnore) Load() error {
 		}
 	}
 
+	// Load repository-local excludes (optional)
+	_ = g.loadFile(filepath.Join(g.workDir, ".git", "info", "exclude"), g.workDir)
+
 	// Walk directories to find nested .gitignore files
 	err := filepath.Walk(g.workDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -74,6 +79,11 @@ func (g *GitIgnore) Load() error {
 			return filepath.SkipDir
 		}
 
+		// Skip directories that are already ignored, such as node_modules
+		if info.IsDir() && path != g.workDir && g.matchLocked(path, true) {
+			return filepath.SkipDir
+		}
+
 		if !info.IsDir() && info.Name() == ".gitignore" && path != rootGitignore {
 			baseDir := filepath.Dir(path)
 			if err := g.loadFile(path, baseDir); err != nil && !os.IsNotExist(err) {
@@ -158,7 +168,7 @@ func (g *GitIgnore) parseLine(line, baseDir string) *pattern {
 func (g *GitIgnore) IsIgnored(path string) bool {
 	// Check cache first with read lock
 	g.mu.RLock()
-	if !g.loaded {
+	if !g.loaded && len(g.extra) == 0 {
 		g.mu.RUnlock()
 		return false
 	}
@@ -176,9 +186,29 @@ func (g *GitIgnore) IsIgnored(path string) bool {
 
 // calculateIsIgnored performs the actual gitignore check without caching.
 func (g *GitIgnore) calculateIsIgnored(path string) bool {
+	// Check if path is a directory
+	info, err := os.Stat(path)
+	isDir := err == nil && info.IsDir()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.matchLocked(path, isDir)
+}
+
+// Match checks if a path should be ignored when the caller already knows
+// whether it is a directory, as when walking a tree. Unlike IsIgnored it
+// neither stats the path nor caches the result.
+func (g *GitIgnore) Match(path string, isDir bool) bool {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
+	if !g.loaded && len(g.extra) == 0 {
+		return false
+	}
+	return g.matchLocked(path, isDir)
+}
 
+// matchLocked applies the patterns to path. g.mu must be held.
+func (g *GitIgnore) matchLocked(path string, isDir bool) bool {
 	// Make path relative to workDir
 	relPath, err := filepath.Rel(g.workDir, path)
 	if err != nil {
@@ -188,15 +218,13 @@ func (g *GitIgnore) calculateIsIgnored(path string) bool {
 	// Normalize to forward slashes for matching
 	relPath = filepath.ToSlash(relPath)
 
-	// Check if path is a directory
-	info, err := os.Stat(path)
-	isDir := err == nil && info.IsDir()
-
 	// Apply patterns in order (last matching pattern wins)
 	ignored := false
-	for _, p := range g.patterns {
-		if g.matchPattern(p, relPath, isDir) {
-			ignored = !p.negation
+	for _, patterns := range [][]pattern{g.patterns, g.extra} {
+		for _, p := range patterns {
+			if g.matchPattern(p, relPath, isDir) {
+				ignored = !p.negation
+			}
 		}
 	}
 
@@ -281,6 +309,15 @@ func (g *GitIgnore) globMatch(pattern, path string) bool {
 
 // getGlobalGitignore returns the path to global gitignore file.
 func (g *GitIgnore) getGlobalGitignore() string {
+	// Check core.excludesFile
+	if out, err := exec.Command("git", "config", "--path", "--get", "core.excludesFile").Output(); err == nil {
+		if globalPath := strings.TrimSpace(string(out)); globalPath != "" {
+			if _, err := os.Stat(globalPath); err == nil {
+				return globalPath
+			}
+		}
+	}
+
 	// Check XDG config
 	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
 	if xdgConfig == "" {
@@ -314,14 +351,17 @@ func (g *GitIgnore) Reload() error {
 	return g.Load()
 }
 
-// AddPattern adds a pattern programmatically.
+// AddPattern adds a pattern programmatically. Added patterns take effect
+// without Load, survive reloads and take precedence over ignore files.
 func (g *GitIgnore) AddPattern(pat string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	p := g.parseLine(pat, g.workDir)
 	if p != nil {
-		g.patterns = append(g.patterns, *p)
+		g.extra = append(g.extra, *p)
+		g.resultCache = make(map[string]bool)
+		g.cacheOrder = nil
 	}
 }
 
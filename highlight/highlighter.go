@@ -8,6 +8,7 @@ import (
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/lipgloss"
@@ -32,6 +33,19 @@ func New(style string) *Highlighter {
 	}
 }
 
+// NewHTML creates a Highlighter that renders HTML with inline styles, for
+// documents that must be self-contained.
+func NewHTML(style string) *Highlighter {
+	if style == "" {
+		style = "github"
+	}
+
+	return &Highlighter{
+		style:     style,
+		formatter: html.New(html.WithClasses(false)),
+	}
+}
+
 // Highlight applies syntax highlighting to code based on language.
 func (h *Highlighter) Highlight(code, lang string) string {
 	lexer := lexers.Get(lang)
@@ -0,0 +1,38 @@
+package interact
+
+import "context"
+
+// Channel delivers interactions as Requests on a Go channel, for
+// applications that embed an agent and present questions in their own UI.
+// The receiver answers each request with Request.Respond; notifications
+// need no answer.
+//
+//	ch := interact.NewChannel(0)
+//	go func() {
+//		for req := range ch.Requests() {
+//			req.Respond(showDialog(req))
+//		}
+//	}()
+type Channel struct {
+	requester
+	ch chan *Request
+}
+
+// NewChannel creates a Channel whose request channel has the given buffer.
+func NewChannel(buffer int) *Channel {
+	c := &Channel{ch: make(chan *Request, buffer)}
+	c.deliver = func(ctx context.Context, req *Request) error {
+		select {
+		case c.ch <- req:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return c
+}
+
+// Requests returns the channel on which requests are delivered.
+func (c *Channel) Requests() <-chan *Request {
+	return c.ch
+}
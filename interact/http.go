@@ -0,0 +1,171 @@
+package interact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxNotifications bounds the notifications kept for HTTP clients.
+const maxNotifications = 100
+
+// HTTP exposes interactions to HTTP clients, which poll for pending
+// requests and post answers. Mount it under a prefix with
+// http.StripPrefix.
+//
+// Routes:
+//
+//	GET  /requests       pending requests and recent notifications;
+//	                     ?wait=30s blocks until there is something new
+//	POST /requests/{id}  answer a request: {"answer": "..."}
+type HTTP struct {
+	requester
+
+	mu            sync.Mutex
+	pending       map[string]*Request
+	notifications []*Request
+	changed       chan struct{} // closed and replaced on every change
+
+	mux *http.ServeMux
+}
+
+// NewHTTP creates an HTTP interaction.
+func NewHTTP() *HTTP {
+	h := &HTTP{
+		pending: make(map[string]*Request),
+		changed: make(chan struct{}),
+		mux:     http.NewServeMux(),
+	}
+	h.deliver = h.add
+	h.done = h.remove
+
+	h.mux.HandleFunc("GET /requests", h.handleList)
+	h.mux.HandleFunc("POST /requests/{id}", h.handleAnswer)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *HTTP) add(ctx context.Context, req *Request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if req.Kind == KindNotify {
+		h.notifications = append(h.notifications, req)
+		if len(h.notifications) > maxNotifications {
+			h.notifications = h.notifications[len(h.notifications)-maxNotifications:]
+		}
+	} else {
+		h.pending[req.ID] = req
+	}
+	h.changedLocked()
+	return nil
+}
+
+func (h *HTTP) remove(req *Request) {
+	if req.Kind == KindNotify {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.pending[req.ID]; ok {
+		delete(h.pending, req.ID)
+		h.changedLocked()
+	}
+}
+
+func (h *HTTP) changedLocked() {
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// Pending returns the unanswered requests, oldest first.
+func (h *HTTP) Pending() []*Request {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.pendingLocked()
+}
+
+func (h *HTTP) pendingLocked() []*Request {
+	reqs := make([]*Request, 0, len(h.pending))
+	for _, req := range h.pending {
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].CreatedAt.Before(reqs[j].CreatedAt)
+	})
+	return reqs
+}
+
+// Answer answers a pending request by ID.
+func (h *HTTP) Answer(id, answer string) error {
+	h.mu.Lock()
+	req, ok := h.pending[id]
+	h.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("request not found: %s", id)
+	}
+	return req.Respond(answer)
+}
+
+func (h *HTTP) handleList(w http.ResponseWriter, r *http.Request) {
+	if wait := r.URL.Query().Get("wait"); wait != "" {
+		d, err := time.ParseDuration(wait)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid wait: %v", err)})
+			return
+		}
+		h.mu.Lock()
+		changed := h.changed
+		h.mu.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(d):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	h.mu.Lock()
+	body := map[string]any{
+		"requests":      h.pendingLocked(),
+		"notifications": append([]*Request{}, h.notifications...),
+	}
+	h.mu.Unlock()
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *HTTP) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Answer string `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	if err := h.Answer(r.PathValue("id"), body.Answer); err != nil {
+		status := http.StatusNotFound
+		switch {
+		case errors.Is(err, ErrAnswered):
+			status = http.StatusConflict
+		case errors.Is(err, ErrInvalidAnswer):
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
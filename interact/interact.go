@@ -0,0 +1,247 @@
+// Package interact provides sdk.Interaction implementations: a terminal
+// prompt, a channel-based one for embedding, and an HTTP one.
+package interact
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+// Kind identifies what a Request asks for.
+type Kind string
+
+const (
+	KindAsk     Kind = "ask"     // free-form question
+	KindConfirm Kind = "confirm" // yes/no question; Default is "yes" or "no"
+	KindChoose  Kind = "choose"  // pick one of Options
+	KindNotify  Kind = "notify"  // message, no reply expected
+)
+
+var (
+	// ErrAnswered is returned when a request is answered twice.
+	ErrAnswered = errors.New("request already answered")
+
+	// ErrInvalidAnswer is returned when an answer does not fit the request.
+	ErrInvalidAnswer = errors.New("invalid answer")
+)
+
+// Request is a pending interaction delivered by Channel and HTTP.
+type Request struct {
+	ID        string    `json:"id"`
+	Kind      Kind      `json:"kind"`
+	Question  string    `json:"question"`
+	Options   []string  `json:"options,omitempty"`
+	Default   string    `json:"default,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	answer chan string
+	once   sync.Once
+}
+
+func newRequest(kind Kind, question string, options []string, defaultAnswer string) *Request {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return &Request{
+		ID:        hex.EncodeToString(b),
+		Kind:      kind,
+		Question:  question,
+		Options:   options,
+		Default:   defaultAnswer,
+		CreatedAt: time.Now(),
+		answer:    make(chan string, 1),
+	}
+}
+
+// Respond answers the request. An empty answer selects the default. For
+// choices, the answer may be an option or its 1-based number; for
+// confirmations, "y"/"yes"/"n"/"no". Answers that fit neither are
+// rejected with ErrInvalidAnswer and the request stays pending.
+func (r *Request) Respond(answer string) error {
+	if r.Kind == KindNotify {
+		return nil
+	}
+	if err := r.validate(answer); err != nil {
+		return err
+	}
+	err := ErrAnswered
+	r.once.Do(func() {
+		r.answer <- answer
+		err = nil
+	})
+	return err
+}
+
+func (r *Request) validate(answer string) error {
+	var err error
+	switch r.Kind {
+	case KindConfirm:
+		_, err = parseYes(answer, false)
+	case KindChoose:
+		_, err = resolveChoice(answer, r.Options, r.Default)
+	}
+	return err
+}
+
+// requester implements sdk.Interaction on top of a function that delivers
+// requests to whoever answers them. Channel and HTTP embed it.
+type requester struct {
+	deliver func(ctx context.Context, req *Request) error
+	done    func(req *Request) // called once the request is settled; may be nil
+}
+
+func (q *requester) await(ctx context.Context, req *Request) (string, error) {
+	if q.done != nil {
+		defer q.done(req)
+	}
+	if err := q.deliver(ctx, req); err != nil {
+		return "", err
+	}
+	select {
+	case answer := <-req.answer:
+		return answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (q *requester) Ask(ctx context.Context, question, defaultAnswer string) (string, error) {
+	answer, err := q.await(ctx, newRequest(KindAsk, question, nil, defaultAnswer))
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(answer) == "" {
+		return defaultAnswer, nil
+	}
+	return answer, nil
+}
+
+func (q *requester) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	answer, err := q.await(ctx, newRequest(KindConfirm, question, nil, formatYes(defaultYes)))
+	if err != nil {
+		return false, err
+	}
+	return parseYes(answer, defaultYes)
+}
+
+func (q *requester) Choose(ctx context.Context, question string, options []string, defaultOption string) (string, error) {
+	answer, err := q.await(ctx, newRequest(KindChoose, question, options, defaultOption))
+	if err != nil {
+		return "", err
+	}
+	return resolveChoice(answer, options, defaultOption)
+}
+
+func (q *requester) Notify(ctx context.Context, message string) error {
+	req := newRequest(KindNotify, message, nil, "")
+	if q.done != nil {
+		defer q.done(req)
+	}
+	return q.deliver(ctx, req)
+}
+
+func formatYes(yes bool) string {
+	if yes {
+		return "yes"
+	}
+	return "no"
+}
+
+// parseYes interprets a confirmation answer.
+func parseYes(answer string, defaultYes bool) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return defaultYes, nil
+	case "y", "yes", "true":
+		return true, nil
+	case "n", "no", "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: %q is not yes or no", ErrInvalidAnswer, answer)
+	}
+}
+
+// resolveChoice maps an answer to one of options. Answers may be the
+// option text (case-insensitive) or its 1-based number.
+func resolveChoice(answer string, options []string, defaultOption string) (string, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultOption, nil
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+		return options[n-1], nil
+	}
+	for _, opt := range options {
+		if strings.EqualFold(opt, answer) {
+			return opt, nil
+		}
+	}
+	if len(options) == 0 {
+		return answer, nil
+	}
+	return "", fmt.Errorf("%w: %q is not one of the options", ErrInvalidAnswer, answer)
+}
+
+// --- Timeouts ---
+
+type timeoutInteraction struct {
+	sdk.Interaction
+	timeout time.Duration
+}
+
+// WithTimeout bounds how long each interaction waits for a reply. When the
+// timeout expires the default answer is used; cancellation of the caller's
+// context is still reported as an error.
+func WithTimeout(i sdk.Interaction, timeout time.Duration) sdk.Interaction {
+	return &timeoutInteraction{Interaction: i, timeout: timeout}
+}
+
+// timedOut reports whether err came from the timeout rather than from the
+// caller's context.
+func timedOut(parent context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil
+}
+
+func (t *timeoutInteraction) Ask(ctx context.Context, question, defaultAnswer string) (string, error) {
+	tctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	answer, err := t.Interaction.Ask(tctx, question, defaultAnswer)
+	if timedOut(ctx, err) {
+		return defaultAnswer, nil
+	}
+	return answer, err
+}
+
+func (t *timeoutInteraction) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	tctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	yes, err := t.Interaction.Confirm(tctx, question, defaultYes)
+	if timedOut(ctx, err) {
+		return defaultYes, nil
+	}
+	return yes, err
+}
+
+func (t *timeoutInteraction) Choose(ctx context.Context, question string, options []string, defaultOption string) (string, error) {
+	tctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	choice, err := t.Interaction.Choose(tctx, question, options, defaultOption)
+	if timedOut(ctx, err) {
+		return defaultOption, nil
+	}
+	return choice, err
+}
+
+var (
+	_ sdk.Interaction = (*Terminal)(nil)
+	_ sdk.Interaction = (*Channel)(nil)
+	_ sdk.Interaction = (*HTTP)(nil)
+)
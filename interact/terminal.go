@@ -0,0 +1,158 @@
+package interact
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	questionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true) // Cyan
+	optionStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#A78BFA"))            // Purple
+	hintStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280"))            // Gray
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))            // Red
+	noticeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))            // Green
+)
+
+// Terminal asks questions on a terminal. Prompts from concurrent agents
+// are serialized. Invalid answers are re-asked.
+type Terminal struct {
+	out io.Writer
+
+	lines    chan string
+	readErr  error // set before lines is closed
+	readOnce sync.Once
+	in       io.Reader
+
+	mu sync.Mutex // serializes prompts
+}
+
+// NewTerminal creates a Terminal reading answers from in and writing
+// prompts to out.
+func NewTerminal(in io.Reader, out io.Writer) *Terminal {
+	return &Terminal{in: in, out: out, lines: make(chan string)}
+}
+
+// NewStdTerminal creates a Terminal on os.Stdin and os.Stderr.
+func NewStdTerminal() *Terminal {
+	return NewTerminal(os.Stdin, os.Stderr)
+}
+
+// readLoop reads input lines for the lifetime of the Terminal, so a
+// cancelled prompt does not leave a blocked read behind.
+func (t *Terminal) readLoop() {
+	scanner := bufio.NewScanner(t.in)
+	for scanner.Scan() {
+		t.lines <- scanner.Text()
+	}
+	t.readErr = scanner.Err()
+	if t.readErr == nil {
+		t.readErr = io.EOF
+	}
+	close(t.lines)
+}
+
+// prompt writes text and reads one answer line.
+func (t *Terminal) prompt(ctx context.Context, text string) (string, error) {
+	t.readOnce.Do(func() { go t.readLoop() })
+
+	fmt.Fprint(t.out, text)
+	select {
+	case line, ok := <-t.lines:
+		if !ok {
+			return "", t.readErr
+		}
+		return strings.TrimSpace(line), nil
+	case <-ctx.Done():
+		fmt.Fprintln(t.out)
+		return "", ctx.Err()
+	}
+}
+
+// ask repeats a prompt until parse accepts the answer.
+func (t *Terminal) ask(ctx context.Context, text string, parse func(string) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for {
+		answer, err := t.prompt(ctx, text)
+		if err != nil {
+			return err
+		}
+		err = parse(answer)
+		if !errors.Is(err, ErrInvalidAnswer) {
+			return err
+		}
+		fmt.Fprintln(t.out, errorStyle.Render(err.Error()))
+	}
+}
+
+func (t *Terminal) Ask(ctx context.Context, question, defaultAnswer string) (string, error) {
+	text := questionStyle.Render(question)
+	if defaultAnswer != "" {
+		text += " " + hintStyle.Render(fmt.Sprintf("[%s]", defaultAnswer))
+	}
+	text += "\n> "
+
+	var result string
+	err := t.ask(ctx, text, func(answer string) error {
+		result = answer
+		if result == "" {
+			result = defaultAnswer
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (t *Terminal) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+	text := questionStyle.Render(question) + " " + hintStyle.Render(hint) + " "
+
+	var result bool
+	err := t.ask(ctx, text, func(answer string) error {
+		var err error
+		result, err = parseYes(answer, defaultYes)
+		return err
+	})
+	return result, err
+}
+
+func (t *Terminal) Choose(ctx context.Context, question string, options []string, defaultOption string) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(questionStyle.Render(question))
+	sb.WriteByte('\n')
+	for i, opt := range options {
+		line := fmt.Sprintf("  %d) %s", i+1, opt)
+		if opt == defaultOption {
+			line += " " + hintStyle.Render("(default)")
+		}
+		sb.WriteString(optionStyle.Render(line))
+		sb.WriteByte('\n')
+	}
+	sb.WriteString("> ")
+
+	var result string
+	err := t.ask(ctx, sb.String(), func(answer string) error {
+		var err error
+		result, err = resolveChoice(answer, options, defaultOption)
+		return err
+	})
+	return result, err
+}
+
+func (t *Terminal) Notify(ctx context.Context, message string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := fmt.Fprintln(t.out, noticeStyle.Render(message))
+	return err
+}
@@ -0,0 +1,50 @@
+package sdk
+
+import "context"
+
+// Interaction is how an agent reaches a human. Implementations decide how
+// questions are presented (terminal, embedding application, HTTP client)
+// and must return ctx.Err() when ctx is cancelled while waiting.
+//
+// An agent configured with WithInteraction makes it available to tools
+// through the run context; ask_user and permission prompts use it when no
+// dedicated handler is set.
+type Interaction interface {
+	// Ask asks a free-form question. An empty reply yields defaultAnswer.
+	Ask(ctx context.Context, question, defaultAnswer string) (string, error)
+
+	// Confirm asks a yes/no question.
+	Confirm(ctx context.Context, question string, defaultYes bool) (bool, error)
+
+	// Choose asks the human to pick one of options. An empty reply yields
+	// defaultOption.
+	Choose(ctx context.Context, question string, options []string, defaultOption string) (string, error)
+
+	// Notify shows a message without waiting for a reply.
+	Notify(ctx context.Context, message string) error
+}
+
+type interactionKey struct{}
+
+// ContextWithInteraction returns a context carrying i.
+func ContextWithInteraction(ctx context.Context, i Interaction) context.Context {
+	return context.WithValue(ctx, interactionKey{}, i)
+}
+
+// InteractionFromContext returns the Interaction carried by ctx, or nil.
+func InteractionFromContext(ctx context.Context) Interaction {
+	i, _ := ctx.Value(interactionKey{}).(Interaction)
+	return i
+}
+
+// interactionContext attaches the agent's interaction and, in review mode,
+// its change approver to a run context.
+func (a *Agent) interactionContext(ctx context.Context) context.Context {
+	if a.interaction != nil {
+		ctx = ContextWithInteraction(ctx, a.interaction)
+	}
+	if approver := a.reviewApprover(); approver != nil {
+		ctx = ContextWithChangeApprover(ctx, approver)
+	}
+	return ctx
+}
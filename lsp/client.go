@@ -0,0 +1,621 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// requestTimeout bounds a single request. Servers answer the first
+// requests only after loading the workspace, which can take a while.
+const requestTimeout = 60 * time.Second
+
+// ServerInfo describes a language server.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Client is a JSON-RPC 2.0 client for a language server.
+type Client struct {
+	transport  Transport
+	rootDir    string
+	initOpts   map[string]any
+	serverInfo *ServerInfo
+	nextID     atomic.Int64
+	pending    map[int64]chan Message
+	mu         sync.Mutex
+	closed     bool
+	done       chan struct{}
+
+	// Documents opened on the server: URI → version and text
+	docs map[string]*document
+
+	// Latest diagnostics per URI; published is closed and replaced on
+	// every publication
+	diags     map[string]*diagnosticsEntry
+	published chan struct{}
+}
+
+type document struct {
+	version int
+	text    string
+}
+
+type diagnosticsEntry struct {
+	generation  int
+	diagnostics []Diagnostic
+}
+
+// NewClient creates a new client with the given transport for a workspace
+// rooted at rootDir.
+func NewClient(transport Transport, rootDir string) *Client {
+	c := &Client{
+		transport: transport,
+		rootDir:   rootDir,
+		pending:   make(map[int64]chan Message),
+		done:      make(chan struct{}),
+		docs:      make(map[string]*document),
+		diags:     make(map[string]*diagnosticsEntry),
+		published: make(chan struct{}),
+	}
+
+	go c.receiveLoop()
+	return c
+}
+
+// NewClientFromConfig starts the server described by cfg for rootDir.
+func NewClientFromConfig(cfg ServerConfig, rootDir string) (*Client, error) {
+	transport, err := NewStdioTransport(cfg.Command, cfg.Args, cfg.Env, rootDir)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClient(transport, rootDir)
+	c.initOpts = cfg.InitializationOptions
+	return c, nil
+}
+
+// Initialize performs the LSP initialization handshake.
+func (c *Client) Initialize(ctx context.Context) error {
+	rootURI := PathToURI(c.rootDir)
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"clientInfo": map[string]any{
+			"name":    "gokin-sdk",
+			"version": "0.2.0",
+		},
+		"rootUri":  rootURI,
+		"rootPath": c.rootDir,
+		"workspaceFolders": []map[string]any{
+			{"uri": rootURI, "name": filepath.Base(c.rootDir)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"synchronization":    map[string]any{"didSave": false},
+				"definition":         map[string]any{"linkSupport": true},
+				"references":         map[string]any{},
+				"hover":              map[string]any{"contentFormat": []string{"markdown", "plaintext"}},
+				"rename":             map[string]any{"prepareSupport": false},
+				"publishDiagnostics": map[string]any{"relatedInformation": false},
+			},
+			"workspace": map[string]any{
+				"workspaceEdit":    map[string]any{"documentChanges": true},
+				"symbol":           map[string]any{},
+				"workspaceFolders": true,
+				"configuration":    true,
+			},
+		},
+	}
+	if c.initOpts != nil {
+		params["initializationOptions"] = c.initOpts
+	}
+
+	resp, err := c.call(ctx, "initialize", params)
+	if err != nil {
+		return fmt.Errorf("initialization failed: %w", err)
+	}
+
+	var result struct {
+		ServerInfo *ServerInfo `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("parsing init result: %w", err)
+	}
+	c.serverInfo = result.ServerInfo
+
+	return c.notify("initialized", map[string]any{})
+}
+
+// ServerInfo returns information about the connected server, if it sent
+// any.
+func (c *Client) ServerInfo() *ServerInfo {
+	return c.serverInfo
+}
+
+// RootDir returns the workspace root.
+func (c *Client) RootDir() string {
+	return c.rootDir
+}
+
+// Closed reports whether the client was closed or the server went away.
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Sync sends the current on-disk content of path to the server, opening
+// the document on first use. It reports whether the server's copy changed.
+func (c *Client) Sync(ctx context.Context, path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	text := string(data)
+	uri := PathToURI(path)
+
+	c.mu.Lock()
+	doc, open := c.docs[uri]
+	if open && doc.text == text {
+		c.mu.Unlock()
+		return false, nil
+	}
+	if !open {
+		doc = &document{}
+		c.docs[uri] = doc
+	}
+	doc.version++
+	doc.text = text
+	version := doc.version
+	c.mu.Unlock()
+
+	if !open {
+		languageID := LanguageID(path)
+		if languageID == "" {
+			languageID = "plaintext"
+		}
+		return true, c.notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{
+				"uri":        uri,
+				"languageId": languageID,
+				"version":    version,
+				"text":       text,
+			},
+		})
+	}
+	return true, c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": version},
+		"contentChanges": []map[string]any{{"text": text}},
+	})
+}
+
+// Definition returns the locations where the symbol at pos is defined.
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	resp, err := c.positionRequest(ctx, "textDocument/definition", path, pos, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseLocations(resp)
+}
+
+// References returns the locations that refer to the symbol at pos.
+func (c *Client) References(ctx context.Context, path string, pos Position, includeDeclaration bool) ([]Location, error) {
+	resp, err := c.positionRequest(ctx, "textDocument/references", path, pos, map[string]any{
+		"context": map[string]any{"includeDeclaration": includeDeclaration},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseLocations(resp)
+}
+
+// Hover returns the documentation and type information for the symbol at
+// pos as plain text or markdown, or "" if there is none.
+func (c *Client) Hover(ctx context.Context, path string, pos Position) (string, error) {
+	resp, err := c.positionRequest(ctx, "textDocument/hover", path, pos, nil)
+	if err != nil {
+		return "", err
+	}
+	if isNull(resp) {
+		return "", nil
+	}
+	var result hoverResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return "", fmt.Errorf("parsing hover: %w", err)
+	}
+	return hoverText(result.Contents), nil
+}
+
+// WorkspaceSymbols searches the workspace for symbols matching query.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]Symbol, error) {
+	resp, err := c.call(ctx, "workspace/symbol", map[string]any{"query": query})
+	if err != nil {
+		return nil, err
+	}
+	if isNull(resp) {
+		return nil, nil
+	}
+	var symbols []Symbol
+	if err := json.Unmarshal(resp, &symbols); err != nil {
+		return nil, fmt.Errorf("parsing symbols: %w", err)
+	}
+	return symbols, nil
+}
+
+// Rename computes the edits that rename the symbol at pos to newName. The
+// edits are not applied.
+func (c *Client) Rename(ctx context.Context, path string, pos Position, newName string) (*WorkspaceEdit, error) {
+	resp, err := c.positionRequest(ctx, "textDocument/rename", path, pos, map[string]any{"newName": newName})
+	if err != nil {
+		return nil, err
+	}
+	if isNull(resp) {
+		return &WorkspaceEdit{}, nil
+	}
+	var wire workspaceEditWire
+	if err := json.Unmarshal(resp, &wire); err != nil {
+		return nil, fmt.Errorf("parsing workspace edit: %w", err)
+	}
+
+	edit := &WorkspaceEdit{Changes: make(map[string][]TextEdit)}
+	for uri, edits := range wire.Changes {
+		edit.Changes[uri] = append(edit.Changes[uri], edits...)
+	}
+	for _, raw := range wire.DocumentChanges {
+		var change textDocumentEdit
+		if err := json.Unmarshal(raw, &change); err != nil {
+			return nil, fmt.Errorf("parsing workspace edit: %w", err)
+		}
+		if change.Kind != "" {
+			return nil, fmt.Errorf("rename needs a file %s operation, which is not supported", change.Kind)
+		}
+		uri := change.TextDocument.URI
+		edit.Changes[uri] = append(edit.Changes[uri], change.Edits...)
+	}
+	return edit, nil
+}
+
+// Diagnostics syncs path and returns the diagnostics the server publishes
+// for it, waiting up to wait for a fresh publication after a change.
+func (c *Client) Diagnostics(ctx context.Context, path string, wait time.Duration) ([]Diagnostic, error) {
+	uri := PathToURI(path)
+
+	c.mu.Lock()
+	before, seen := 0, false
+	if entry, ok := c.diags[uri]; ok {
+		before, seen = entry.generation, true
+	}
+	c.mu.Unlock()
+
+	changed, err := c.Sync(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		entry, ok := c.diags[uri]
+		fresh := ok && (entry.generation > before || (!changed && seen))
+		published := c.published
+		c.mu.Unlock()
+
+		if fresh {
+			return entry.diagnostics, nil
+		}
+
+		select {
+		case <-published:
+		case <-timer.C:
+			if ok {
+				return entry.diagnostics, nil
+			}
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, fmt.Errorf("client closed")
+		}
+	}
+}
+
+// Close shuts the server down and closes the transport.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+
+	if !closed {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, _ = c.call(ctx, "shutdown", nil)
+		cancel()
+		_ = c.notify("exit", nil)
+	}
+
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+
+	// Cancel pending requests
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+
+	return c.transport.Close()
+}
+
+// positionRequest sends a textDocument request for pos in path, syncing the
+// document first so the server sees the current content.
+func (c *Client) positionRequest(ctx context.Context, method, path string, pos Position, extra map[string]any) (json.RawMessage, error) {
+	if _, err := c.Sync(ctx, path); err != nil {
+		return nil, err
+	}
+	params := map[string]any{
+		"textDocument": map[string]any{"uri": PathToURI(path)},
+		"position":     pos,
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	return c.call(ctx, method, params)
+}
+
+func (c *Client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := c.nextID.Add(1)
+
+	msg := Message{
+		ID:     json.RawMessage(strconv.FormatInt(id, 10)),
+		Method: method,
+	}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling params: %w", err)
+		}
+		msg.Params = data
+	}
+
+	// Register pending request
+	ch := make(chan Message, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("client closed")
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.Send(msg); err != nil {
+		return nil, err
+	}
+
+	// Wait for response
+	select {
+	case <-ctx.Done():
+		c.cancelRequest(id)
+		return nil, ctx.Err()
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("request cancelled")
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-time.After(requestTimeout):
+		c.cancelRequest(id)
+		return nil, fmt.Errorf("%s timed out", method)
+	}
+}
+
+// cancelRequest tells the server a request is no longer needed.
+func (c *Client) cancelRequest(id int64) {
+	_ = c.notify("$/cancelRequest", map[string]any{"id": id})
+}
+
+func (c *Client) notify(method string, params any) error {
+	msg := Message{Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshaling params: %w", err)
+		}
+		msg.Params = data
+	}
+	return c.transport.Send(msg)
+}
+
+func (c *Client) receiveLoop() {
+	defer func() {
+		// The server went away: fail pending and future requests
+		c.mu.Lock()
+		if !c.closed {
+			c.closed = true
+			close(c.done)
+		}
+		for id, ch := range c.pending {
+			close(ch)
+			delete(c.pending, id)
+		}
+		c.mu.Unlock()
+	}()
+
+	for {
+		msg, err := c.transport.Receive()
+		if err != nil {
+			// Transport closed or error
+			return
+		}
+
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			c.handleServerRequest(msg)
+		case msg.Method != "":
+			c.handleNotification(msg)
+		case len(msg.ID) > 0:
+			// Route response to pending request
+			id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+			if err != nil {
+				continue
+			}
+			// Deliver under the lock so Close cannot close ch meanwhile;
+			// ch is buffered for the single response
+			c.mu.Lock()
+			if ch, ok := c.pending[id]; ok {
+				ch <- msg
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// handleServerRequest answers requests the server sends to the client.
+// Servers block on some of them, such as workspace/configuration.
+func (c *Client) handleServerRequest(msg Message) {
+	reply := Message{ID: msg.ID, Result: json.RawMessage("null")}
+
+	switch msg.Method {
+	case "workspace/configuration":
+		// No settings: one null per requested item
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		nulls := make([]any, len(params.Items))
+		reply.Result, _ = json.Marshal(nulls)
+	case "workspace/workspaceFolders":
+		reply.Result, _ = json.Marshal([]map[string]any{
+			{"uri": PathToURI(c.rootDir), "name": filepath.Base(c.rootDir)},
+		})
+	case "workspace/applyEdit":
+		// Edits go through the file tools, never behind their back
+		reply.Result = json.RawMessage(`{"applied":false}`)
+	case "client/registerCapability", "client/unregisterCapability",
+		"window/workDoneProgress/create", "window/showMessageRequest",
+		"window/showDocument":
+	default:
+		reply.Result = nil
+		reply.Error = &ResponseError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+
+	_ = c.transport.Send(reply)
+}
+
+func (c *Client) handleNotification(msg Message) {
+	if msg.Method != "textDocument/publishDiagnostics" {
+		return
+	}
+	var params publishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	entry, ok := c.diags[params.URI]
+	if !ok {
+		entry = &diagnosticsEntry{}
+		c.diags[params.URI] = entry
+	}
+	entry.generation++
+	entry.diagnostics = params.Diagnostics
+	close(c.published)
+	c.published = make(chan struct{})
+	c.mu.Unlock()
+}
+
+// locationOrLink decodes both Location and LocationLink results.
+type locationOrLink struct {
+	URI                  string `json:"uri"`
+	Range                Range  `json:"range"`
+	TargetURI            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+func (l locationOrLink) location() Location {
+	if l.TargetURI != "" {
+		return Location{URI: l.TargetURI, Range: l.TargetSelectionRange}
+	}
+	return Location{URI: l.URI, Range: l.Range}
+}
+
+// parseLocations decodes a result that may be null, a Location, or an
+// array of Locations or LocationLinks.
+func parseLocations(raw json.RawMessage) ([]Location, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var list []locationOrLink
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("parsing locations: %w", err)
+		}
+	} else {
+		var single locationOrLink
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, fmt.Errorf("parsing location: %w", err)
+		}
+		list = append(list, single)
+	}
+
+	locations := make([]Location, 0, len(list))
+	for _, l := range list {
+		locations = append(locations, l.location())
+	}
+	return locations, nil
+}
+
+// hoverText renders hover contents: a MarkupContent, a MarkedString
+// (string or {language, value}) or an array of MarkedStrings.
+func hoverText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err == nil {
+		var out []string
+		for _, p := range parts {
+			if s := hoverText(p); s != "" {
+				out = append(out, s)
+			}
+		}
+		return strings.Join(out, "\n\n")
+	}
+
+	var content struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return ""
+	}
+	if content.Language != "" {
+		return "```" + content.Language + "\n" + content.Value + "\n```"
+	}
+	return content.Value
+}
+
+func isNull(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+	return s == "" || s == "null"
+}
@@ -0,0 +1,111 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// PositionAt converts a 1-based line and a 1-based column counted in
+// characters to a protocol Position.
+func PositionAt(text string, line, column int) (Position, error) {
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return Position{}, fmt.Errorf("line %d out of range (1-%d)", line, len(lines))
+	}
+	lineText := strings.TrimSuffix(lines[line-1], "\r")
+	if column < 1 {
+		column = 1
+	}
+	character := 0
+	for i, r := range []rune(lineText) {
+		if i == column-1 {
+			break
+		}
+		character += utf16Len(r)
+	}
+	return Position{Line: line - 1, Character: character}, nil
+}
+
+// Column converts the UTF-16 offset of a position on lineText to a 1-based
+// column counted in characters.
+func Column(lineText string, character int) int {
+	column, units := 1, 0
+	for _, r := range lineText {
+		if units >= character {
+			break
+		}
+		units += utf16Len(r)
+		column++
+	}
+	return column
+}
+
+// Offset converts a position to a byte offset into text, clamping
+// positions past the end of a line or of the text.
+func Offset(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+
+	units := 0
+	for units < pos.Character && offset < len(text) {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+// ApplyEdits applies text edits to text. Edits must not overlap.
+func ApplyEdits(text string, edits []TextEdit) (string, error) {
+	type span struct {
+		start, end int
+		newText    string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, e := range edits {
+		start, end := Offset(text, e.Range.Start), Offset(text, e.Range.End)
+		if end < start {
+			return "", fmt.Errorf("invalid edit range at line %d", e.Range.Start.Line+1)
+		}
+		spans = append(spans, span{start, end, e.NewText})
+	}
+
+	// Inserts at the same position keep their order, as the protocol requires
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end < spans[j].end
+	})
+	var sb strings.Builder
+	prev := 0
+	for _, s := range spans {
+		if s.start < prev {
+			return "", fmt.Errorf("overlapping edits at byte %d", s.start)
+		}
+		sb.WriteString(text[prev:s.start])
+		sb.WriteString(s.newText)
+		prev = s.end
+	}
+	sb.WriteString(text[prev:])
+	return sb.String(), nil
+}
+
+func utf16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
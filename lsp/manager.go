@@ -0,0 +1,176 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Manager starts language servers on demand, one per configured server,
+// and routes files to them by language.
+type Manager struct {
+	rootDir string
+	configs []ServerConfig
+	clients map[string]*Client // server name → client
+	pending map[string]*start  // server name → start in progress
+	closed  bool
+	mu      sync.Mutex
+}
+
+var errManagerClosed = errors.New("language server manager is shut down")
+
+// start is a server being started and initialized. Callers that need the
+// same server wait on done instead of starting another.
+type start struct {
+	done   chan struct{}
+	client *Client
+	err    error
+}
+
+// NewManager creates a manager for the workspace at rootDir. Without
+// configs, DefaultServers is used.
+func NewManager(rootDir string, configs []ServerConfig) *Manager {
+	if len(configs) == 0 {
+		configs = DefaultServers()
+	}
+	return &Manager{
+		rootDir: rootDir,
+		configs: configs,
+		clients: make(map[string]*Client),
+		pending: make(map[string]*start),
+	}
+}
+
+// RootDir returns the workspace root.
+func (m *Manager) RootDir() string {
+	return m.rootDir
+}
+
+// ClientFor returns the client for the language of path, starting its
+// server if needed.
+func (m *Manager) ClientFor(ctx context.Context, path string) (*Client, error) {
+	lang := LanguageID(path)
+	if lang == "" {
+		return nil, fmt.Errorf("no language server for %s: unknown language", path)
+	}
+	return m.ClientForLanguage(ctx, lang)
+}
+
+// ClientForLanguage returns the client for a language ID, starting its
+// server if needed. A server that exited is restarted. Concurrent callers
+// share one start, and servers of other languages stay usable meanwhile.
+func (m *Manager) ClientForLanguage(ctx context.Context, lang string) (*Client, error) {
+	cfg, ok := m.configFor(lang)
+	if !ok {
+		return nil, fmt.Errorf("no language server configured for %s", lang)
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, errManagerClosed
+	}
+	if client, ok := m.clients[cfg.Name]; ok {
+		if !client.Closed() {
+			m.mu.Unlock()
+			return client, nil
+		}
+		delete(m.clients, cfg.Name)
+	}
+	if s, ok := m.pending[cfg.Name]; ok {
+		m.mu.Unlock()
+		select {
+		case <-s.done:
+			return s.client, s.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s := &start{done: make(chan struct{})}
+	m.pending[cfg.Name] = s
+	m.mu.Unlock()
+
+	// Initialize can take a while; it runs without holding m.mu
+	s.client, s.err = m.start(ctx, cfg)
+
+	m.mu.Lock()
+	delete(m.pending, cfg.Name)
+	switch {
+	case s.err != nil:
+	case m.closed:
+		// Shutdown ran while the server was starting
+		s.client.Close()
+		s.client, s.err = nil, errManagerClosed
+	default:
+		m.clients[cfg.Name] = s.client
+	}
+	m.mu.Unlock()
+	close(s.done)
+	return s.client, s.err
+}
+
+// start launches and initializes the server described by cfg.
+func (m *Manager) start(ctx context.Context, cfg ServerConfig) (*Client, error) {
+	if _, err := exec.LookPath(cfg.Command); err != nil {
+		return nil, fmt.Errorf("language server %s is not installed (%s not found)", cfg.Name, cfg.Command)
+	}
+
+	client, err := NewClientFromConfig(cfg, m.rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("starting %s: %w", cfg.Name, err)
+	}
+	if err := client.Initialize(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("initializing %s: %w", cfg.Name, err)
+	}
+	return client, nil
+}
+
+// Running returns the clients of servers that are running.
+func (m *Manager) Running() []*Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var clients []*Client
+	for _, c := range m.configs {
+		if client, ok := m.clients[c.Name]; ok && !client.Closed() {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// Shutdown stops all running servers. Servers still starting are stopped
+// once their start completes, and the manager starts no new ones.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+
+	var errs []string
+	for name, client := range m.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+		}
+		delete(m.clients, name)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown errors: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (m *Manager) configFor(lang string) (ServerConfig, bool) {
+	for _, cfg := range m.configs {
+		for _, l := range cfg.Languages {
+			if l == lang {
+				return cfg, true
+			}
+		}
+	}
+	return ServerConfig{}, false
+}
@@ -0,0 +1,205 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageSize bounds a single incoming message.
+const maxMessageSize = 64 << 20
+
+// Transport is the interface for LSP communication.
+type Transport interface {
+	// Send sends a JSON-RPC message.
+	Send(msg Message) error
+
+	// Receive returns the next incoming message.
+	Receive() (Message, error)
+
+	// Close shuts down the transport.
+	Close() error
+}
+
+// StreamTransport exchanges messages framed with Content-Length headers, as
+// LSP requires, over a reader and a writer.
+type StreamTransport struct {
+	reader *bufio.Reader
+	writer io.WriteCloser
+	cmd    *exec.Cmd // set when the transport owns a server process
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewStreamTransport creates a transport over an existing connection, such
+// as a socket to a server started with its own listen option.
+func NewStreamTransport(r io.Reader, w io.WriteCloser) *StreamTransport {
+	return &StreamTransport{
+		reader: bufio.NewReaderSize(r, 64*1024),
+		writer: w,
+	}
+}
+
+// NewStdioTransport spawns a language server in dir and talks to it over
+// stdin/stdout.
+func NewStdioTransport(command string, args []string, env map[string]string, dir string) (*StreamTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+
+	// Build safe environment
+	cmd.Env = buildTransportEnv(env)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	// Servers log to stderr; discard it
+	cmd.Stderr = io.Discard
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting process: %w", err)
+	}
+
+	t := NewStreamTransport(stdout, stdin)
+	t.cmd = cmd
+	return t, nil
+}
+
+func (t *StreamTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return fmt.Errorf("transport closed")
+	}
+
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
+
+	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
+	if _, err := io.WriteString(t.writer, header); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if _, err := t.writer.Write(data); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	return nil
+}
+
+func (t *StreamTransport) Receive() (Message, error) {
+	length := -1
+	for {
+		line, err := t.reader.ReadString('\n')
+		if err != nil {
+			return Message{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if length < 0 {
+				continue // stray blank line between messages
+			}
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return Message{}, fmt.Errorf("invalid Content-Length: %q", value)
+			}
+			length = n
+		}
+	}
+	if length > maxMessageSize {
+		return Message{}, fmt.Errorf("message too large: %d bytes", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(t.reader, data); err != nil {
+		return Message{}, err
+	}
+
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Message{}, fmt.Errorf("unmarshaling message: %w", err)
+	}
+
+	return msg, nil
+}
+
+func (t *StreamTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+
+	t.writer.Close()
+	if t.cmd == nil {
+		return nil
+	}
+
+	// Graceful shutdown: wait 5 seconds then kill
+	done := make(chan error, 1)
+	go func() {
+		done <- t.cmd.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.cmd.Process.Kill()
+		<-done
+	}
+
+	return nil
+}
+
+func buildTransportEnv(extra map[string]string) []string {
+	// Inherit safe environment variables, including the toolchain settings
+	// language servers need to resolve packages
+	safeVars := []string{
+		"PATH", "HOME", "USER", "SHELL", "TERM", "LANG", "TMPDIR",
+		"XDG_CACHE_HOME", "XDG_CONFIG_HOME",
+		"GOPATH", "GOROOT", "GOCACHE", "GOMODCACHE", "GOFLAGS", "GOPROXY",
+		"GOPRIVATE", "GOTOOLCHAIN", "CGO_ENABLED",
+		"NODE_PATH", "PYTHONPATH", "VIRTUAL_ENV", "CARGO_HOME", "RUSTUP_HOME",
+	}
+
+	env := make([]string, 0)
+	for _, key := range safeVars {
+		if val := os.Getenv(key); val != "" {
+			env = append(env, key+"="+val)
+		}
+	}
+
+	// Ensure PATH
+	if os.Getenv("PATH") == "" {
+		env = append(env, "PATH=/usr/local/bin:/usr/bin:/bin")
+	}
+
+	// Add extra env vars
+	for k, v := range extra {
+		env = append(env, k+"="+v)
+	}
+
+	return env
+}
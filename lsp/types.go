@@ -0,0 +1,246 @@
+// Package lsp provides a Language Server Protocol client for semantic code
+// navigation and refactoring: definitions, references, hover, symbols,
+// diagnostics and renames, answered by language servers such as gopls,
+// pyright or typescript-language-server.
+//
+// Servers are started on first use, one per language:
+//
+//	servers := lsp.NewManager(workDir, nil) // nil: DefaultServers
+//	defer servers.Shutdown(ctx)
+//	registry.MustRegister(tools.NewLSP(servers))
+//	registry.MustRegister(tools.NewLSPRename(servers))
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Message is a JSON-RPC 2.0 message. Servers may use string or numeric IDs
+// for their own requests, so the ID is kept raw.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *ResponseError  `json:"error,omitempty"`
+}
+
+// ResponseError is a JSON-RPC error returned by a server.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// Position is a zero-based line and UTF-16 character offset.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a half-open range between two positions.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextEdit replaces a range of a document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is a set of edits across documents, keyed by URI.
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes,omitempty"`
+}
+
+// workspaceEditWire is a workspace edit as sent by servers, which may use
+// either changes or documentChanges.
+type workspaceEditWire struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []json.RawMessage     `json:"documentChanges,omitempty"`
+}
+
+// textDocumentEdit is a documentChanges entry that edits a document.
+type textDocumentEdit struct {
+	Kind         string `json:"kind,omitempty"` // set for create, rename and delete operations
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Edits []TextEdit `json:"edits"`
+}
+
+// Severity ranks a diagnostic.
+type Severity int
+
+const (
+	SeverityError       Severity = 1
+	SeverityWarning     Severity = 2
+	SeverityInformation Severity = 3
+	SeverityHint        Severity = 4
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic is an error, warning or hint reported for a document.
+type Diagnostic struct {
+	Range    Range    `json:"range"`
+	Severity Severity `json:"severity,omitempty"`
+	Code     any      `json:"code,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// publishDiagnosticsParams is the payload of textDocument/publishDiagnostics.
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// SymbolKind is the kind of a symbol, as numbered by the protocol.
+type SymbolKind int
+
+var symbolKindNames = []string{
+	"", "file", "module", "namespace", "package", "class", "method", "property",
+	"field", "constructor", "enum", "interface", "function", "variable",
+	"constant", "string", "number", "boolean", "array", "object", "key",
+	"null", "enum_member", "struct", "event", "operator", "type_parameter",
+}
+
+func (k SymbolKind) String() string {
+	if k > 0 && int(k) < len(symbolKindNames) {
+		return symbolKindNames[k]
+	}
+	return "symbol"
+}
+
+// Symbol is a named symbol found by a workspace symbol search.
+type Symbol struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+// hoverResult is the result of textDocument/hover. Contents may be a
+// MarkupContent, a MarkedString or an array of MarkedStrings.
+type hoverResult struct {
+	Contents json.RawMessage `json:"contents"`
+}
+
+// ServerConfig describes how to launch a language server.
+type ServerConfig struct {
+	// Name identifies the server.
+	Name string `json:"name" yaml:"name"`
+
+	// Command is the server executable; it must speak LSP over stdio.
+	Command string `json:"command" yaml:"command"`
+
+	// Args are command arguments.
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+
+	// Env are extra environment variables for the server process.
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// Languages are the language IDs the server handles, such as "go".
+	Languages []string `json:"languages" yaml:"languages"`
+
+	// InitializationOptions are passed to the server on initialize.
+	InitializationOptions map[string]any `json:"initialization_options,omitempty" yaml:"initialization_options,omitempty"`
+}
+
+// DefaultServers returns configurations for common language servers.
+// Servers whose command is not installed are skipped when used.
+func DefaultServers() []ServerConfig {
+	return []ServerConfig{
+		{Name: "gopls", Command: "gopls", Languages: []string{"go"}},
+		{Name: "pyright", Command: "pyright-langserver", Args: []string{"--stdio"}, Languages: []string{"python"}},
+		{Name: "typescript", Command: "typescript-language-server", Args: []string{"--stdio"},
+			Languages: []string{"typescript", "typescriptreact", "javascript", "javascriptreact"}},
+		{Name: "rust-analyzer", Command: "rust-analyzer", Languages: []string{"rust"}},
+		{Name: "clangd", Command: "clangd", Languages: []string{"c", "cpp"}},
+	}
+}
+
+// languageIDs maps file extensions to LSP language IDs.
+var languageIDs = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".pyi":  "python",
+	".ts":   "typescript",
+	".mts":  "typescript",
+	".cts":  "typescript",
+	".tsx":  "typescriptreact",
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".cjs":  "javascript",
+	".jsx":  "javascriptreact",
+	".rs":   "rust",
+	".c":    "c",
+	".h":    "c",
+	".cc":   "cpp",
+	".cpp":  "cpp",
+	".cxx":  "cpp",
+	".hpp":  "cpp",
+	".java": "java",
+	".rb":   "ruby",
+	".php":  "php",
+	".cs":   "csharp",
+	".lua":  "lua",
+}
+
+// LanguageID returns the LSP language ID of a file, or "" if unknown.
+func LanguageID(path string) string {
+	return languageIDs[strings.ToLower(filepath.Ext(path))]
+}
+
+// PathToURI converts an absolute file path to a file:// URI.
+func PathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// URIToPath converts a file:// URI to a file path. Other URIs are returned
+// unchanged.
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
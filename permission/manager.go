@@ -14,6 +14,35 @@ import (
 // It receives a request and returns the user's decision.
 type PromptHandler func(ctx context.Context, req *Request) (Decision, error)
 
+// Choices offered by InteractionPrompt.
+var interactionChoices = []string{"Allow", "Allow for session", "Deny", "Deny for session"}
+
+// InteractionPrompt adapts an sdk.Interaction to a PromptHandler. The
+// request is presented as a choice between allowing or denying once or
+// for the session, after the diff of a file change; the default is to deny.
+func InteractionPrompt(i sdk.Interaction) PromptHandler {
+	return func(ctx context.Context, req *Request) (Decision, error) {
+		question := fmt.Sprintf("%s (%s risk). Allow %s?", req.Reason, req.RiskLevel, req.ToolName)
+		if req.Diff != "" {
+			question = req.Diff + "\n" + question
+		}
+		choice, err := i.Choose(ctx, question, interactionChoices, "Deny")
+		if err != nil {
+			return DecisionDeny, err
+		}
+		switch choice {
+		case "Allow":
+			return DecisionAllow, nil
+		case "Allow for session":
+			return DecisionAllowSession, nil
+		case "Deny for session":
+			return DecisionDenySession, nil
+		default:
+			return DecisionDeny, nil
+		}
+	}
+}
+
 // Manager handles permission checks and session caching.
 type Manager struct {
 	rules   *Rules
@@ -47,6 +76,8 @@ func NewManager(rules *Rules, enabled bool) *Manager {
 }
 
 // SetPromptHandler sets the function to call when user input is needed.
+// Without one, prompts go to the sdk.Interaction carried by the context of
+// the check, if any.
 func (m *Manager) SetPromptHandler(handler PromptHandler) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -78,6 +109,25 @@ func (m *Manager) cacheKey(toolName string, args map[string]any) string {
 // Check checks if a tool is allowed to execute.
 // Returns a Response indicating whether execution is allowed.
 func (m *Manager) Check(ctx context.Context, toolName string, args map[string]any) (*Response, error) {
+	return m.check(ctx, toolName, args, "")
+}
+
+// ApproveChange is an sdk.ChangeApprover backed by the manager. The change
+// is checked like a call of summary.ToolName on summary.Target, and a
+// prompt receives the diff in Request.Diff:
+//
+//	edit.SetApprover(permissions.ApproveChange)
+func (m *Manager) ApproveChange(ctx context.Context, summary *sdk.ExecutionSummary) (bool, error) {
+	resp, err := m.check(ctx, summary.ToolName, map[string]any{"file_path": summary.Target}, summary.Diff)
+	if err != nil {
+		return false, err
+	}
+	return resp.Allowed, nil
+}
+
+var _ sdk.ChangeApprover = (*Manager)(nil).ApproveChange
+
+func (m *Manager) check(ctx context.Context, toolName string, args map[string]any, diff string) (*Response, error) {
 	// If permissions are disabled, allow everything
 	if !m.enabled {
 		return &Response{Allowed: true, Decision: DecisionAllow}, nil
@@ -125,19 +175,24 @@ func (m *Manager) Check(ctx context.Context, toolName string, args map[string]an
 				return &Response{Allowed: true, Decision: DecisionAllowSession}, nil
 			}
 		}
-		return m.askUser(ctx, toolName, args)
+		return m.askUser(ctx, toolName, args, diff)
 	}
 
 	// Default to asking
-	return m.askUser(ctx, toolName, args)
+	return m.askUser(ctx, toolName, args, diff)
 }
 
 // askUser prompts the user for permission.
-func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]any) (*Response, error) {
+func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]any, diff string) (*Response, error) {
 	m.mu.RLock()
 	handler := m.promptHandler
 	m.mu.RUnlock()
 
+	if handler == nil {
+		if i := sdk.InteractionFromContext(ctx); i != nil {
+			handler = InteractionPrompt(i)
+		}
+	}
 	if handler == nil {
 		// No handler set, default to allow (backwards compatibility)
 		return &Response{Allowed: true, Decision: DecisionAllow}, nil
@@ -145,6 +200,7 @@ func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]
 
 	// Create permission request
 	req := NewRequest(toolName, args)
+	req.Diff = diff
 
 	// Ask the user
 	decision, err := handler(ctx, req)
@@ -45,6 +45,7 @@ type Request struct {
 	Args      map[string]any // Arguments passed to the tool
 	RiskLevel RiskLevel      // Risk level of the operation
 	Reason    string         // Human-readable reason for the request
+	Diff      string         // Unified diff of a pending file change, if any
 }
 
 // NewRequest creates a new permission request.
@@ -87,10 +88,10 @@ func GetToolRiskLevel(toolName string) RiskLevel {
 		"git_status", "git_log", "git_diff", "git_blame",
 		"code_graph", "semantic_search", "history_search",
 		"web_search", "web_fetch", "todo",
-		"task_output", "task_stop":
+		"task_output", "task_stop", "lsp", "go_symbols":
 		return RiskLow
-	case "write", "edit", "git_add", "copy", "move", "mkdir",
-		"atomicwrite", "task", "batch":
+	case "write", "edit", "apply_patch", "git_add", "copy", "move", "mkdir",
+		"atomicwrite", "task", "batch", "lsp_rename", "go_edit":
 		return RiskMedium
 	case "bash", "delete", "git_commit", "ssh":
 		return RiskHigh
@@ -114,6 +115,27 @@ func buildReason(toolName string, args map[string]any) string {
 		}
 		return "Edit file"
 
+	case "apply_patch":
+		if path, ok := args["file_path"].(string); ok && path != "" {
+			return fmt.Sprintf("Apply patch to: %s", path)
+		}
+		return "Apply patch"
+
+	case "lsp_rename":
+		if name, ok := args["new_name"].(string); ok && name != "" {
+			return fmt.Sprintf("Rename symbol to: %s", name)
+		}
+		return "Rename symbol"
+
+	case "go_edit":
+		if path, ok := args["file_path"].(string); ok && path != "" {
+			if name, ok := args["name"].(string); ok && name != "" {
+				return fmt.Sprintf("Edit %s in: %s", name, path)
+			}
+			return fmt.Sprintf("Edit Go file: %s", path)
+		}
+		return "Edit Go file"
+
 	case "bash":
 		if cmd, ok := args["command"].(string); ok {
 			if len(cmd) > 150 {
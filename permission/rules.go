@@ -32,11 +32,16 @@ func DefaultRules() *Rules {
 			"web_fetch":       LevelAllow,
 			"task_output":     LevelAllow,
 			"task_stop":       LevelAllow,
+			"lsp":             LevelAllow,
+			"go_symbols":      LevelAllow,
 
 			// File modification tools - ask before executing (caution)
 			"write":       LevelAsk,
 			"atomicwrite": LevelAsk,
 			"edit":        LevelAsk,
+			"apply_patch": LevelAsk,
+			"lsp_rename":  LevelAsk,
+			"go_edit":     LevelAsk,
 			"git_add":     LevelAsk,
 			"copy":        LevelAsk,
 			"move":        LevelAsk,
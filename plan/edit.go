@@ -0,0 +1,278 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVersionConflict is returned when a diff was made against a stale plan version.
+var ErrVersionConflict = errors.New("plan version conflict")
+
+// ErrInvalidDependency is returned when an edit leaves a step depending on a
+// missing step or creates a dependency cycle.
+var ErrInvalidDependency = errors.New("invalid step dependency")
+
+// EditOp identifies a structured plan edit.
+type EditOp string
+
+const (
+	EditInsert          EditOp = "insert"           // Insert a new step at Position
+	EditRemove          EditOp = "remove"           // Remove StepID
+	EditMove            EditOp = "move"             // Move StepID to Position
+	EditUpdate          EditOp = "update"           // Rewrite title, description or dependencies of StepID
+	EditRequireApproval EditOp = "require_approval" // Set per-step approval on StepID
+	EditConstrain       EditOp = "constrain"        // Replace the constraints of StepID
+)
+
+// StepEdit is a single structured change to a plan.
+type StepEdit struct {
+	Op               EditOp   `json:"op"`
+	StepID           int      `json:"step_id,omitempty"`
+	Position         int      `json:"position"` // 0-based index for insert/move; negative appends
+	Title            string   `json:"title,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	DependsOn        []int    `json:"depends_on,omitempty"`
+	RequiresApproval bool     `json:"requires_approval,omitempty"`
+	Constraints      []string `json:"constraints,omitempty"`
+}
+
+// PlanDiff is an ordered list of edits made against a specific plan version.
+type PlanDiff struct {
+	PlanID      string     `json:"plan_id"`
+	BaseVersion int        `json:"base_version"`
+	Edits       []StepEdit `json:"edits"`
+}
+
+// IsEmpty returns true if the diff contains no edits.
+func (d *PlanDiff) IsEmpty() bool {
+	return d == nil || len(d.Edits) == 0
+}
+
+// NewDiff starts an empty diff against the plan's current version.
+func (p *Plan) NewDiff() *PlanDiff {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return &PlanDiff{PlanID: p.ID, BaseVersion: p.Version}
+}
+
+// ApplyDiff applies all edits in diff atomically: either every edit succeeds
+// and the plan version is bumped, or the plan is left unchanged.
+// Steps that have already started cannot be removed, moved or rewritten, and
+// the edited plan must not depend on missing steps or contain cycles.
+func (p *Plan) ApplyDiff(diff *PlanDiff) error {
+	if diff.IsEmpty() {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if diff.PlanID != "" && diff.PlanID != p.ID {
+		return fmt.Errorf("diff is for plan %s, not %s", diff.PlanID, p.ID)
+	}
+	if diff.BaseVersion != p.Version {
+		return fmt.Errorf("%w: diff base %d, plan at %d", ErrVersionConflict, diff.BaseVersion, p.Version)
+	}
+
+	steps := make([]*Step, len(p.Steps))
+	for i, step := range p.Steps {
+		steps[i] = deepCopyStep(step)
+	}
+
+	lastID := p.lastStepIDLocked()
+	for i, edit := range diff.Edits {
+		var err error
+		steps, err = applyEdit(steps, edit, &lastID)
+		if err != nil {
+			return fmt.Errorf("edit %d (%s): %w", i, edit.Op, err)
+		}
+	}
+	if err := validateDependencies(steps); err != nil {
+		return err
+	}
+
+	p.Steps = steps
+	p.LastStepID = lastID
+	p.Version++
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// applyEdit applies one edit to steps. Inserted steps take the ID after
+// *lastID, which is advanced so IDs are never reused.
+func applyEdit(steps []*Step, edit StepEdit, lastID *int) ([]*Step, error) {
+	if edit.Op == EditInsert {
+		if edit.Title == "" {
+			return nil, fmt.Errorf("inserted step requires a title")
+		}
+		*lastID++
+		step := &Step{
+			ID:               *lastID,
+			Title:            edit.Title,
+			Description:      edit.Description,
+			Status:           StatusPending,
+			DependsOn:        edit.DependsOn,
+			RequiresApproval: edit.RequiresApproval,
+			Constraints:      edit.Constraints,
+		}
+		return insertStep(steps, step, edit.Position), nil
+	}
+
+	idx := indexOfStep(steps, edit.StepID)
+	if idx < 0 {
+		return nil, fmt.Errorf("step %d not found", edit.StepID)
+	}
+	step := steps[idx]
+	if step.Status != StatusPending && step.Status != StatusPaused {
+		return nil, fmt.Errorf("step %d is %s and can no longer be edited", step.ID, step.Status)
+	}
+
+	switch edit.Op {
+	case EditRemove:
+		steps = append(steps[:idx], steps[idx+1:]...)
+		for _, s := range steps {
+			s.DependsOn = removeInt(s.DependsOn, step.ID)
+		}
+	case EditMove:
+		steps = append(steps[:idx], steps[idx+1:]...)
+		steps = insertStep(steps, step, edit.Position)
+	case EditUpdate:
+		if edit.Title != "" {
+			step.Title = edit.Title
+		}
+		if edit.Description != "" {
+			step.Description = edit.Description
+		}
+		if edit.DependsOn != nil {
+			step.DependsOn = edit.DependsOn
+		}
+	case EditRequireApproval:
+		step.RequiresApproval = edit.RequiresApproval
+	case EditConstrain:
+		step.Constraints = edit.Constraints
+	default:
+		return nil, fmt.Errorf("unknown edit op: %s", edit.Op)
+	}
+	return steps, nil
+}
+
+// validateDependencies checks that every dependency names an existing step
+// and that the dependencies are acyclic.
+func validateDependencies(steps []*Step) error {
+	byID := make(map[int]*Step, len(steps))
+	for _, s := range steps {
+		byID[s.ID] = s
+	}
+	for _, s := range steps {
+		for _, dep := range s.DependsOn {
+			if _, ok := byID[dep]; !ok {
+				return fmt.Errorf("%w: step %d depends on missing step %d", ErrInvalidDependency, s.ID, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int]int, len(steps))
+	var visit func(id int) error
+	visit = func(id int) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("%w: step %d is part of a dependency cycle", ErrInvalidDependency, id)
+		case done:
+			return nil
+		}
+		state[id] = visiting
+		for _, dep := range byID[id].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[id] = done
+		return nil
+	}
+	for _, s := range steps {
+		if err := visit(s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertStep(steps []*Step, step *Step, pos int) []*Step {
+	if pos < 0 || pos > len(steps) {
+		pos = len(steps)
+	}
+	steps = append(steps, nil)
+	copy(steps[pos+1:], steps[pos:])
+	steps[pos] = step
+	return steps
+}
+
+func indexOfStep(steps []*Step, id int) int {
+	for i, s := range steps {
+		if s.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeInt(list []int, v int) []int {
+	var out []int
+	for _, x := range list {
+		if x != v {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// CurrentVersion returns the plan's edit version (thread-safe).
+func (p *Plan) CurrentVersion() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.Version
+}
+
+// restoreSteps restores the plan's structure from copies of steps: their
+// order, titles, descriptions, dependencies, approval flags and
+// constraints. Steps that still exist keep their execution state, so an
+// undo during execution does not reset steps that already ran.
+func (p *Plan) restoreSteps(steps []*Step) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Steps = restoreStructure(steps, p.Steps)
+	p.Version++
+	p.UpdatedAt = time.Now()
+}
+
+// restoreStructure copies steps, taking the execution state of each step
+// from the live step with the same ID.
+func restoreStructure(steps, live []*Step) []*Step {
+	byID := make(map[int]*Step, len(live))
+	for _, s := range live {
+		byID[s.ID] = s
+	}
+
+	restored := make([]*Step, len(steps))
+	for i, step := range steps {
+		s := deepCopyStep(step)
+		if cur, ok := byID[s.ID]; ok {
+			s.Status = cur.Status
+			s.Output = cur.Output
+			s.Error = cur.Error
+			s.StartTime = cur.StartTime
+			s.EndTime = cur.EndTime
+			s.RetryCount = cur.RetryCount
+			s.Children = restoreStructure(step.Children, cur.Children)
+		}
+		restored[i] = s
+	}
+	return restored
+}
@@ -0,0 +1,240 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExportFormat identifies a plan visualization format.
+type ExportFormat string
+
+const (
+	FormatMermaid ExportFormat = "mermaid"
+	FormatDOT     ExportFormat = "dot"
+	FormatJSON    ExportFormat = "json"
+)
+
+// ExportSchemaVersion is bumped whenever the JSON export shape changes incompatibly.
+const ExportSchemaVersion = 1
+
+// PlanView is the stable JSON representation of a plan.
+type PlanView struct {
+	SchemaVersion int         `json:"schema_version"`
+	ID            string      `json:"id"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description,omitempty"`
+	Status        string      `json:"status"`
+	Progress      float64     `json:"progress"`
+	Steps         []*StepView `json:"steps"`
+}
+
+// StepView is the stable JSON representation of a plan step.
+type StepView struct {
+	ID         int         `json:"id"`
+	Title      string      `json:"title"`
+	Status     string      `json:"status"`
+	Parallel   bool        `json:"parallel,omitempty"`
+	DependsOn  []int       `json:"depends_on,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+	RetryCount int         `json:"retry_count,omitempty"`
+	Children   []*StepView `json:"children,omitempty"`
+}
+
+// View returns a snapshot of the plan in the stable export schema.
+func (p *Plan) View() *PlanView {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	view := &PlanView{
+		SchemaVersion: ExportSchemaVersion,
+		ID:            p.ID,
+		Title:         p.Title,
+		Description:   p.Description,
+		Status:        p.Status.String(),
+		Progress:      p.progressLocked(),
+		Steps:         make([]*StepView, 0, len(p.Steps)),
+	}
+	for _, step := range p.Steps {
+		view.Steps = append(view.Steps, stepView(step))
+	}
+	return view
+}
+
+func stepView(step *Step) *StepView {
+	v := &StepView{
+		ID:         step.ID,
+		Title:      step.Title,
+		Status:     step.Status.String(),
+		Parallel:   step.Parallel,
+		DependsOn:  step.DependsOn,
+		Error:      step.Error,
+		DurationMs: step.Duration().Milliseconds(),
+		RetryCount: step.RetryCount,
+	}
+	for _, child := range step.Children {
+		v.Children = append(v.Children, stepView(child))
+	}
+	return v
+}
+
+// Export renders the plan in the given format.
+func (p *Plan) Export(format ExportFormat) ([]byte, error) {
+	switch format {
+	case FormatMermaid:
+		return []byte(p.ToMermaid()), nil
+	case FormatDOT:
+		return []byte(p.ToDOT()), nil
+	case FormatJSON:
+		return json.MarshalIndent(p.View(), "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ToMermaid renders the plan as a Mermaid flowchart.
+// Steps run in order unless they declare dependencies, in which case
+// edges follow DependsOn.
+func (p *Plan) ToMermaid() string {
+	view := p.View()
+
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	steps := flattenSteps(view.Steps, "")
+	for _, fs := range steps {
+		sb.WriteString(fmt.Sprintf("    %s[\"%s\"]:::%s\n", fs.key, mermaidLabel(stepLabel(fs.step)), fs.step.Status))
+	}
+	for _, e := range stepEdges(view.Steps) {
+		sb.WriteString(fmt.Sprintf("    %s --> %s\n", e[0], e[1]))
+	}
+	sb.WriteString(mermaidStatusClasses)
+	return sb.String()
+}
+
+// ToDOT renders the plan as a Graphviz DOT digraph.
+func (p *Plan) ToDOT() string {
+	view := p.View()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("digraph %q {\n", view.ID))
+	sb.WriteString("    rankdir=TB;\n")
+	sb.WriteString("    node [shape=box, style=\"rounded,filled\"];\n")
+	for _, fs := range flattenSteps(view.Steps, "") {
+		sb.WriteString(fmt.Sprintf("    %s [label=%q, fillcolor=%q];\n", fs.key, stepLabel(fs.step), statusColor(fs.step.Status)))
+	}
+	for _, e := range stepEdges(view.Steps) {
+		sb.WriteString(fmt.Sprintf("    %s -> %s;\n", e[0], e[1]))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// LiveExport re-renders the manager's current plan on every progress update
+// and passes the result to fn. It watches progress through WatchProgress,
+// so it keeps working when the progress update handler is replaced.
+func LiveExport(m *Manager, format ExportFormat, fn func(data []byte)) {
+	m.WatchProgress(func(progress *ProgressUpdate) {
+		plan := m.GetCurrentPlan()
+		if plan == nil {
+			return
+		}
+		if data, err := plan.Export(format); err == nil {
+			fn(data)
+		}
+	})
+}
+
+// --- internal ---
+
+type flatStep struct {
+	key  string
+	step *StepView
+}
+
+// flattenSteps walks steps depth-first, assigning graph node keys.
+// Nested steps are keyed by their path so IDs stay unique.
+func flattenSteps(steps []*StepView, prefix string) []flatStep {
+	var out []flatStep
+	for _, s := range steps {
+		key := fmt.Sprintf("%sstep%d", prefix, s.ID)
+		out = append(out, flatStep{key: key, step: s})
+		out = append(out, flattenSteps(s.Children, key+"_")...)
+	}
+	return out
+}
+
+// stepEdges returns graph edges for top-level ordering, explicit
+// dependencies and parent/child nesting.
+func stepEdges(steps []*StepView) [][2]string {
+	var edges [][2]string
+	for i, s := range steps {
+		key := fmt.Sprintf("step%d", s.ID)
+		if len(s.DependsOn) > 0 {
+			for _, dep := range s.DependsOn {
+				edges = append(edges, [2]string{fmt.Sprintf("step%d", dep), key})
+			}
+		} else if i > 0 && !s.Parallel {
+			edges = append(edges, [2]string{fmt.Sprintf("step%d", steps[i-1].ID), key})
+		}
+		edges = append(edges, childEdges(s, key)...)
+	}
+	return edges
+}
+
+func childEdges(s *StepView, key string) [][2]string {
+	var edges [][2]string
+	for _, c := range s.Children {
+		ckey := fmt.Sprintf("%s_step%d", key, c.ID)
+		edges = append(edges, [2]string{key, ckey})
+		edges = append(edges, childEdges(c, ckey)...)
+	}
+	return edges
+}
+
+func stepLabel(s *StepView) string {
+	label := fmt.Sprintf("%d. %s", s.ID, s.Title)
+	if s.Error != "" {
+		label += "\n" + truncateLabel(s.Error, 60)
+	}
+	return label
+}
+
+// truncateLabel shortens s to at most n runes, marking the cut with "...".
+func truncateLabel(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+func mermaidLabel(s string) string {
+	s = strings.NewReplacer("\"", "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+func statusColor(status string) string {
+	switch status {
+	case "completed":
+		return "#c8e6c9"
+	case "in_progress":
+		return "#bbdefb"
+	case "failed":
+		return "#ffcdd2"
+	case "skipped":
+		return "#eeeeee"
+	case "paused":
+		return "#fff9c4"
+	default:
+		return "#ffffff"
+	}
+}
+
+const mermaidStatusClasses = `    classDef pending fill:#ffffff,stroke:#999
+    classDef in_progress fill:#bbdefb,stroke:#1976d2
+    classDef completed fill:#c8e6c9,stroke:#388e3c
+    classDef failed fill:#ffcdd2,stroke:#d32f2f
+    classDef skipped fill:#eeeeee,stroke:#999
+    classDef paused fill:#fff9c4,stroke:#fbc02d
+`
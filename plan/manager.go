@@ -2,6 +2,7 @@ package plan
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -21,6 +22,18 @@ const (
 // ApprovalHandler is called to get user approval for a plan.
 type ApprovalHandler func(ctx context.Context, plan *Plan) (ApprovalDecision, error)
 
+// ReviewHandler is called to review a plan with structured edits. It receives
+// the edits made since the last approval and may return a diff of its own,
+// which is applied when the decision is ApprovalModified.
+type ReviewHandler func(ctx context.Context, plan *Plan, changes *PlanDiff) (ApprovalDecision, *PlanDiff, error)
+
+// ErrStepNotApproved is returned by StartStep when a step marked
+// RequiresApproval was not approved.
+var ErrStepNotApproved = errors.New("step not approved")
+
+// StepApprovalHandler is called before a step marked RequiresApproval starts.
+type StepApprovalHandler func(ctx context.Context, step *Step) (ApprovalDecision, error)
+
 // StepHandler is called before executing each step.
 // It can be used to show progress or confirm individual steps.
 type StepHandler func(step *Step)
@@ -34,10 +47,13 @@ type Manager struct {
 	lastRejectedPlan *Plan  // Store the last rejected plan for context
 	lastFeedback     string // Store the last user feedback for plan modifications
 	approvalHandler  ApprovalHandler
+	reviewHandler    ReviewHandler
+	stepApproval     StepApprovalHandler
 	onStepStart      StepHandler
 	onStepComplete   StepHandler
 	onProgressUpdate func(progress *ProgressUpdate) // Progress update handler
-	undoExtension    *ManagerUndoExtension          // Undo/redo support
+	progressWatches  []func(progress *ProgressUpdate)
+	undoExtension    *ManagerUndoExtension // Undo/redo support
 
 	// Plan persistence
 	planStore *PlanStore
@@ -47,6 +63,10 @@ type Manager struct {
 	contextClearRequested bool
 	approvedPlanSnapshot  *Plan
 
+	// Structured edits made since the plan was last approved
+	pendingEdits    []StepEdit
+	approvedVersion int
+
 	// Execution mode tracking - distinguishes between plan creation and plan execution phases
 	executionMode bool // true = executing approved plan, false = creating/designing plan
 	currentStepID int  // ID of the step currently being executed (-1 if none)
@@ -70,6 +90,21 @@ func (m *Manager) SetApprovalHandler(handler ApprovalHandler) {
 	m.approvalHandler = handler
 }
 
+// SetReviewHandler sets the handler for structured plan review.
+// When set, it takes precedence over the approval handler.
+func (m *Manager) SetReviewHandler(handler ReviewHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reviewHandler = handler
+}
+
+// SetStepApprovalHandler sets the handler for per-step approval.
+func (m *Manager) SetStepApprovalHandler(handler StepApprovalHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stepApproval = handler
+}
+
 // SetStepHandlers sets the step lifecycle handlers.
 func (m *Manager) SetStepHandlers(onStart, onComplete StepHandler) {
 	m.mu.Lock()
@@ -112,6 +147,8 @@ func (m *Manager) CreatePlan(title, description, request string) *Plan {
 	plan := NewPlan(title, description)
 	plan.Request = request
 	m.currentPlan = plan
+	m.pendingEdits = nil
+	m.approvedVersion = 0
 	return plan
 }
 
@@ -120,6 +157,10 @@ func (m *Manager) SetPlan(plan *Plan) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.currentPlan = plan
+	m.pendingEdits = nil
+	if plan != nil {
+		m.approvedVersion = plan.CurrentVersion()
+	}
 }
 
 // ClearPlan clears the current plan and returns it if it existed.
@@ -173,6 +214,8 @@ func (m *Manager) RequestApproval(ctx context.Context) (ApprovalDecision, error)
 	m.mu.RLock()
 	plan := m.currentPlan
 	handler := m.approvalHandler
+	reviewer := m.reviewHandler
+	changes := &PlanDiff{BaseVersion: m.approvedVersion, Edits: append([]StepEdit(nil), m.pendingEdits...)}
 	m.mu.RUnlock()
 
 	if plan == nil {
@@ -180,28 +223,185 @@ func (m *Manager) RequestApproval(ctx context.Context) (ApprovalDecision, error)
 	}
 
 	if !m.requireApproval {
+		m.markApproved(plan)
 		return ApprovalApproved, nil
 	}
 
+	if reviewer != nil {
+		changes.PlanID = plan.ID
+		decision, diff, err := reviewer(ctx, plan, changes)
+		if err != nil {
+			return decision, err
+		}
+		if decision == ApprovalModified && !diff.IsEmpty() {
+			if err := m.EditPlan(diff); err != nil {
+				return ApprovalPending, fmt.Errorf("failed to apply review edits: %w", err)
+			}
+		}
+		if decision == ApprovalApproved || decision == ApprovalModified {
+			m.markApproved(plan)
+		}
+		return decision, nil
+	}
+
 	if handler == nil {
 		// No handler, auto-approve
+		m.markApproved(plan)
 		return ApprovalApproved, nil
 	}
 
-	return handler(ctx, plan)
+	decision, err := handler(ctx, plan)
+	if err == nil && decision == ApprovalApproved {
+		m.markApproved(plan)
+	}
+	return decision, err
+}
+
+// EditPlan applies a structured diff to the current plan. When undo is
+// enabled a checkpoint is saved first so the edit can be reverted with
+// UndoEdit.
+func (m *Manager) EditPlan(diff *PlanDiff) error {
+	m.mu.RLock()
+	plan := m.currentPlan
+	undoExt := m.undoExtension
+	store := m.planStore
+	m.mu.RUnlock()
+
+	if plan == nil {
+		return fmt.Errorf("no active plan to edit")
+	}
+	if diff.IsEmpty() {
+		return nil
+	}
+
+	if undoExt != nil {
+		if err := undoExt.SaveCheckpoint(); err != nil {
+			return err
+		}
+	}
+
+	if err := plan.ApplyDiff(diff); err != nil {
+		if undoExt != nil {
+			undoExt.dropLastCheckpoint()
+		}
+		return err
+	}
+
+	m.mu.Lock()
+	m.pendingEdits = append(m.pendingEdits, diff.Edits...)
+	m.mu.Unlock()
+
+	if store != nil {
+		if err := store.Save(plan); err != nil {
+			slog.Warn("failed to save plan state", "error", err)
+		}
+	}
+	return nil
 }
 
-// StartStep marks a step as started.
+// UndoEdit reverts the current plan to the checkpoint taken before the most
+// recent edit. Undo must be enabled with EnableUndo.
+func (m *Manager) UndoEdit() error {
+	m.mu.RLock()
+	undoExt := m.undoExtension
+	store := m.planStore
+	m.mu.RUnlock()
+
+	if undoExt == nil {
+		return fmt.Errorf("undo is not enabled")
+	}
+
+	state, err := undoExt.Undo()
+	if err != nil {
+		return err
+	}
+
+	// Edits made after the checkpoint are no longer part of the plan
+	m.mu.Lock()
+	m.pendingEdits = state.PendingEdits
+	m.mu.Unlock()
+
+	if store != nil {
+		if plan := m.GetCurrentPlan(); plan != nil {
+			if err := store.Save(plan); err != nil {
+				slog.Warn("failed to save plan state", "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ApproveStep asks for approval before starting a step marked RequiresApproval.
+// Steps without the flag, or managers without a step approval handler, are
+// approved automatically.
+func (m *Manager) ApproveStep(ctx context.Context, stepID int) (ApprovalDecision, error) {
+	m.mu.RLock()
+	plan := m.currentPlan
+	handler := m.stepApproval
+	m.mu.RUnlock()
+
+	if plan == nil {
+		return ApprovalRejected, fmt.Errorf("no active plan")
+	}
+
+	step := plan.GetStep(stepID)
+	if step == nil {
+		return ApprovalRejected, fmt.Errorf("step %d not found", stepID)
+	}
+
+	if !step.RequiresApproval || handler == nil {
+		return ApprovalApproved, nil
+	}
+	return handler(ctx, step)
+}
+
+// pendingEditsSnapshot returns a copy of the edits made since the last
+// approval.
+func (m *Manager) pendingEditsSnapshot() []StepEdit {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]StepEdit(nil), m.pendingEdits...)
+}
+
+// markApproved records the plan version that was approved and clears the
+// pending edit log.
+func (m *Manager) markApproved(plan *Plan) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pendingEdits = nil
+	m.approvedVersion = plan.CurrentVersion()
+}
+
+// StartStep marks a step as started. Steps marked RequiresApproval are
+// approved through the step approval handler first; one that is not
+// approved stays pending. Use StartStepContext to learn why.
 func (m *Manager) StartStep(stepID int) {
+	if err := m.StartStepContext(context.Background(), stepID); err != nil {
+		slog.Warn("plan step not started", "step", stepID, "error", err)
+	}
+}
+
+// StartStepContext is like StartStep, passing ctx to the step approval
+// handler. It returns ErrStepNotApproved, and leaves the step pending, if
+// the handler does not approve a step marked RequiresApproval.
+func (m *Manager) StartStepContext(ctx context.Context, stepID int) error {
 	m.mu.Lock()
 	plan := m.currentPlan
 	store := m.planStore
 	onStart := m.onStepStart
-	onProgress := m.onProgressUpdate
+	onProgress := m.progressHandlerLocked()
 	m.mu.Unlock()
 
 	if plan == nil {
-		return
+		return nil
+	}
+
+	decision, err := m.ApproveStep(ctx, stepID)
+	if err != nil {
+		return err
+	}
+	if decision != ApprovalApproved && decision != ApprovalModified {
+		return fmt.Errorf("%w: step %d", ErrStepNotApproved, stepID)
 	}
 
 	plan.StartStep(stepID)
@@ -235,6 +435,7 @@ func (m *Manager) StartStep(stepID int) {
 			onStart(step)
 		}
 	}
+	return nil
 }
 
 // CompleteStep marks a step as completed.
@@ -243,7 +444,7 @@ func (m *Manager) CompleteStep(stepID int, output string) {
 	plan := m.currentPlan
 	store := m.planStore
 	onComplete := m.onStepComplete
-	onProgress := m.onProgressUpdate
+	onProgress := m.progressHandlerLocked()
 	m.mu.Unlock()
 
 	if plan == nil {
@@ -292,7 +493,7 @@ func (m *Manager) FailStep(stepID int, errMsg string) {
 	m.mu.Lock()
 	plan := m.currentPlan
 	store := m.planStore
-	onProgress := m.onProgressUpdate
+	onProgress := m.progressHandlerLocked()
 	m.mu.Unlock()
 
 	if plan == nil {
@@ -329,7 +530,7 @@ func (m *Manager) FailStep(stepID int, errMsg string) {
 func (m *Manager) SkipStep(stepID int) {
 	m.mu.Lock()
 	plan := m.currentPlan
-	onProgress := m.onProgressUpdate
+	onProgress := m.progressHandlerLocked()
 	m.mu.Unlock()
 
 	if plan == nil {
@@ -360,7 +561,7 @@ func (m *Manager) PauseStep(stepID int, reason string) {
 	m.mu.Lock()
 	plan := m.currentPlan
 	store := m.planStore
-	onProgress := m.onProgressUpdate
+	onProgress := m.progressHandlerLocked()
 	m.mu.Unlock()
 
 	if plan == nil {
@@ -469,13 +670,41 @@ func (m *Manager) GetPreviousStepsSummary(currentStepID int, maxLen int) string
 	return sb.String()
 }
 
-// SetProgressUpdateHandler sets the progress update handler.
+// SetProgressUpdateHandler sets the progress update handler. Watchers
+// added with WatchProgress are not affected.
 func (m *Manager) SetProgressUpdateHandler(handler func(progress *ProgressUpdate)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.onProgressUpdate = handler
 }
 
+// WatchProgress adds fn to the functions called on every progress update,
+// after the progress update handler.
+func (m *Manager) WatchProgress(fn func(progress *ProgressUpdate)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.progressWatches = append(m.progressWatches, fn)
+}
+
+// progressHandlerLocked returns a function calling the progress update
+// handler and every watcher, or nil if there are none. The caller must
+// hold m.mu.
+func (m *Manager) progressHandlerLocked() func(progress *ProgressUpdate) {
+	handler := m.onProgressUpdate
+	watches := m.progressWatches
+	if len(watches) == 0 {
+		return handler
+	}
+	return func(progress *ProgressUpdate) {
+		if handler != nil {
+			handler(progress)
+		}
+		for _, fn := range watches {
+			fn(progress)
+		}
+	}
+}
+
 // EnableUndo enables undo/redo support for plan execution.
 func (m *Manager) EnableUndo(maxHistory int) {
 	m.mu.Lock()
@@ -74,6 +74,9 @@ type Step struct {
 	MaxRetries  int           `json:"max_retries,omitempty"`   // Max retry attempts (0 = no retries)
 	Timeout     time.Duration `json:"timeout,omitempty"`       // Per-step timeout (0 = no timeout)
 	RetryCount  int           `json:"retry_count,omitempty"`   // Current retry count
+
+	RequiresApproval bool     `json:"requires_approval,omitempty"` // Must be approved before it starts
+	Constraints      []string `json:"constraints,omitempty"`       // Free-form rules the step must respect
 }
 
 // Duration returns the step execution duration.
@@ -114,6 +117,13 @@ type Plan struct {
 	// Context snapshot from planning conversation (preserved across session clear)
 	ContextSnapshot string `json:"context_snapshot,omitempty"`
 
+	// Version is incremented by every structured edit (see ApplyDiff).
+	Version int `json:"version"`
+
+	// LastStepID is the highest step ID ever allocated. IDs of removed
+	// steps are not reused, so old references cannot point at a new step.
+	LastStepID int `json:"last_step_id,omitempty"`
+
 	mu sync.RWMutex
 }
 
@@ -156,7 +166,7 @@ func (p *Plan) AddStepWithOptions(title, description string, parallel bool, depe
 	defer p.mu.Unlock()
 
 	step := &Step{
-		ID:          len(p.Steps) + 1,
+		ID:          p.nextStepIDLocked(),
 		Title:       title,
 		Description: description,
 		Status:      StatusPending,
@@ -174,7 +184,7 @@ func (p *Plan) AddStepFull(title, description string, parallel bool, dependsOn [
 	defer p.mu.Unlock()
 
 	step := &Step{
-		ID:          len(p.Steps) + 1,
+		ID:          p.nextStepIDLocked(),
 		Title:       title,
 		Description: description,
 		Status:      StatusPending,
@@ -188,6 +198,24 @@ func (p *Plan) AddStepFull(title, description string, parallel bool, dependsOn [
 	return step
 }
 
+// lastStepIDLocked returns the highest step ID allocated so far, also
+// covering plans saved before LastStepID existed. The caller must hold p.mu.
+func (p *Plan) lastStepIDLocked() int {
+	last := p.LastStepID
+	for _, step := range p.Steps {
+		if step.ID > last {
+			last = step.ID
+		}
+	}
+	return last
+}
+
+// nextStepIDLocked allocates a new step ID. The caller must hold p.mu.
+func (p *Plan) nextStepIDLocked() int {
+	p.LastStepID = p.lastStepIDLocked() + 1
+	return p.LastStepID
+}
+
 // GetStep returns a step by ID.
 func (p *Plan) GetStep(id int) *Step {
 	p.mu.RLock()
@@ -523,6 +551,8 @@ func (p *Plan) GetStepsSnapshot() []*Step {
 // deepCopyStep performs a deep copy of a Step, including its Children.
 func deepCopyStep(step *Step) *Step {
 	stepCopy := *step
+	stepCopy.DependsOn = append([]int(nil), step.DependsOn...)
+	stepCopy.Constraints = append([]string(nil), step.Constraints...)
 	if len(step.Children) > 0 {
 		stepCopy.Children = make([]*Step, len(step.Children))
 		for i, child := range step.Children {
@@ -14,6 +14,10 @@ type UndoState struct {
 	Steps       []*Step   `json:"steps"`
 	Timestamp   time.Time `json:"timestamp"`
 	Executed    []int     `json:"executed"` // IDs of steps that were executed
+	Version     int       `json:"version"`  // Plan version at checkpoint time
+
+	// PendingEdits are the edits awaiting review at checkpoint time.
+	PendingEdits []StepEdit `json:"pending_edits,omitempty"`
 }
 
 // ManagerUndoExtension extends the plan Manager with undo/redo capabilities.
@@ -48,17 +52,12 @@ func (e *ManagerUndoExtension) SaveCheckpoint() error {
 		PlanTitle:   plan.Title,
 		Description: plan.Description,
 		Request:     plan.Request,
-		Steps:       make([]*Step, len(plan.Steps)),
+		Steps:       plan.GetStepsSnapshot(), // deep copy isolates checkpoint from future modifications
 		Timestamp:   time.Now(),
 		Executed:    make([]int, 0),
-	}
+		Version:     plan.CurrentVersion(),
 
-	// Deep copy steps to isolate checkpoint from future modifications
-	for i, step := range plan.Steps {
-		if step != nil {
-			stepCopy := *step
-			state.Steps[i] = &stepCopy
-		}
+		PendingEdits: e.manager.pendingEditsSnapshot(),
 	}
 
 	// Add to history
@@ -104,3 +103,34 @@ func (e *ManagerUndoExtension) ClearHistory() {
 func (e *ManagerUndoExtension) CanUndo() bool {
 	return len(e.history) > 0
 }
+
+// Undo restores the current plan's structure from the most recent
+// checkpoint and removes that checkpoint from history. Steps keep their
+// execution state. The plan version still moves forward,
+// so diffs prepared against the undone state are rejected.
+func (e *ManagerUndoExtension) Undo() (*UndoState, error) {
+	if len(e.history) == 0 {
+		return nil, fmt.Errorf("no checkpoint to restore")
+	}
+
+	plan := e.manager.GetCurrentPlan()
+	if plan == nil {
+		return nil, fmt.Errorf("no active plan to restore")
+	}
+
+	state := e.history[len(e.history)-1]
+	if state.PlanID != plan.ID {
+		return nil, fmt.Errorf("checkpoint belongs to plan %s, current plan is %s", state.PlanID, plan.ID)
+	}
+
+	e.history = e.history[:len(e.history)-1]
+	plan.restoreSteps(state.Steps)
+	return state, nil
+}
+
+// dropLastCheckpoint discards the most recent checkpoint without restoring it.
+func (e *ManagerUndoExtension) dropLastCheckpoint() {
+	if len(e.history) > 0 {
+		e.history = e.history[:len(e.history)-1]
+	}
+}
@@ -0,0 +1,241 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// PlanExportFormat identifies a plan tree visualization format.
+type PlanExportFormat string
+
+const (
+	PlanExportMermaid PlanExportFormat = "mermaid"
+	PlanExportDOT     PlanExportFormat = "dot"
+	PlanExportJSON    PlanExportFormat = "json"
+)
+
+// PlanTreeSchemaVersion is bumped whenever the JSON export shape changes incompatibly.
+const PlanTreeSchemaVersion = 1
+
+// PlanTreeView is the stable JSON representation of a plan tree.
+type PlanTreeView struct {
+	SchemaVersion int           `json:"schema_version"`
+	TotalNodes    int           `json:"total_nodes"`
+	Simulation    string        `json:"simulation,omitempty"`
+	BestPath      []string      `json:"best_path,omitempty"`
+	Root          *PlanNodeView `json:"root,omitempty"`
+}
+
+// PlanNodeView is the stable JSON representation of a plan node.
+type PlanNodeView struct {
+	ID         string          `json:"id"`
+	ParentID   string          `json:"parent_id,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Prompt     string          `json:"prompt,omitempty"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	Status     string          `json:"status"`
+	Score      float64         `json:"score"`
+	Visits     int             `json:"visits"`
+	AvgReward  float64         `json:"avg_reward"`
+	Error      string          `json:"error,omitempty"`
+	OnBestPath bool            `json:"on_best_path,omitempty"`
+	Children   []*PlanNodeView `json:"children,omitempty"`
+}
+
+// View returns a snapshot of the tree in the stable export schema.
+func (t *PlanTree) View() *PlanTreeView {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	view := &PlanTreeView{
+		SchemaVersion: PlanTreeSchemaVersion,
+		TotalNodes:    t.TotalNodes,
+		Simulation:    string(t.Simulation),
+	}
+
+	onPath := make(map[string]bool, len(t.BestPath))
+	for _, n := range t.BestPath {
+		onPath[n.ID] = true
+		view.BestPath = append(view.BestPath, n.ID)
+	}
+
+	if t.Root != nil {
+		view.Root = planNodeView(t.Root, onPath)
+	}
+	return view
+}
+
+func planNodeView(node *PlanNode, onPath map[string]bool) *PlanNodeView {
+	v := &PlanNodeView{
+		ID:         node.ID,
+		ParentID:   node.ParentID,
+		Status:     string(node.Status),
+		Score:      node.Score,
+		Visits:     node.Visits,
+		OnBestPath: onPath[node.ID],
+	}
+	if node.Visits > 0 {
+		v.AvgReward = node.TotalReward / float64(node.Visits)
+	}
+	if node.Action != nil {
+		v.Type = string(node.Action.Type)
+		v.Prompt = node.Action.Prompt
+		v.ToolName = node.Action.ToolName
+	}
+	if node.Result != nil {
+		v.Error = node.Result.Error
+	}
+	for _, child := range node.Children {
+		v.Children = append(v.Children, planNodeView(child, onPath))
+	}
+	return v
+}
+
+// Export renders the tree in the given format.
+func (t *PlanTree) Export(format PlanExportFormat) ([]byte, error) {
+	switch format {
+	case PlanExportMermaid:
+		return []byte(t.ToMermaid()), nil
+	case PlanExportDOT:
+		return []byte(t.ToDOT()), nil
+	case PlanExportJSON:
+		return json.MarshalIndent(t.View(), "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ToMermaid renders the tree as a Mermaid flowchart. Edges on the best
+// path are drawn thick; nodes are styled by status.
+func (t *PlanTree) ToMermaid() string {
+	view := t.View()
+
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	walkNodeViews(view.Root, func(n *PlanNodeView) {
+		sb.WriteString(fmt.Sprintf("    %s[\"%s\"]:::%s\n", graphNodeID(n.ID), mermaidEscape(planNodeLabel(n)), n.Status))
+	})
+	walkNodeViews(view.Root, func(n *PlanNodeView) {
+		for _, c := range n.Children {
+			arrow := "-->"
+			if n.OnBestPath && c.OnBestPath {
+				arrow = "==>"
+			}
+			sb.WriteString(fmt.Sprintf("    %s %s %s\n", graphNodeID(n.ID), arrow, graphNodeID(c.ID)))
+		}
+	})
+	sb.WriteString(`    classDef pending fill:#ffffff,stroke:#999
+    classDef running fill:#bbdefb,stroke:#1976d2
+    classDef completed fill:#c8e6c9,stroke:#388e3c
+    classDef failed fill:#ffcdd2,stroke:#d32f2f
+    classDef skipped fill:#eeeeee,stroke:#999
+`)
+	return sb.String()
+}
+
+// ToDOT renders the tree as a Graphviz DOT digraph.
+func (t *PlanTree) ToDOT() string {
+	view := t.View()
+
+	var sb strings.Builder
+	sb.WriteString("digraph plan {\n")
+	sb.WriteString("    rankdir=TB;\n")
+	sb.WriteString("    node [shape=box, style=\"rounded,filled\"];\n")
+	walkNodeViews(view.Root, func(n *PlanNodeView) {
+		penwidth := 1
+		if n.OnBestPath {
+			penwidth = 3
+		}
+		sb.WriteString(fmt.Sprintf("    %s [label=%q, fillcolor=%q, penwidth=%d];\n",
+			graphNodeID(n.ID), planNodeLabel(n), planStatusColor(n.Status), penwidth))
+	})
+	walkNodeViews(view.Root, func(n *PlanNodeView) {
+		for _, c := range n.Children {
+			attrs := ""
+			if n.OnBestPath && c.OnBestPath {
+				attrs = " [penwidth=3]"
+			}
+			sb.WriteString(fmt.Sprintf("    %s -> %s%s;\n", graphNodeID(n.ID), graphNodeID(c.ID), attrs))
+		}
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// --- internal ---
+
+func walkNodeViews(n *PlanNodeView, fn func(*PlanNodeView)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	for _, c := range n.Children {
+		walkNodeViews(c, fn)
+	}
+}
+
+func planNodeLabel(n *PlanNodeView) string {
+	label := fmt.Sprintf("[%s] %s", n.Type, truncateLabel(n.Prompt, 60))
+	if n.ToolName != "" {
+		label += fmt.Sprintf(" (%s)", n.ToolName)
+	}
+	label += fmt.Sprintf("\nscore=%.2f visits=%d", n.Score, n.Visits)
+	if n.Error != "" {
+		label += "\nerror: " + truncateLabel(n.Error, 60)
+	}
+	return label
+}
+
+// truncateLabel shortens s to at most n runes, marking the cut with "...".
+func truncateLabel(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// graphNodeID converts a plan node ID into an identifier valid in both
+// Mermaid and DOT. IDs that had to be rewritten get a hash of the original
+// appended, so "a-b" and "a_b" stay distinct.
+func graphNodeID(id string) string {
+	var sb strings.Builder
+	sb.WriteString("n_")
+	rewritten := false
+	for _, r := range id {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+			rewritten = true
+		}
+	}
+	if rewritten {
+		h := fnv.New32a()
+		h.Write([]byte(id))
+		fmt.Fprintf(&sb, "_%08x", h.Sum32())
+	}
+	return sb.String()
+}
+
+func mermaidEscape(s string) string {
+	s = strings.NewReplacer("\"", "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+func planStatusColor(status string) string {
+	switch PlanNodeStatus(status) {
+	case PlanNodeCompleted:
+		return "#c8e6c9"
+	case PlanNodeRunning:
+		return "#bbdefb"
+	case PlanNodeFailed:
+		return "#ffcdd2"
+	case PlanNodeSkipped:
+		return "#eeeeee"
+	default:
+		return "#ffffff"
+	}
+}
@@ -64,6 +64,8 @@ type PlanLifecycle struct {
 	ReplanCount  int    `json:"replan_count"`
 	ReplanReason string `json:"replan_reason,omitempty"`
 
+	onTransition func(from, to PlanLifecycleState)
+
 	mu sync.Mutex
 }
 
@@ -80,38 +82,103 @@ func NewPlanLifecycle(planID string, tree *PlanTree) *PlanLifecycle {
 	}
 }
 
+// OnTransition sets a callback invoked after every successful state change.
+func (lc *PlanLifecycle) OnTransition(fn func(from, to PlanLifecycleState)) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.onTransition = fn
+}
+
 // TransitionTo moves the plan to a new lifecycle state.
 func (lc *PlanLifecycle) TransitionTo(state PlanLifecycleState) error {
 	lc.mu.Lock()
-	defer lc.mu.Unlock()
 
 	if !CanTransitionTo(lc.State, state) {
-		return fmt.Errorf("invalid transition from %s to %s", lc.State, state)
+		from := lc.State
+		lc.mu.Unlock()
+		return fmt.Errorf("invalid transition from %s to %s", from, state)
 	}
 
+	from := lc.State
 	lc.State = state
 	lc.Version++
 	lc.UpdatedAt = time.Now()
+	fn := lc.onTransition
+	lc.mu.Unlock()
+
+	if fn != nil {
+		fn(from, state)
+	}
 	return nil
 }
 
 // RequestReplan marks the plan for replanning by transitioning to draft.
 func (lc *PlanLifecycle) RequestReplan(reason string) error {
 	lc.mu.Lock()
-	defer lc.mu.Unlock()
 
 	if lc.State != PlanStateFailed && lc.State != PlanStatePaused {
-		return fmt.Errorf("can only replan from failed or paused state, current: %s", lc.State)
+		current := lc.State
+		lc.mu.Unlock()
+		return fmt.Errorf("can only replan from failed or paused state, current: %s", current)
 	}
 
+	from := lc.State
 	lc.State = PlanStateDraft
 	lc.ReplanCount++
 	lc.ReplanReason = reason
 	lc.Version++
 	lc.UpdatedAt = time.Now()
+	fn := lc.onTransition
+	lc.mu.Unlock()
+
+	if fn != nil {
+		fn(from, PlanStateDraft)
+	}
 	return nil
 }
 
+// SetTree replaces the plan tree, e.g. after a full rebuild.
+func (lc *PlanLifecycle) SetTree(tree *PlanTree) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.Tree = tree
+	lc.Version++
+	lc.UpdatedAt = time.Now()
+}
+
+// PlanLifecycleSnapshot is the serializable state of a PlanLifecycle,
+// used for checkpointing.
+type PlanLifecycleSnapshot struct {
+	PlanID       string             `json:"plan_id"`
+	State        PlanLifecycleState `json:"state"`
+	Version      int                `json:"version"`
+	ReplanCount  int                `json:"replan_count"`
+	ReplanReason string             `json:"replan_reason,omitempty"`
+}
+
+// Snapshot returns the lifecycle's current state without its tree.
+func (lc *PlanLifecycle) Snapshot() *PlanLifecycleSnapshot {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return &PlanLifecycleSnapshot{
+		PlanID:       lc.PlanID,
+		State:        lc.State,
+		Version:      lc.Version,
+		ReplanCount:  lc.ReplanCount,
+		ReplanReason: lc.ReplanReason,
+	}
+}
+
+// RestorePlanLifecycle recreates a lifecycle from a snapshot and its tree.
+func RestorePlanLifecycle(s *PlanLifecycleSnapshot, tree *PlanTree) *PlanLifecycle {
+	lc := NewPlanLifecycle(s.PlanID, tree)
+	lc.State = s.State
+	lc.Version = s.Version
+	lc.ReplanCount = s.ReplanCount
+	lc.ReplanReason = s.ReplanReason
+	return lc
+}
+
 // IsActive returns true if the plan is in an active (non-terminal) state.
 func (lc *PlanLifecycle) IsActive() bool {
 	lc.mu.Lock()
@@ -0,0 +1,265 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SimulationMode selects how MCTS estimates the reward of a node.
+type SimulationMode string
+
+const (
+	// SimulationHeuristic scores nodes with static heuristics only.
+	SimulationHeuristic SimulationMode = "heuristic"
+	// SimulationCritic asks a critic model to score the path to a node.
+	SimulationCritic SimulationMode = "critic"
+)
+
+// CriticConfig configures LLM-evaluated MCTS rollouts.
+type CriticConfig struct {
+	// TokenBudget caps the critic tokens spent per search (0 = unlimited).
+	// Once exhausted, rollouts fall back to heuristic scoring.
+	TokenBudget int
+
+	// MaxConcurrency limits parallel critic calls (default: 4).
+	MaxConcurrency int
+
+	// HeuristicWeight blends the heuristic score into the critic score
+	// (0.0 = critic only, 1.0 = heuristic only).
+	HeuristicWeight float64
+}
+
+// DefaultCriticConfig returns sensible defaults for critic rollouts.
+func DefaultCriticConfig() CriticConfig {
+	return CriticConfig{
+		TokenBudget:     20000,
+		MaxConcurrency:  4,
+		HeuristicWeight: 0.2,
+	}
+}
+
+// RolloutStats summarizes critic usage during a single search.
+type RolloutStats struct {
+	CriticCalls int `json:"critic_calls"`
+	CacheHits   int `json:"cache_hits"`
+	Fallbacks   int `json:"fallbacks"`
+	TokensUsed  int `json:"tokens_used"`
+}
+
+// criticReplyTokens is the token estimate reserved for a critic's reply.
+const criticReplyTokens = 64
+
+// rolloutCritic scores candidate plan paths with a cheap model.
+type rolloutCritic struct {
+	client Client
+	config CriticConfig
+	sem    chan struct{}
+
+	mu       sync.Mutex
+	cache    map[string]float64
+	stats    RolloutStats
+	reserved int // estimated tokens of calls in flight
+}
+
+func newRolloutCritic(client Client, config CriticConfig) *rolloutCritic {
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = 4
+	}
+	return &rolloutCritic{
+		client: client,
+		config: config,
+		sem:    make(chan struct{}, config.MaxConcurrency),
+		cache:  make(map[string]float64),
+	}
+}
+
+// reset clears per-search accounting. The rollout cache is kept across
+// searches so replanning does not pay for paths it has already scored.
+func (c *rolloutCritic) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = RolloutStats{}
+	c.reserved = 0
+}
+
+func (c *rolloutCritic) snapshot() RolloutStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// reserve sets aside estimate tokens for a call, reporting false if the
+// per-search budget cannot cover it together with the calls in flight.
+// The caller must hold c.mu.
+func (c *rolloutCritic) reserve(estimate int) bool {
+	if c.config.TokenBudget > 0 && c.stats.TokensUsed+c.reserved+estimate > c.config.TokenBudget {
+		return false
+	}
+	c.reserved += estimate
+	return true
+}
+
+// settle replaces a call's reservation with the tokens it actually used.
+// The caller must hold c.mu.
+func (c *rolloutCritic) settle(estimate, used int) {
+	c.reserved -= estimate
+	c.stats.TokensUsed += used
+}
+
+// evaluate returns the critic score for path, or ok=false if the critic
+// could not be used (budget exhausted, request failed, unparsable reply).
+func (c *rolloutCritic) evaluate(ctx context.Context, goal PlanGoal, path []*PlanNode) (float64, bool) {
+	key := rolloutKey(goal, path)
+	prompt := buildCriticPrompt(goal, path)
+
+	// Reserve before calling so concurrent prefetches cannot overrun the
+	// budget; the reservation is settled with the real usage afterwards.
+	estimate := len(prompt)/4 + criticReplyTokens
+	c.mu.Lock()
+	if score, ok := c.cache[key]; ok {
+		c.stats.CacheHits++
+		c.mu.Unlock()
+		return score, true
+	}
+	if !c.reserve(estimate) {
+		c.stats.Fallbacks++
+		c.mu.Unlock()
+		return 0, false
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.sem <- struct{}{}:
+		defer func() { <-c.sem }()
+	case <-ctx.Done():
+		c.release(estimate, 0, false)
+		return 0, false
+	}
+
+	sr, err := c.client.SendMessage(ctx, prompt)
+	if err != nil {
+		c.release(estimate, estimate, true)
+		return 0, false
+	}
+	resp, err := sr.Collect(ctx)
+	if err != nil {
+		c.release(estimate, estimate, true)
+		return 0, false
+	}
+
+	score, perr := parseCriticScore(resp.Text)
+
+	used := resp.InputTokens + resp.OutputTokens
+	if used == 0 {
+		// The provider did not report usage
+		used = estimate
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.CriticCalls++
+	c.settle(estimate, used)
+	if perr != nil {
+		c.stats.Fallbacks++
+		return 0, false
+	}
+	c.cache[key] = score
+	return score, true
+}
+
+// prefetch scores several paths concurrently, filling the rollout cache.
+func (c *rolloutCritic) prefetch(ctx context.Context, goal PlanGoal, paths [][]*PlanNode) {
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path []*PlanNode) {
+			defer wg.Done()
+			c.evaluate(ctx, goal, path)
+		}(path)
+	}
+	wg.Wait()
+}
+
+// release settles a call that produced no score, counting a fallback if
+// fallback is set.
+func (c *rolloutCritic) release(estimate, used int, fallback bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settle(estimate, used)
+	if fallback {
+		c.stats.Fallbacks++
+	}
+}
+
+// buildCriticPrompt asks the critic to rate a candidate path against the goal.
+func buildCriticPrompt(goal PlanGoal, path []*PlanNode) string {
+	var sb strings.Builder
+	sb.WriteString("You are evaluating a candidate plan. Rate how likely it is to achieve the goal.\n\n")
+	sb.WriteString(fmt.Sprintf("Goal: %s\n", goal.Description))
+	if len(goal.SuccessCriteria) > 0 {
+		sb.WriteString("Success criteria:\n")
+		for _, c := range goal.SuccessCriteria {
+			sb.WriteString(fmt.Sprintf("- %s\n", c))
+		}
+	}
+	sb.WriteString("\nPlan steps:\n")
+	for i, node := range path {
+		if node.Action == nil {
+			continue
+		}
+		line := fmt.Sprintf("%d. [%s] %s", i+1, node.Action.Type, node.Action.Prompt)
+		if node.Action.ToolName != "" {
+			line += fmt.Sprintf(" (tool: %s)", node.Action.ToolName)
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString(`
+Return only JSON: {"score": <0.0-1.0>, "reason": "..."}`)
+	return sb.String()
+}
+
+// parseCriticScore extracts a score in [0, 1] from the critic's reply.
+func parseCriticScore(text string) (float64, error) {
+	if idx := strings.Index(text, "{"); idx >= 0 {
+		if end := strings.LastIndex(text, "}"); end > idx {
+			text = text[idx : end+1]
+		}
+	}
+
+	var reply struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(text), &reply); err != nil {
+		return 0, fmt.Errorf("invalid critic reply: %w", err)
+	}
+	if reply.Score == nil {
+		return 0, fmt.Errorf("critic reply has no score")
+	}
+
+	score := *reply.Score
+	if score < 0 {
+		score = 0
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score, nil
+}
+
+// rolloutKey identifies a path for caching by goal and action content.
+func rolloutKey(goal PlanGoal, path []*PlanNode) string {
+	h := sha256.New()
+	h.Write([]byte(goal.Description))
+	for _, node := range path {
+		if node.Action == nil {
+			continue
+		}
+		fmt.Fprintf(h, "\x00%s\x00%s\x00%s", node.Action.Type, node.Action.ToolName, node.Action.Prompt)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
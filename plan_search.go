@@ -134,6 +134,15 @@ func (p *Planner) mctsSearch(ctx context.Context, tree *PlanTree, goal PlanGoal)
 		maxNodes = 100
 	}
 
+	tree.Simulation = p.simulationFor(goal)
+	if p.critic != nil {
+		p.critic.reset()
+		defer func() {
+			stats := p.critic.snapshot()
+			tree.Rollouts = &stats
+		}()
+	}
+
 	for i := 0; i < iterations; i++ {
 		select {
 		case <-ctx.Done():
@@ -151,11 +160,19 @@ func (p *Planner) mctsSearch(ctx context.Context, tree *PlanTree, goal PlanGoal)
 		// 2. Expansion: add children to selected node
 		if len(selected.Children) == 0 && selected.Status == PlanNodePending && selected.Action != nil {
 			expandCtx := selected.Action.Prompt
-			_ = p.ExpandNode(ctx, tree, selected.ID, expandCtx)
+			if err := p.ExpandNode(ctx, tree, selected.ID, expandCtx); err == nil && tree.Simulation == SimulationCritic {
+				// Score the new children in parallel; later iterations
+				// that select them will hit the rollout cache.
+				paths := make([][]*PlanNode, 0, len(selected.Children))
+				for _, child := range selected.Children {
+					paths = append(paths, p.pathTo(tree, child))
+				}
+				p.critic.prefetch(ctx, goal, paths)
+			}
 		}
 
 		// 3. Simulation: estimate reward
-		reward := p.mctsSimulate(selected, goal)
+		reward := p.mctsSimulate(ctx, tree, selected, goal)
 
 		// 4. Backpropagation: update visits and rewards up the tree
 		tree.mu.Lock()
@@ -166,6 +183,19 @@ func (p *Planner) mctsSearch(ctx context.Context, tree *PlanTree, goal PlanGoal)
 	return p.extractBestPath(tree), nil
 }
 
+// simulationFor picks the rollout mode for a search. With a critic
+// configured, the mode that has produced the most successful plans for the
+// goal's task type wins; without outcomes yet, the critic is used.
+func (p *Planner) simulationFor(goal PlanGoal) SimulationMode {
+	if p.critic == nil || p.optimizer == nil {
+		return p.simulation
+	}
+	if mode, ok := p.optimizer.BestSimulationMode(classifyTaskType(goal.Description)); ok {
+		return mode
+	}
+	return p.simulation
+}
+
 // mctsSelect walks down the tree using UCB1 formula.
 func (p *Planner) mctsSelect(node *PlanNode, c float64) *PlanNode {
 	for len(node.Children) > 0 {
@@ -205,10 +235,38 @@ func (p *Planner) mctsSelect(node *PlanNode, c float64) *PlanNode {
 	return node
 }
 
-// mctsSimulate estimates the reward for a node based on scoring.
-func (p *Planner) mctsSimulate(node *PlanNode, goal PlanGoal) float64 {
+// mctsSimulate estimates the reward for a node. In critic mode the path to
+// the node is scored by the critic and blended with the heuristic score.
+func (p *Planner) mctsSimulate(ctx context.Context, tree *PlanTree, node *PlanNode, goal PlanGoal) float64 {
 	score := p.ScoreNode(node, goal)
-	return score.Composite
+	if tree.Simulation != SimulationCritic || p.critic == nil {
+		return score.Composite
+	}
+
+	criticScore, ok := p.critic.evaluate(ctx, goal, p.pathTo(tree, node))
+	if !ok {
+		return score.Composite
+	}
+
+	w := p.critic.config.HeuristicWeight
+	return w*score.Composite + (1-w)*criticScore
+}
+
+// pathTo returns the nodes from the root down to node.
+func (p *Planner) pathTo(tree *PlanTree, node *PlanNode) []*PlanNode {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	path := []*PlanNode{node}
+	for id := node.ParentID; id != ""; {
+		parent, ok := tree.nodeIndex[id]
+		if !ok {
+			break
+		}
+		path = append([]*PlanNode{parent}, path...)
+		id = parent.ParentID
+	}
+	return path
 }
 
 // extractBestPath finds the highest-reward path from root to leaf.
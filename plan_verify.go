@@ -0,0 +1,142 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// VerifyKind identifies the kind of check a verify action performs.
+type VerifyKind string
+
+const (
+	// VerifyCommand runs a shell command through the "bash" tool and checks its exit code.
+	VerifyCommand VerifyKind = "command"
+	// VerifyTests runs the "run_tests" tool and checks that the suite passes.
+	VerifyTests VerifyKind = "tests"
+	// VerifyFileExists asserts that a path exists on disk.
+	VerifyFileExists VerifyKind = "file_exists"
+)
+
+// VerifySpec describes the concrete check behind an ActionVerify node.
+type VerifySpec struct {
+	Kind     VerifyKind `json:"kind"`
+	Command  string     `json:"command,omitempty"`
+	Path     string     `json:"path,omitempty"`
+	Filter   string     `json:"filter,omitempty"`
+	ExitCode int        `json:"exit_code,omitempty"`
+}
+
+// Verifier checks a verify action and reports pass/fail as a PlanResult.
+type Verifier interface {
+	Verify(ctx context.Context, spec *VerifySpec) *PlanResult
+}
+
+// ToolVerifier runs verification checks through registered tools.
+type ToolVerifier struct {
+	executor *Executor
+	workDir  string
+}
+
+// NewToolVerifier creates a verifier that runs checks through the executor's registry.
+// Relative paths in file_exists checks are resolved against workDir.
+func NewToolVerifier(executor *Executor, workDir string) *ToolVerifier {
+	return &ToolVerifier{
+		executor: executor,
+		workDir:  workDir,
+	}
+}
+
+// Verify runs the check described by spec.
+func (v *ToolVerifier) Verify(ctx context.Context, spec *VerifySpec) *PlanResult {
+	if spec == nil {
+		return &PlanResult{Error: "verify action has no check", Success: false}
+	}
+
+	switch spec.Kind {
+	case VerifyCommand:
+		return v.verifyCommand(ctx, spec)
+	case VerifyTests:
+		return v.verifyTests(ctx, spec)
+	case VerifyFileExists:
+		return v.verifyFileExists(spec)
+	default:
+		return &PlanResult{Error: fmt.Sprintf("unknown verify kind: %s", spec.Kind), Success: false}
+	}
+}
+
+func (v *ToolVerifier) verifyCommand(ctx context.Context, spec *VerifySpec) *PlanResult {
+	if spec.Command == "" {
+		return &PlanResult{Error: "command check requires a command", Success: false}
+	}
+
+	result := v.executor.executeTool(ctx, &genai.FunctionCall{
+		Name: "bash",
+		Args: map[string]any{"command": spec.Command},
+	})
+
+	// A failure without an exit code means the command never ran to completion
+	var code int
+	if cr, ok := result.Data.(*CommandResult); ok {
+		code = cr.ExitCode
+	} else if !result.Success {
+		return &PlanResult{Output: result.Content, Error: result.Error, Success: false}
+	}
+
+	if code != spec.ExitCode {
+		return &PlanResult{
+			Output:  result.Content,
+			Error:   fmt.Sprintf("command %q exited with code %d, expected %d", spec.Command, code, spec.ExitCode),
+			Success: false,
+		}
+	}
+	return &PlanResult{Output: result.Content, Success: true}
+}
+
+func (v *ToolVerifier) verifyTests(ctx context.Context, spec *VerifySpec) *PlanResult {
+	args := map[string]any{}
+	if spec.Path != "" {
+		args["path"] = spec.Path
+	}
+	if spec.Filter != "" {
+		args["filter"] = spec.Filter
+	}
+
+	result := v.executor.executeTool(ctx, &genai.FunctionCall{Name: "run_tests", Args: args})
+	if !result.Success {
+		return &PlanResult{Output: result.Content, Error: result.Error, Success: false}
+	}
+
+	if report, ok := result.Data.(*TestReport); ok {
+		if !report.Success {
+			return &PlanResult{Output: result.Content, Error: report.FailureSummary(), Success: false}
+		}
+		return &PlanResult{Output: result.Content, Success: true}
+	}
+
+	// run_tests reports the overall outcome as the first word of its summary.
+	if !strings.HasPrefix(strings.TrimSpace(result.Content), "PASS") {
+		return &PlanResult{Output: result.Content, Error: "tests failed", Success: false}
+	}
+	return &PlanResult{Output: result.Content, Success: true}
+}
+
+func (v *ToolVerifier) verifyFileExists(spec *VerifySpec) *PlanResult {
+	if spec.Path == "" {
+		return &PlanResult{Error: "file_exists check requires a path", Success: false}
+	}
+
+	path := spec.Path
+	if !filepath.IsAbs(path) && v.workDir != "" {
+		path = filepath.Join(v.workDir, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return &PlanResult{Error: fmt.Sprintf("expected file %s to exist: %s", spec.Path, err), Success: false}
+	}
+	return &PlanResult{Output: fmt.Sprintf("file exists: %s", spec.Path), Success: true}
+}
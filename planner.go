@@ -52,6 +52,7 @@ type PlannedAction struct {
 	Prompt        string         `json:"prompt"`
 	ToolName      string         `json:"tool_name,omitempty"`
 	ToolArgs      map[string]any `json:"tool_args,omitempty"`
+	Verify        *VerifySpec    `json:"verify,omitempty"`
 	NodeID        string         `json:"node_id"`
 	Prerequisites []string       `json:"prerequisites,omitempty"`
 }
@@ -68,6 +69,8 @@ type PlanTree struct {
 	Root       *PlanNode            `json:"root"`
 	BestPath   []*PlanNode          `json:"-"`
 	TotalNodes int                  `json:"total_nodes"`
+	Simulation SimulationMode       `json:"simulation,omitempty"`
+	Rollouts   *RolloutStats        `json:"rollouts,omitempty"`
 	nodeIndex  map[string]*PlanNode `json:"-"`
 	mu         sync.RWMutex         `json:"-"`
 }
@@ -102,6 +105,9 @@ type PlannerConfig struct {
 	// MaxTreeNodes limits the total number of nodes in the plan tree.
 	MaxTreeNodes int
 
+	// MaxReplans caps how many times a single plan is repaired after step failures.
+	MaxReplans int
+
 	// Weights for multi-component scoring.
 	Weights ScoringWeights
 }
@@ -113,6 +119,7 @@ func DefaultPlannerConfig() PlannerConfig {
 		ExplorationC:   1.414,
 		MaxTreeDepth:   5,
 		MaxTreeNodes:   100,
+		MaxReplans:     3,
 		Weights:        DefaultScoringWeights(),
 	}
 }
@@ -125,16 +132,19 @@ type Planner struct {
 	beamWidth   int
 	config      PlannerConfig
 	currentTree *PlanTree // used by scoring to access tree index
+	simulation  SimulationMode
+	critic      *rolloutCritic
 }
 
 // NewPlanner creates a new planner.
 func NewPlanner(client Client, optimizer *StrategyOptimizer) *Planner {
 	return &Planner{
-		client:    client,
-		optimizer: optimizer,
-		strategy:  SearchBeam,
-		beamWidth: 3,
-		config:    DefaultPlannerConfig(),
+		client:     client,
+		optimizer:  optimizer,
+		strategy:   SearchBeam,
+		beamWidth:  3,
+		config:     DefaultPlannerConfig(),
+		simulation: SimulationHeuristic,
 	}
 }
 
@@ -150,6 +160,26 @@ func (p *Planner) WithPlannerConfig(config PlannerConfig) *Planner {
 	return p
 }
 
+// WithCritic enables LLM-evaluated MCTS rollouts using a (typically cheaper)
+// critic client. Nodes are scored by asking the critic how well the path to
+// them serves the PlanGoal, falling back to heuristics when the token budget
+// runs out. With a StrategyOptimizer, each search uses the simulation mode
+// with the best recorded outcomes for the task type (see RecordOutcome).
+func (p *Planner) WithCritic(critic Client, config CriticConfig) *Planner {
+	p.critic = newRolloutCritic(critic, config)
+	p.simulation = SimulationCritic
+	return p
+}
+
+// RecordOutcome reports whether executing tree succeeded, so the strategy
+// optimizer can learn which simulation mode produces better plans.
+func (p *Planner) RecordOutcome(tree *PlanTree, taskDesc string, success bool, duration time.Duration) {
+	if p.optimizer == nil || tree == nil || tree.Simulation == "" {
+		return
+	}
+	p.optimizer.RecordSimulationOutcome(classifyTaskType(taskDesc), tree.Simulation, success, duration)
+}
+
 // BuildPlan generates a plan tree for the given goal and optionally applies search.
 func (p *Planner) BuildPlan(ctx context.Context, goal PlanGoal) (*PlanTree, error) {
 	if goal.MaxDepth == 0 {
@@ -264,6 +294,67 @@ func (p *Planner) RecordResult(tree *PlanTree, nodeID string, result *PlanResult
 
 	// Backpropagate reward
 	p.backpropagate(tree, nodeID, node.Score)
+
+	// A verify node checks the work of its prerequisites, so its outcome
+	// is a direct signal for them as well.
+	if node.Action != nil && node.Action.Type == ActionVerify {
+		for _, prereqID := range node.Action.Prerequisites {
+			p.backpropagate(tree, prereqID, node.Score)
+		}
+	}
+}
+
+// Replan recovers from a failed node by generating alternative actions as new
+// siblings of that node, using the failure reason as context. Completed nodes
+// are kept and the failed node's pending subtree is skipped. It returns an
+// error when the failed node is the root, since there is no completed work to
+// build on and the caller should rebuild the plan instead.
+func (p *Planner) Replan(ctx context.Context, tree *PlanTree, nodeID, goal, reason string) error {
+	tree.mu.Lock()
+	node, ok := tree.nodeIndex[nodeID]
+	if !ok {
+		tree.mu.Unlock()
+		return fmt.Errorf("node not found: %s", nodeID)
+	}
+	parent, ok := tree.nodeIndex[node.ParentID]
+	if node.ParentID == "" || !ok {
+		tree.mu.Unlock()
+		return fmt.Errorf("cannot replan root node: %s", nodeID)
+	}
+	skipPendingSubtree(node)
+	tree.mu.Unlock()
+
+	failedStep := ""
+	if node.Action != nil {
+		failedStep = node.Action.Prompt
+	}
+	prompt := fmt.Sprintf("%s\n\nA previous step failed.\nFailed step: %s\nFailure: %s\nGenerate alternative steps that avoid this failure.",
+		goal, failedStep, reason)
+
+	actions, err := p.generateActions(ctx, prompt, node)
+	if err != nil {
+		return fmt.Errorf("failed to generate replacement actions: %w", err)
+	}
+	if len(actions) == 0 {
+		return fmt.Errorf("no replacement actions generated for node: %s", nodeID)
+	}
+
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	for _, action := range actions {
+		child := &PlanNode{
+			ID:       fmt.Sprintf("%s-r%d", parent.ID, tree.TotalNodes),
+			ParentID: parent.ID,
+			Action:   action,
+			Status:   PlanNodePending,
+		}
+		parent.Children = append(parent.Children, child)
+		tree.nodeIndex[child.ID] = child
+		tree.TotalNodes++
+	}
+
+	return nil
 }
 
 // GetReadyNodes returns nodes that are ready to execute.
@@ -338,7 +429,10 @@ Goal: %s`, goal)
 	prompt += `
 
 Return JSON array:
-[{"type": "tool_call|delegate|verify", "prompt": "...", "tool_name": "...", "tool_args": {}, "prerequisites": []}]`
+[{"type": "tool_call|delegate|verify", "prompt": "...", "tool_name": "...", "tool_args": {}, "verify": {"kind": "command|tests|file_exists", "command": "...", "path": "...", "exit_code": 0}, "prerequisites": []}]
+
+Use "tool_call" with an exact tool_name and tool_args to run a tool directly.
+Use "verify" with a "verify" object to check the result of earlier steps.`
 
 	sr, err := p.client.SendMessage(ctx, prompt)
 	if err != nil {
@@ -363,6 +457,7 @@ Return JSON array:
 		Prompt        string         `json:"prompt"`
 		ToolName      string         `json:"tool_name"`
 		ToolArgs      map[string]any `json:"tool_args"`
+		Verify        *VerifySpec    `json:"verify"`
 		Prerequisites []string       `json:"prerequisites"`
 	}
 
@@ -390,6 +485,7 @@ Return JSON array:
 			Prompt:        ra.Prompt,
 			ToolName:      ra.ToolName,
 			ToolArgs:      ra.ToolArgs,
+			Verify:        ra.Verify,
 			Prerequisites: ra.Prerequisites,
 		}
 	}
@@ -426,6 +522,16 @@ func (p *Planner) backpropagate(tree *PlanTree, nodeID string, reward float64) {
 	}
 }
 
+// skipPendingSubtree marks all pending descendants of node as skipped.
+func skipPendingSubtree(node *PlanNode) {
+	for _, child := range node.Children {
+		if child.Status == PlanNodePending {
+			child.Status = PlanNodeSkipped
+		}
+		skipPendingSubtree(child)
+	}
+}
+
 func (p *Planner) printNode(sb *strings.Builder, node *PlanNode, depth int) {
 	indent := strings.Repeat("  ", depth)
 	status := string(node.Status)
@@ -459,6 +565,3 @@ func classifyTaskType(desc string) string {
 		return "general"
 	}
 }
-
-// Ensure time is used by generateActions
-var _ = time.Now
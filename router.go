@@ -331,9 +331,11 @@ func (r *Router) getStrategySuccessRate(strategy ExecutionStrategy) float64 {
 
 func (r *Router) updateConversationMode(toolName string) {
 	switch {
-	case toolName == "grep" || toolName == "glob" || toolName == "read" || toolName == "tree":
+	case toolName == "grep" || toolName == "glob" || toolName == "read" || toolName == "tree" || toolName == "lsp" ||
+		toolName == "go_symbols":
 		r.conversationMode = "exploring"
-	case toolName == "write" || toolName == "edit":
+	case toolName == "write" || toolName == "edit" || toolName == "apply_patch" || toolName == "lsp_rename" ||
+		toolName == "go_edit":
 		r.conversationMode = "implementing"
 	case toolName == "bash" && r.recentErrors > 2:
 		r.conversationMode = "debugging"
@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -42,6 +43,13 @@ type RunnerConfig struct {
 	// OnAgentProgress is called with text output from agents.
 	OnAgentProgress func(agentID string, text string)
 
+	// OnAgentEvent is called with every event from spawned agents.
+	// Event.AgentID identifies the agent.
+	OnAgentEvent func(Event)
+
+	// Interaction handles human interaction for spawned agents.
+	Interaction Interaction
+
 	// DefaultMaxTurns is the default max turns for spawned agents.
 	DefaultMaxTurns int
 
@@ -101,7 +109,10 @@ func (r *Runner) Spawn(ctx context.Context, task AgentTask) (string, *AgentResul
 
 	result, err := agent.Run(ctx, task.Prompt)
 	r.mu.Lock()
-	if err != nil {
+	if errors.Is(err, ErrAgentStopped) {
+		ra.status = AgentStatusCancelled
+		result = &AgentResult{Error: err}
+	} else if err != nil {
 		ra.status = AgentStatusFailed
 		result = &AgentResult{Error: err}
 	} else {
@@ -165,7 +176,12 @@ func (r *Runner) SpawnAsync(ctx context.Context, task AgentTask) (string, error)
 		result, err := agent.Run(agentCtx, task.Prompt)
 
 		r.mu.Lock()
-		if err != nil {
+		if errors.Is(err, ErrAgentStopped) {
+			ra.status = AgentStatusCancelled
+			if result == nil {
+				result = &AgentResult{Error: err}
+			}
+		} else if err != nil {
 			ra.status = AgentStatusFailed
 			if result == nil {
 				result = &AgentResult{Error: err}
@@ -272,12 +288,76 @@ func (r *Runner) Cancel(agentID string) error {
 	return nil
 }
 
+// Inject queues a user message for a running agent; see Agent.Inject.
+func (r *Runner) Inject(agentID, message string) error {
+	agent, err := r.runningAgent(agentID)
+	if err != nil {
+		return err
+	}
+	agent.Inject(message)
+	return nil
+}
+
+// Pause holds a running agent at its next turn boundary; see Agent.Pause.
+func (r *Runner) Pause(agentID string) error {
+	agent, err := r.runningAgent(agentID)
+	if err != nil {
+		return err
+	}
+	agent.Pause()
+	return nil
+}
+
+// Unpause releases a paused agent; see Agent.Unpause.
+func (r *Runner) Unpause(agentID string) error {
+	agent, err := r.runningAgent(agentID)
+	if err != nil {
+		return err
+	}
+	agent.Unpause()
+	return nil
+}
+
+// Stop ends a running agent, optionally letting it wrap up with a summary;
+// see Agent.Stop.
+func (r *Runner) Stop(agentID string, graceful bool) error {
+	agent, err := r.runningAgent(agentID)
+	if err != nil {
+		return err
+	}
+	agent.Stop(graceful)
+	return nil
+}
+
+// runningAgent returns the agent with the given ID if it is still running.
+func (r *Runner) runningAgent(agentID string) (*Agent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.agents[agentID]
+	if !ok {
+		return nil, fmt.Errorf("agent not found: %s", agentID)
+	}
+	if a.status != AgentStatusRunning {
+		return nil, fmt.Errorf("agent %s is not running (%s)", agentID, a.status)
+	}
+	return a.agent, nil
+}
+
 // Memory returns the shared memory instance.
 func (r *Runner) Memory() *SharedMemory {
 	return r.memory
 }
 
-func (r *Runner) createAgent(id string, task AgentTask) (*Agent, *Registry, error) {
+// NewAgent builds an agent configured the way the runner configures
+// spawned agents for task.Type, without running it. extra options are
+// applied last. The agent is not tracked by the runner.
+func (r *Runner) NewAgent(task AgentTask, extra ...AgentOption) (*Agent, error) {
+	agent, _, err := r.createAgent(generateID(), task, extra...)
+	return agent, err
+}
+
+func (r *Runner) createAgent(id string, task AgentTask, extra ...AgentOption) (*Agent, *Registry, error) {
 	maxTurns := task.MaxTurns
 	if maxTurns <= 0 {
 		maxTurns = r.config.DefaultMaxTurns
@@ -320,6 +400,15 @@ func (r *Runner) createAgent(id string, task AgentTask) (*Agent, *Registry, erro
 		}))
 	}
 
+	if r.config.OnAgentEvent != nil {
+		opts = append(opts, WithEventHandler(r.config.OnAgentEvent))
+	}
+	if r.config.Interaction != nil {
+		opts = append(opts, WithInteraction(r.config.Interaction))
+	}
+
+	opts = append(opts, extra...)
+
 	agentClient := r.client.Clone()
 	agent, err := NewAgent(id, agentClient, registry, opts...)
 	if err != nil {
@@ -56,6 +56,21 @@ func WithOnAgentProgress(fn func(agentID string, text string)) RunnerOption {
 	}
 }
 
+// WithOnAgentEvent sets a handler for typed events from all spawned agents,
+// including agents run by a Coordinator on this runner.
+func WithOnAgentEvent(fn func(Event)) RunnerOption {
+	return func(r *Runner) {
+		r.config.OnAgentEvent = fn
+	}
+}
+
+// WithRunnerInteraction sets the Interaction used by spawned agents.
+func WithRunnerInteraction(i Interaction) RunnerOption {
+	return func(r *Runner) {
+		r.config.Interaction = i
+	}
+}
+
 // WithSharedMemory sets a custom SharedMemory instance for the runner.
 func WithSharedMemory(mem *SharedMemory) RunnerOption {
 	return func(r *Runner) {
@@ -3,6 +3,7 @@ package security
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -10,19 +11,55 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
 // SandboxConfig holds sandbox configuration.
 type SandboxConfig struct {
-	// Enabled determines if sandboxing is active
+	// Enabled determines if sandboxing is active. On Linux the command runs
+	// in new user, PID and mount namespaces with the filesystem read-only
+	// except RootDir.
 	Enabled bool
-	// RootDir is the root directory for chroot (empty = use current workDir)
+	// RootDir is the directory left writable (empty = use current workDir)
 	RootDir string
 	// EnableSeccomp enables seccomp-bpf syscall filtering (Linux only)
 	EnableSeccomp bool
-	// ReadOnly makes the sandbox filesystem read-only
+	// ReadOnly makes the sandbox filesystem read-only, including RootDir.
+	// The command's private TMPDIR stays writable.
 	ReadOnly bool
+	// NoNetwork runs the command in an empty network namespace with only
+	// loopback (Linux only)
+	NoNetwork bool
+	// Landlock also confines filesystem writes to RootDir with Landlock
+	// when the kernel supports it (Linux only)
+	Landlock bool
+	// Limits are resource limits applied to the command (Linux only)
+	Limits ResourceLimits
+	// BestEffort runs the command even when a requested protection is
+	// unavailable or fails to apply. By default such a command is not run:
+	// NewSandboxedCommand fails, or the command exits with code 126.
+	BestEffort bool
+}
+
+// ResourceLimits are rlimits applied to a sandboxed command. Zero values
+// leave the corresponding limit unchanged.
+type ResourceLimits struct {
+	// CPUTime limits CPU time (RLIMIT_CPU), rounded up to whole seconds
+	CPUTime time.Duration
+	// Memory limits the address space in bytes (RLIMIT_AS)
+	Memory uint64
+	// FileSize limits the size of files the command writes (RLIMIT_FSIZE)
+	FileSize uint64
+	// Processes limits the number of processes (RLIMIT_NPROC). The kernel
+	// counts all processes of the user, not only those of the command.
+	Processes uint64
+}
+
+func (l ResourceLimits) isZero() bool {
+	return l == ResourceLimits{}
 }
 
 // DefaultSandboxConfig returns the default sandbox configuration.
@@ -31,6 +68,117 @@ func DefaultSandboxConfig() SandboxConfig {
 		Enabled:       true,
 		EnableSeccomp: false,
 		ReadOnly:      false,
+		Landlock:      true,
+	}
+}
+
+// SandboxFeature names an isolation feature of the sandbox.
+type SandboxFeature string
+
+const (
+	FeatureUserNamespace    SandboxFeature = "user_namespace"
+	FeaturePIDNamespace     SandboxFeature = "pid_namespace"
+	FeatureNetworkNamespace SandboxFeature = "network_namespace"
+	FeatureReadOnlyRoot     SandboxFeature = "readonly_root"
+	FeatureLandlock         SandboxFeature = "landlock"
+	FeatureSeccomp          SandboxFeature = "seccomp"
+	FeatureRlimits          SandboxFeature = "rlimits"
+)
+
+// requested returns the features c asks for.
+func (c SandboxConfig) requested() []SandboxFeature {
+	features := []SandboxFeature{FeaturePIDNamespace, FeatureReadOnlyRoot}
+	if c.NoNetwork {
+		features = append(features, FeatureNetworkNamespace)
+	}
+	if c.Landlock {
+		features = append(features, FeatureLandlock)
+	}
+	if c.EnableSeccomp {
+		features = append(features, FeatureSeccomp)
+	}
+	if !c.Limits.isZero() {
+		features = append(features, FeatureRlimits)
+	}
+	return features
+}
+
+// SandboxSupport describes which sandbox features the system provides.
+type SandboxSupport struct {
+	Available   []SandboxFeature
+	Unavailable map[SandboxFeature]string // feature -> reason
+	LandlockABI int                       // 0 if Landlock is unavailable
+}
+
+// Has reports whether feature f is available.
+func (s SandboxSupport) Has(f SandboxFeature) bool {
+	for _, a := range s.Available {
+		if a == f {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	sandboxSupport     SandboxSupport
+	sandboxSupportOnce sync.Once
+)
+
+// DetectSandboxSupport probes the system for sandbox features. The probe
+// runs once per process; later calls return the cached result.
+func DetectSandboxSupport() SandboxSupport {
+	sandboxSupportOnce.Do(func() {
+		sandboxSupport = detectSandboxSupport()
+	})
+	return sandboxSupport
+}
+
+// unsupportedSandbox reports every feature as unavailable on this platform.
+func unsupportedSandbox() SandboxSupport {
+	s := SandboxSupport{Unavailable: make(map[SandboxFeature]string)}
+	for _, f := range []SandboxFeature{FeatureUserNamespace, FeaturePIDNamespace, FeatureNetworkNamespace,
+		FeatureReadOnlyRoot, FeatureLandlock, FeatureSeccomp, FeatureRlimits} {
+		s.Unavailable[f] = "not supported on " + runtime.GOOS
+	}
+	return s
+}
+
+// SandboxReport describes the isolation a sandboxed command ran with.
+type SandboxReport struct {
+	Applied     []SandboxFeature          `json:"applied,omitempty"`
+	Unavailable map[SandboxFeature]string `json:"unavailable,omitempty"` // requested but not applied, with the reason
+}
+
+func (r *SandboxReport) applied(f SandboxFeature) {
+	r.Applied = append(r.Applied, f)
+}
+
+func (r *SandboxReport) unavailable(f SandboxFeature, reason string) {
+	if r.Unavailable == nil {
+		r.Unavailable = make(map[SandboxFeature]string)
+	}
+	r.Unavailable[f] = reason
+}
+
+// missing returns an error naming the requested features that were not
+// applied, or nil if there are none.
+func (r *SandboxReport) missing() error {
+	if len(r.Unavailable) == 0 {
+		return nil
+	}
+	features := make([]string, 0, len(r.Unavailable))
+	for f, reason := range r.Unavailable {
+		features = append(features, fmt.Sprintf("%s (%s)", f, reason))
+	}
+	sort.Strings(features)
+	return fmt.Errorf("sandbox protections unavailable: %s", strings.Join(features, "; "))
+}
+
+func (r *SandboxReport) merge(other SandboxReport) {
+	r.Applied = append(r.Applied, other.Applied...)
+	for f, reason := range other.Unavailable {
+		r.unavailable(f, reason)
 	}
 }
 
@@ -40,12 +188,18 @@ type SandboxResult struct {
 	Stdout   []byte
 	Stderr   []byte
 	Error    error
+	Report   SandboxReport
 }
 
 // SandboxedCommand represents a command that will be executed in a sandbox.
 type SandboxedCommand struct {
 	cmd    *exec.Cmd
 	config SandboxConfig
+	tmpDir string // private TMPDIR, writable inside the sandbox
+
+	report SandboxReport
+	// reportR receives the report of the sandbox init process (Linux)
+	reportR, reportW *os.File
 }
 
 // NewSandboxedCommand creates a new sandboxed command.
@@ -63,18 +217,44 @@ func NewSandboxedCommand(ctx context.Context, workDir string, command string, co
 		return nil, fmt.Errorf("workDir does not exist: %s", absWorkDir)
 	}
 
+	// The command gets a private TMPDIR outside workDir, removed by Run
+	tmpDir, err := os.MkdirTemp("", "gokin-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox TMPDIR: %w", err)
+	}
+
 	cmd := exec.CommandContext(ctx, "bash", "-c", command)
 	cmd.Dir = absWorkDir
-	cmd.Env = SafeEnvironment(absWorkDir)
+	cmd.Env = setEnv(SafeEnvironment(absWorkDir), "TMPDIR", tmpDir)
 
 	sandboxed := &SandboxedCommand{
 		cmd:    cmd,
 		config: config,
+		tmpDir: tmpDir,
+	}
+
+	if config.Enabled {
+		err := sandboxed.applySandbox(absWorkDir)
+		if err == nil && !config.BestEffort {
+			err = sandboxed.report.missing()
+		}
+		if err != nil {
+			sandboxed.closeReport()
+			os.RemoveAll(tmpDir)
+			return nil, fmt.Errorf("failed to apply sandbox: %w", err)
+		}
 	}
 
 	return sandboxed, nil
 }
 
+// Report returns what is known about the isolation of the command before
+// it runs: requested features that are unavailable on this system. The
+// complete report is in SandboxResult.Report.
+func (sc *SandboxedCommand) Report() SandboxReport {
+	return sc.report
+}
+
 // SafeEnvironment returns a sanitized environment with safe defaults.
 func SafeEnvironment(workDir string) []string {
 	safeVars := map[string]string{
@@ -107,8 +287,10 @@ func SafeEnvironment(workDir string) []string {
 	return env
 }
 
-// Run runs the sandboxed command and returns the result.
+// Run runs the sandboxed command and returns the result. The command's
+// TMPDIR is removed when it finishes.
 func (sc *SandboxedCommand) Run(timeout time.Duration) *SandboxResult {
+	defer os.RemoveAll(sc.tmpDir)
 	result := &SandboxResult{}
 
 	if timeout > 0 {
@@ -133,6 +315,7 @@ func (sc *SandboxedCommand) Run(timeout time.Duration) *SandboxResult {
 	}
 
 	if err := sc.cmd.Start(); err != nil {
+		sc.closeReport()
 		result.Error = fmt.Errorf("failed to start command: %w", err)
 		return result
 	}
@@ -153,9 +336,21 @@ func (sc *SandboxedCommand) Run(timeout time.Duration) *SandboxResult {
 		stderrCh <- streamReadResult{data: data, err: err}
 	}()
 
-	waitErr := sc.cmd.Wait()
+	result.Report = sc.report
+	if sc.reportR != nil {
+		// The init process writes its report just before running the command
+		sc.reportW.Close()
+		var applied SandboxReport
+		if err := json.NewDecoder(sc.reportR).Decode(&applied); err == nil {
+			result.Report.merge(applied)
+		}
+		sc.reportR.Close()
+	}
+
+	// Wait closes the pipes, so drain them first
 	stdoutRes := <-stdoutCh
 	stderrRes := <-stderrCh
+	waitErr := sc.cmd.Wait()
 	result.Stdout = stdoutRes.data
 	result.Stderr = stderrRes.data
 
@@ -170,6 +365,13 @@ func (sc *SandboxedCommand) Run(timeout time.Duration) *SandboxResult {
 		result.ExitCode = sc.cmd.ProcessState.ExitCode()
 	}
 
+	// In strict mode the init process aborts when a protection fails
+	if sc.config.Enabled && !sc.config.BestEffort {
+		if err := result.Report.missing(); err != nil {
+			result.Error = err
+		}
+	}
+
 	streamErr := errors.Join(
 		wrapStreamReadError("stdout", stdoutRes.err),
 		wrapStreamReadError("stderr", stderrRes.err),
@@ -181,6 +383,25 @@ func (sc *SandboxedCommand) Run(timeout time.Duration) *SandboxResult {
 	return result
 }
 
+// closeReport closes the report pipe of a command that will not run.
+func (sc *SandboxedCommand) closeReport() {
+	if sc.reportR != nil {
+		sc.reportR.Close()
+		sc.reportW.Close()
+	}
+}
+
+// setEnv sets key to value in env, replacing any existing entry.
+func setEnv(env []string, key, value string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, e := range env {
+		if !strings.HasPrefix(e, key+"=") {
+			out = append(out, e)
+		}
+	}
+	return append(out, key+"="+value)
+}
+
 // readWithTimeout reads from a pipe with a timeout.
 func readWithTimeout(pipe interface{}, timeout time.Duration) ([]byte, error) {
 	reader, ok := pipe.(io.Reader)
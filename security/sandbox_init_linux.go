@@ -0,0 +1,502 @@
+//go:build linux
+
+package security
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sandboxInitEnv carries the sandboxSpec to the re-executed binary.
+	sandboxInitEnv = "_GOKIN_SANDBOX_INIT"
+	// sandboxProbe is sent instead of a spec by DetectSandboxSupport.
+	sandboxProbe = "probe"
+	// sandboxReportFD is where the init process writes its SandboxReport.
+	sandboxReportFD = 3
+)
+
+// sandboxSpec tells the init process how to set up the sandbox.
+type sandboxSpec struct {
+	Path          string         `json:"path"`
+	Args          []string       `json:"args"`
+	RootDir       string         `json:"root_dir"`
+	TmpDir        string         `json:"tmp_dir,omitempty"`
+	ReadOnly      bool           `json:"read_only,omitempty"`
+	Namespaces    bool           `json:"namespaces,omitempty"`
+	UserNamespace bool           `json:"user_namespace,omitempty"`
+	NoNetwork     bool           `json:"no_network,omitempty"`
+	LandlockABI   int            `json:"landlock_abi,omitempty"`
+	Seccomp       bool           `json:"seccomp,omitempty"`
+	Limits        ResourceLimits `json:"limits"`
+	Strict        bool           `json:"strict,omitempty"` // abort when a protection fails to apply
+}
+
+func init() {
+	raw, ok := os.LookupEnv(sandboxInitEnv)
+	if !ok {
+		return
+	}
+	// Landlock, seccomp and no_new_privs apply to the calling thread and
+	// are inherited through exec, so everything must happen on one thread.
+	runtime.LockOSThread()
+	os.Exit(sandboxInit(raw))
+}
+
+// sandboxInit runs inside the sandbox namespaces, applies the remaining
+// restrictions and execs the command. It only returns on failure.
+func sandboxInit(raw string) int {
+	if raw == sandboxProbe {
+		return 0
+	}
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: invalid spec: %v\n", err)
+		return 126
+	}
+	syscall.CloseOnExec(sandboxReportFD)
+	reportFile := os.NewFile(sandboxReportFD, "sandbox-report")
+
+	var report SandboxReport
+	// apply records the outcome of setting up f and reports whether to go
+	// on. In strict mode a failure ends the init process before the
+	// command runs.
+	apply := func(f SandboxFeature, err error) bool {
+		if err == nil {
+			report.applied(f)
+			return true
+		}
+		report.unavailable(f, err.Error())
+		if !spec.Strict {
+			return true
+		}
+		fmt.Fprintf(os.Stderr, "sandbox: %s: %v\n", f, err)
+		json.NewEncoder(reportFile).Encode(report)
+		reportFile.Close()
+		return false
+	}
+
+	if spec.Namespaces {
+		if spec.UserNamespace {
+			report.applied(FeatureUserNamespace)
+		}
+		report.applied(FeaturePIDNamespace)
+		if !apply(FeatureReadOnlyRoot, setupMounts(spec.writable())) {
+			return 126
+		}
+		if spec.NoNetwork {
+			if err := loopbackUp(); err != nil {
+				fmt.Fprintf(os.Stderr, "sandbox: loopback unavailable: %v\n", err)
+			}
+			report.applied(FeatureNetworkNamespace)
+		}
+	}
+
+	// Capabilities were only needed for the mounts above
+	if spec.UserNamespace {
+		if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: failed to drop capabilities: %v\n", err)
+			return 126
+		}
+	}
+
+	if spec.LandlockABI > 0 || spec.Seccomp {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: failed to set no_new_privs: %v\n", err)
+			return 126
+		}
+	}
+	if spec.LandlockABI > 0 {
+		if !apply(FeatureLandlock, applyLandlock(spec.LandlockABI, append(spec.writable(), "/dev"))) {
+			return 126
+		}
+	}
+	if spec.Seccomp {
+		if !apply(FeatureSeccomp, applySeccomp()) {
+			return 126
+		}
+	}
+
+	// Limits go last: a small address space limit can starve this process
+	if !spec.Limits.isZero() {
+		if !apply(FeatureRlimits, applyLimits(spec.Limits)) {
+			return 126
+		}
+	}
+
+	json.NewEncoder(reportFile).Encode(report)
+	reportFile.Close()
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, sandboxInitEnv+"=") {
+			env = append(env, e)
+		}
+	}
+	err := syscall.Exec(spec.Path, spec.Args, env)
+	fmt.Fprintf(os.Stderr, "sandbox: exec %s: %v\n", spec.Path, err)
+	return 127
+}
+
+// writable returns the directories the command may write to.
+func (spec *sandboxSpec) writable() []string {
+	var dirs []string
+	if !spec.ReadOnly {
+		dirs = append(dirs, spec.RootDir)
+	}
+	if spec.TmpDir != "" {
+		dirs = append(dirs, spec.TmpDir)
+	}
+	return dirs
+}
+
+// --- Mounts ---
+
+// setupMounts makes every mount read-only except the writable
+// directories, /dev and /proc, and mounts a fresh /proc for the PID
+// namespace.
+func setupMounts(writable []string) error {
+	// The bind mounts below replace directories under the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	for _, dir := range writable {
+		// A separate mount keeps dir writable when its parent is remounted
+		if err := unix.Mount(dir, dir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", dir, err)
+		}
+	}
+	// Fails when /proc is partially masked, as in containers; the
+	// inherited /proc then still shows the parent's processes.
+	_ = unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+
+	mounts, err := readMountInfo()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, m := range mounts {
+		if underAny(m.point, writable) || underPath(m.point, "/dev") || underPath(m.point, "/proc") {
+			continue
+		}
+		flags := uintptr(unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY) | m.lockedFlags
+		if err := unix.Mount("", m.point, "", flags, ""); err != nil {
+			if m.point == "/" {
+				return fmt.Errorf("failed to remount / read-only: %w", err)
+			}
+			failed = append(failed, m.point)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d mounts left writable: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return os.Chdir(wd)
+}
+
+type mountInfo struct {
+	point string
+	// lockedFlags must be kept on remount; inherited mounts do not allow
+	// clearing them.
+	lockedFlags uintptr
+}
+
+// readMountInfo parses /proc/self/mountinfo.
+func readMountInfo() ([]mountInfo, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		m := mountInfo{point: unescapeMountPath(fields[4])}
+		atime := false
+		for _, opt := range strings.Split(fields[5], ",") {
+			switch opt {
+			case "nosuid":
+				m.lockedFlags |= unix.MS_NOSUID
+			case "nodev":
+				m.lockedFlags |= unix.MS_NODEV
+			case "noexec":
+				m.lockedFlags |= unix.MS_NOEXEC
+			case "noatime":
+				m.lockedFlags |= unix.MS_NOATIME
+				atime = true
+			case "nodiratime":
+				m.lockedFlags |= unix.MS_NODIRATIME
+			case "relatime":
+				m.lockedFlags |= unix.MS_RELATIME
+				atime = true
+			}
+		}
+		if !atime {
+			m.lockedFlags |= unix.MS_STRICTATIME
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (\040 etc.) of mountinfo.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// underPath reports whether path is dir or inside it.
+func underPath(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// underAny reports whether path is one of dirs or inside one of them.
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if underPath(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// loopbackUp brings up lo in a new network namespace.
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// --- Resource limits ---
+
+func applyLimits(l ResourceLimits) error {
+	var errs []error
+	set := func(resource int, name string, value uint64) {
+		if value == 0 {
+			return
+		}
+		var cur unix.Rlimit
+		if err := unix.Getrlimit(resource, &cur); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		// Limits can only be lowered without privileges
+		if cur.Max != unix.RLIM_INFINITY && value > cur.Max {
+			value = cur.Max
+		}
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if l.CPUTime > 0 {
+		secs := uint64((l.CPUTime + 999_999_999) / 1_000_000_000)
+		set(unix.RLIMIT_CPU, "cpu", secs)
+	}
+	set(unix.RLIMIT_AS, "memory", l.Memory)
+	set(unix.RLIMIT_FSIZE, "file size", l.FileSize)
+	set(unix.RLIMIT_NPROC, "processes", l.Processes)
+	return errors.Join(errs...)
+}
+
+// --- Landlock ---
+
+// landlockWriteAccess returns the write accesses known to Landlock ABI abi.
+// Reads and execution stay unrestricted.
+func landlockWriteAccess(abi int) uint64 {
+	access := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return access
+}
+
+// applyLandlock denies filesystem writes outside the writable paths.
+func applyLandlock(abi int, writable []string) error {
+	access := landlockWriteAccess(abi)
+	attr := unix.LandlockRulesetAttr{Access_fs: access}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("landlock_create_ruleset: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	for _, path := range writable {
+		pathFD, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(pathFD)}
+		_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, fd, unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+		unix.Close(pathFD)
+		if errno != 0 {
+			return fmt.Errorf("landlock_add_rule %s: %w", path, errno)
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("landlock_restrict_self: %w", errno)
+	}
+	return nil
+}
+
+// --- Seccomp ---
+
+// seccompArch maps GOARCH to its AUDIT_ARCH value. The filter below reads
+// syscall arguments in little-endian layout.
+var seccompArch = map[string]uint32{
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+}
+
+// seccompBlocked are syscalls a sandboxed command has no business making.
+var seccompBlocked = []uint32{
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_UNSHARE,
+	unix.SYS_SETNS,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_REBOOT,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_QUOTACTL,
+}
+
+// cloneNamespaceFlags are clone flags that create namespaces.
+const cloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWUTS | unix.CLONE_NEWIPC |
+	unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET | unix.CLONE_NEWCGROUP
+
+const (
+	seccompRetAllow       = 0x7fff0000
+	seccompRetErrno       = 0x00050000
+	seccompRetKillProcess = 0x80000000
+
+	seccompDataNr   = 0  // offsetof(struct seccomp_data, nr)
+	seccompDataArch = 4  // offsetof(struct seccomp_data, arch)
+	seccompDataArg0 = 16 // offsetof(struct seccomp_data, args[0]), low word
+)
+
+// applySeccomp installs a filter that fails the blocked syscalls with
+// EPERM and refuses to create namespaces.
+func applySeccomp() error {
+	arch, ok := seccompArch[runtime.GOARCH]
+	if !ok {
+		return fmt.Errorf("seccomp filter not implemented for %s", runtime.GOARCH)
+	}
+
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+	jeq := func(k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: jt, Jf: jf, K: k}
+	}
+	deny := stmt(unix.BPF_RET|unix.BPF_K, seccompRetErrno|uint32(unix.EPERM))
+
+	prog := []unix.SockFilter{
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArch),
+		jeq(arch, 1, 0),
+		stmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNr),
+	}
+	if runtime.GOARCH == "amd64" {
+		// x32 syscalls would bypass the numbers below
+		prog = append(prog,
+			unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, Jt: 0, Jf: 1, K: 0x40000000},
+			deny)
+	}
+	for _, nr := range seccompBlocked {
+		prog = append(prog, jeq(nr, 0, 1), deny)
+	}
+	// clone3 passes flags in memory the filter cannot read; callers fall
+	// back to clone when it reports ENOSYS.
+	prog = append(prog,
+		jeq(unix.SYS_CLONE3, 0, 1),
+		stmt(unix.BPF_RET|unix.BPF_K, seccompRetErrno|uint32(unix.ENOSYS)),
+		jeq(unix.SYS_CLONE, 0, 3),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArg0),
+		unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, Jt: 0, Jf: 1, K: cloneNamespaceFlags},
+		deny,
+		stmt(unix.BPF_RET|unix.BPF_K, seccompRetAllow),
+	)
+
+	fprog := unix.SockFprog{Len: uint16(len(prog)), Filter: &prog[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&fprog)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+	return nil
+}
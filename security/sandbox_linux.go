@@ -3,18 +3,168 @@
 package security
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"syscall"
+
+	"golang.org/x/sys/unix"
 )
 
+// The sandbox is set up by re-executing the current binary
+// (/proc/self/exe) inside the new namespaces. The init hook in
+// sandbox_init_linux.go sees sandboxInitEnv, applies mounts, rlimits,
+// Landlock and seccomp, reports what it applied on fd 3 and then execs
+// the command.
+
 // applySandbox applies sandbox restrictions to the command (Linux-specific)
 func (sc *SandboxedCommand) applySandbox(workDir string) error {
-	// Set process attributes for basic isolation
-	sc.cmd.SysProcAttr = &syscall.SysProcAttr{
-		// Create new process group
-		Setpgid: true,
-		// Set clone flags for basic isolation
-		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS,
+	root := sc.config.RootDir
+	if root == "" {
+		root = workDir
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve RootDir: %w", err)
+	}
+
+	support := DetectSandboxSupport()
+	for _, f := range sc.config.requested() {
+		if !support.Has(f) {
+			sc.report.unavailable(f, support.Unavailable[f])
+		}
+	}
+
+	spec := sandboxSpec{
+		Path:     sc.cmd.Path,
+		Args:     sc.cmd.Args,
+		RootDir:  root,
+		TmpDir:   sc.tmpDir,
+		ReadOnly: sc.config.ReadOnly,
+		Limits:   sc.config.Limits,
+		Seccomp:  sc.config.EnableSeccomp && support.Has(FeatureSeccomp),
+		Strict:   !sc.config.BestEffort,
+	}
+	if sc.config.Landlock && support.Has(FeatureLandlock) {
+		spec.LandlockABI = support.LandlockABI
+	}
+
+	// Create new process group
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	if support.Has(FeaturePIDNamespace) {
+		spec.Namespaces = true
+		attr.Cloneflags = syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWPID
+		var caps []uintptr
+		if sc.config.NoNetwork && support.Has(FeatureNetworkNamespace) {
+			spec.NoNetwork = true
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+			caps = append(caps, unix.CAP_NET_ADMIN)
+		}
+		if os.Geteuid() != 0 {
+			spec.UserNamespace = true
+			setUserNamespace(attr, append(caps, unix.CAP_SYS_ADMIN))
+		}
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	sc.reportR, sc.reportW, err = os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create report pipe: %w", err)
+	}
+
+	sc.cmd.Path = "/proc/self/exe"
+	sc.cmd.Args = []string{"gokin-sandbox"}
+	sc.cmd.Env = append(sc.cmd.Env, sandboxInitEnv+"="+string(specJSON))
+	sc.cmd.ExtraFiles = []*os.File{sc.reportW}
+	sc.cmd.SysProcAttr = attr
+	return nil
+}
+
+// setUserNamespace maps the current user into a new user namespace under
+// its own IDs. caps are kept as ambient capabilities so the init process
+// can set up the sandbox; it drops them before running the command.
+func setUserNamespace(attr *syscall.SysProcAttr, caps []uintptr) {
+	attr.Cloneflags |= syscall.CLONE_NEWUSER
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	attr.AmbientCaps = caps
+}
+
+func detectSandboxSupport() SandboxSupport {
+	s := SandboxSupport{Unavailable: make(map[SandboxFeature]string)}
+	mark := func(f SandboxFeature, err error) {
+		if err != nil {
+			s.Unavailable[f] = err.Error()
+		} else {
+			s.Available = append(s.Available, f)
+		}
+	}
+
+	// Namespaces: start the init hook in them and see whether it runs
+	flags := uintptr(syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWPID)
+	nsErr := probeNamespaces(flags, nil)
+	if nsErr == nil && os.Geteuid() != 0 {
+		s.Available = append(s.Available, FeatureUserNamespace)
+	} else if os.Geteuid() != 0 {
+		s.Unavailable[FeatureUserNamespace] = nsErr.Error()
+	}
+	mark(FeaturePIDNamespace, nsErr)
+	mark(FeatureReadOnlyRoot, nsErr)
+	if nsErr == nil {
+		mark(FeatureNetworkNamespace, probeNamespaces(flags|syscall.CLONE_NEWNET, []uintptr{unix.CAP_NET_ADMIN}))
+	} else {
+		mark(FeatureNetworkNamespace, nsErr)
+	}
+
+	abi, err := landlockABI()
+	s.LandlockABI = abi
+	mark(FeatureLandlock, err)
+
+	mark(FeatureSeccomp, seccompSupported())
+	mark(FeatureRlimits, nil)
+	return s
+}
+
+// probeNamespaces runs the init hook in new namespaces with flags.
+func probeNamespaces(flags uintptr, caps []uintptr) error {
+	cmd := exec.Command("/proc/self/exe")
+	cmd.Args = []string{"gokin-sandbox-probe"}
+	cmd.Env = []string{sandboxInitEnv + "=" + sandboxProbe}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: flags}
+	if os.Geteuid() != 0 {
+		setUserNamespace(cmd.SysProcAttr, append(caps, unix.CAP_SYS_ADMIN))
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("namespaces unavailable: %w: %s", err, out)
+		}
+		return fmt.Errorf("namespaces unavailable: %w", err)
 	}
+	return nil
+}
 
+// landlockABI returns the Landlock ABI version supported by the kernel.
+func landlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, fmt.Errorf("landlock unavailable: %w", errno)
+	}
+	return int(abi), nil
+}
+
+// seccompSupported reports whether seccomp filters can be installed.
+func seccompSupported() error {
+	if _, ok := seccompArch[runtime.GOARCH]; !ok {
+		return fmt.Errorf("seccomp filter not implemented for %s", runtime.GOARCH)
+	}
+	if _, err := unix.PrctlRetInt(unix.PR_GET_SECCOMP, 0, 0, 0, 0); err != nil {
+		return fmt.Errorf("seccomp unavailable: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,87 @@
+//go:build linux
+
+package security
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// sandboxConfigForTest enables the namespace sandbox, skipping the test
+// when the system cannot provide it.
+func sandboxConfigForTest(t *testing.T, rootDir string) SandboxConfig {
+	t.Helper()
+	support := DetectSandboxSupport()
+	for _, f := range []SandboxFeature{FeaturePIDNamespace, FeatureReadOnlyRoot} {
+		if !support.Has(f) {
+			t.Skipf("sandbox feature %s unavailable: %s", f, support.Unavailable[f])
+		}
+	}
+	config := DefaultSandboxConfig()
+	config.RootDir = rootDir
+	config.Landlock = support.Has(FeatureLandlock)
+	return config
+}
+
+func TestSandboxDeniesWriteOutsideRootDir(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	config := sandboxConfigForTest(t, root)
+
+	command := "echo x > " + filepath.Join(outside, "escaped") + "; echo y > inside"
+	sc, err := NewSandboxedCommand(context.Background(), root, command, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := sc.Run(10 * time.Second)
+	if res.Error != nil {
+		t.Fatalf("run failed: %v (stderr: %s)", res.Error, res.Stderr)
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "escaped")); !os.IsNotExist(err) {
+		t.Errorf("write outside RootDir was allowed (stat: %v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "inside")); err != nil {
+		t.Errorf("write inside RootDir failed: %v (stderr: %s)", err, res.Stderr)
+	}
+}
+
+func TestSandboxSetupFailureAbortsCommand(t *testing.T) {
+	workDir := t.TempDir()
+	marker := filepath.Join(workDir, "ran")
+	// Binding a missing RootDir fails inside the sandbox
+	config := sandboxConfigForTest(t, filepath.Join(workDir, "missing"))
+
+	sc, err := NewSandboxedCommand(context.Background(), workDir, "touch "+marker, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := sc.Run(10 * time.Second)
+	if res.Error == nil {
+		t.Error("expected an error for a failed sandbox setup")
+	}
+	if res.ExitCode != 126 {
+		t.Errorf("exit code = %d, want 126 (stderr: %s)", res.ExitCode, res.Stderr)
+	}
+	if _, ok := res.Report.Unavailable[FeatureReadOnlyRoot]; !ok {
+		t.Errorf("report does not name the failed protection: %+v", res.Report)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("command ran although the sandbox setup failed")
+	}
+
+	config.BestEffort = true
+	sc, err = NewSandboxedCommand(context.Background(), workDir, "touch "+marker, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := sc.Run(10 * time.Second); res.Error != nil || res.ExitCode != 0 {
+		t.Fatalf("best-effort run failed: %v, exit %d (stderr: %s)", res.Error, res.ExitCode, res.Stderr)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("best-effort command did not run: %v", err)
+	}
+}
@@ -3,11 +3,16 @@
 package security
 
 import (
+	"runtime"
 	"syscall"
 )
 
 // applySandbox applies basic process isolation for non-Linux Unix platforms (macOS, BSD)
 func (sc *SandboxedCommand) applySandbox(workDir string) error {
+	for _, f := range sc.config.requested() {
+		sc.report.unavailable(f, "not supported on "+runtime.GOOS)
+	}
+
 	// For non-Linux Unix systems, we provide basic process group isolation
 	sc.cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true,
@@ -15,3 +20,7 @@ func (sc *SandboxedCommand) applySandbox(workDir string) error {
 
 	return nil
 }
+
+func detectSandboxSupport() SandboxSupport {
+	return unsupportedSandbox()
+}
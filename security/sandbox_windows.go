@@ -2,9 +2,18 @@
 
 package security
 
+import "runtime"
+
 // applySandbox applies basic process isolation for Windows
 func (sc *SandboxedCommand) applySandbox(workDir string) error {
 	// Windows doesn't support Unix process groups
 	// Basic isolation is handled by the OS
+	for _, f := range sc.config.requested() {
+		sc.report.unavailable(f, "not supported on "+runtime.GOOS)
+	}
 	return nil
 }
+
+func detectSandboxSupport() SandboxSupport {
+	return unsupportedSandbox()
+}
@@ -3,7 +3,6 @@ package semantic
 import (
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
 	"path/filepath"
 	"regexp"
@@ -111,8 +110,7 @@ func (c *StructuralChunker) chunkWithRegex(filePath, content string, structRegex
 
 // chunkGo uses the Go AST to split code into functions and types.
 func (c *StructuralChunker) chunkGo(filePath, content string) []ChunkInfo {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	f, err := ParseGoFile(filePath, content)
 	if err != nil {
 		// Fallback if parsing fails
 		return c.chunkSlidingWindow(filePath, content)
@@ -122,7 +120,7 @@ func (c *StructuralChunker) chunkGo(filePath, content string) []ChunkInfo {
 	lines := strings.Split(content, "\n")
 
 	// Process top-level declarations
-	for _, decl := range f.Decls {
+	for _, decl := range f.AST.Decls {
 		var start, end token.Pos
 		var chunkType string
 
@@ -137,8 +135,8 @@ func (c *StructuralChunker) chunkGo(filePath, content string) []ChunkInfo {
 			continue
 		}
 
-		startPos := fset.Position(start)
-		endPos := fset.Position(end)
+		startPos := f.Fset.Position(start)
+		endPos := f.Fset.Position(end)
 
 		// Get the lines for this declaration
 		if startPos.Line > 0 && endPos.Line >= startPos.Line && endPos.Line <= len(lines) {
@@ -0,0 +1,232 @@
+package semantic
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// GoFile is a parsed Go source file.
+type GoFile struct {
+	Path    string
+	Content string
+	Fset    *token.FileSet
+	AST     *ast.File
+}
+
+// ParseGoFile parses Go source, keeping comments.
+func ParseGoFile(filePath, content string) (*GoFile, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return &GoFile{Path: filePath, Content: content, Fset: fset, AST: f}, nil
+}
+
+// GoDecl is a top-level declaration of a Go file: a function, a method, or
+// one type, var or const spec.
+type GoDecl struct {
+	Name      string // "Name", or "Recv.Name" for methods
+	Kind      string // "func", "method", "type", "var" or "const"
+	Signature string // one-line summary, such as "func (s *Server) Start() error"
+	Doc       string // doc comment text
+	Exported  bool
+
+	// Byte offsets into the content. Start..End covers the node as
+	// written: the whole declaration for functions and ungrouped specs,
+	// only the spec inside a grouped declaration. DocStart is where its
+	// doc comment begins, or Start without one. BodyStart..BodyEnd covers
+	// a function body including its braces, and is -1 otherwise.
+	Start, End         int
+	DocStart           int
+	BodyStart, BodyEnd int
+
+	LineStart, LineEnd int // 1-based lines of DocStart..End
+}
+
+// Source returns the declaration's source, including its doc comment.
+func (d GoDecl) Source(content string) string {
+	return content[d.DocStart:d.End]
+}
+
+// Offset returns the byte offset of pos in the file.
+func (f *GoFile) Offset(pos token.Pos) int {
+	return f.Fset.Position(pos).Offset
+}
+
+// Line returns the 1-based line of pos.
+func (f *GoFile) Line(pos token.Pos) int {
+	return f.Fset.Position(pos).Line
+}
+
+// Decls returns the file's top-level declarations in source order.
+func (f *GoFile) Decls() []GoDecl {
+	var decls []GoDecl
+	for _, decl := range f.AST.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			decls = append(decls, f.funcDecl(d))
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			grouped := d.Lparen.IsValid()
+			for _, spec := range d.Specs {
+				decls = append(decls, f.specDecls(d, spec, grouped)...)
+			}
+		}
+	}
+	return decls
+}
+
+func (f *GoFile) funcDecl(d *ast.FuncDecl) GoDecl {
+	decl := GoDecl{
+		Name:      d.Name.Name,
+		Kind:      "func",
+		Exported:  d.Name.IsExported(),
+		Doc:       d.Doc.Text(),
+		BodyStart: -1,
+		BodyEnd:   -1,
+	}
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		decl.Kind = "method"
+		decl.Name = receiverType(d.Recv.List[0].Type) + "." + d.Name.Name
+	}
+	if d.Body != nil {
+		decl.BodyStart, decl.BodyEnd = f.Offset(d.Body.Lbrace), f.Offset(d.Body.Rbrace)+1
+	}
+
+	// The signature is the declaration without doc comment and body
+	sig := *d
+	sig.Doc, sig.Body = nil, nil
+	decl.Signature = f.oneLine(&sig)
+
+	f.setRange(&decl, d.Doc, d.Pos(), d.End())
+	return decl
+}
+
+func (f *GoFile) specDecls(gen *ast.GenDecl, spec ast.Spec, grouped bool) []GoDecl {
+	kind := gen.Tok.String()
+	doc, start, end := gen.Doc, gen.Pos(), gen.End()
+	if grouped {
+		start, end = spec.Pos(), spec.End()
+		doc = nil
+	}
+
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		if grouped {
+			doc = s.Doc
+		}
+		decl := GoDecl{Name: s.Name.Name, Kind: kind, Exported: s.Name.IsExported(), BodyStart: -1, BodyEnd: -1}
+		switch s.Type.(type) {
+		case *ast.StructType:
+			decl.Signature = "type " + s.Name.Name + typeParams(f, s) + " struct"
+		case *ast.InterfaceType:
+			decl.Signature = "type " + s.Name.Name + typeParams(f, s) + " interface"
+		default:
+			bare := *s
+			bare.Doc, bare.Comment = nil, nil
+			decl.Signature = "type " + f.oneLine(&bare)
+		}
+		decl.Doc = docText(doc, gen.Doc, grouped)
+		f.setRange(&decl, doc, start, end)
+		return []GoDecl{decl}
+
+	case *ast.ValueSpec:
+		if grouped {
+			doc = s.Doc
+		}
+		// One spec may declare several names; each is listed with its
+		// own value when the values pair up with the names
+		var decls []GoDecl
+		for i, name := range s.Names {
+			if name.Name == "_" {
+				continue
+			}
+			decl := GoDecl{Name: name.Name, Kind: kind, Exported: name.IsExported(), BodyStart: -1, BodyEnd: -1}
+			single := &ast.ValueSpec{Names: []*ast.Ident{name}, Type: s.Type}
+			if len(s.Values) == len(s.Names) {
+				single.Values = s.Values[i : i+1]
+			}
+			decl.Signature = kind + " " + f.oneLine(single)
+			decl.Doc = docText(doc, gen.Doc, grouped)
+			f.setRange(&decl, doc, start, end)
+			decls = append(decls, decl)
+		}
+		return decls
+	}
+	return nil
+}
+
+func (f *GoFile) setRange(decl *GoDecl, doc *ast.CommentGroup, start, end token.Pos) {
+	decl.Start, decl.End = f.Offset(start), f.Offset(end)
+	decl.DocStart = decl.Start
+	decl.LineStart = f.Line(start)
+	if doc != nil {
+		decl.DocStart = f.Offset(doc.Pos())
+		decl.LineStart = f.Line(doc.Pos())
+	}
+	decl.LineEnd = f.Line(end)
+}
+
+// oneLine prints a node on a single line, truncated for listings.
+func (f *GoFile) oneLine(node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f.Fset, node); err != nil {
+		return ""
+	}
+	s := strings.Join(strings.Fields(buf.String()), " ")
+	if r := []rune(s); len(r) > 160 {
+		s = string(r[:157]) + "..."
+	}
+	return s
+}
+
+// typeParams renders a type parameter list, e.g. "[K comparable, V any]".
+func typeParams(f *GoFile, s *ast.TypeSpec) string {
+	if s.TypeParams == nil || len(s.TypeParams.List) == 0 {
+		return ""
+	}
+	params := make([]string, 0, len(s.TypeParams.List))
+	for _, field := range s.TypeParams.List {
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+f.oneLine(field.Type))
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// docText prefers a spec's own doc comment, falling back to the group's.
+func docText(doc, groupDoc *ast.CommentGroup, grouped bool) string {
+	if doc != nil {
+		return doc.Text()
+	}
+	if grouped && groupDoc != nil {
+		return groupDoc.Text()
+	}
+	return ""
+}
+
+// receiverType returns the type name of a method receiver, without pointer
+// or type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
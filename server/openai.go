@@ -0,0 +1,431 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+// TaskRouter picks an agent type for a message. sdk.Router and
+// sdk.SmartRouter satisfy it.
+type TaskRouter interface {
+	Route(message string) *sdk.RouteDecision
+}
+
+// ChatOption configures a ChatCompletions handler.
+type ChatOption func(*ChatCompletions)
+
+// WithModel exposes the agents built by factory as model name.
+func WithModel(name string, factory AgentFactory) ChatOption {
+	return func(c *ChatCompletions) {
+		c.models[name] = factory
+	}
+}
+
+// WithRoutedModel exposes a Runner as model name. Each request is routed
+// by router on its last user message, and the agent is built the way the
+// runner spawns agents of the chosen type.
+func WithRoutedModel(name string, runner *sdk.Runner, router TaskRouter) ChatOption {
+	return func(c *ChatCompletions) {
+		c.routed[name] = routedModel{runner: runner, router: router}
+	}
+}
+
+// WithToolDeltas adds tool activity to streamed chunks as an extra
+// "tool_activity" field on the delta. Standard clients ignore it.
+func WithToolDeltas(enabled bool) ChatOption {
+	return func(c *ChatCompletions) {
+		c.toolDeltas = enabled
+	}
+}
+
+type routedModel struct {
+	runner *sdk.Runner
+	router TaskRouter
+}
+
+// ChatCompletions serves agents over the OpenAI Chat Completions protocol:
+//
+//	POST /v1/chat/completions
+//	GET  /v1/models
+//
+// Conversation history comes from the request messages; system messages
+// are added to the agent's pinned context. Tools run server-side, so tool
+// definitions and tool messages sent by the client are ignored.
+type ChatCompletions struct {
+	models     map[string]AgentFactory
+	routed     map[string]routedModel
+	toolDeltas bool
+	mux        *http.ServeMux
+}
+
+// NewChatCompletions creates an OpenAI-compatible handler.
+func NewChatCompletions(opts ...ChatOption) *ChatCompletions {
+	c := &ChatCompletions{
+		models: make(map[string]AgentFactory),
+		routed: make(map[string]routedModel),
+		mux:    http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.mux.HandleFunc("POST /v1/chat/completions", c.handleCompletions)
+	c.mux.HandleFunc("GET /v1/models", c.handleModels)
+	return c
+}
+
+// ServeHTTP implements http.Handler.
+func (c *ChatCompletions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mux.ServeHTTP(w, r)
+}
+
+// --- Wire types ---
+
+type chatRequest struct {
+	Model         string        `json:"model"`
+	Messages      []chatMessage `json:"messages"`
+	Stream        bool          `json:"stream"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the message content, which may be a string or an array of
+// content parts; non-text parts are dropped.
+func (m chatMessage) text() string {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type chatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *chatUsage   `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int        `json:"index"`
+	Message      *chatReply `json:"message,omitempty"`
+	Delta        *chatDelta `json:"delta,omitempty"`
+	FinishReason *string    `json:"finish_reason"`
+}
+
+type chatReply struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatDelta struct {
+	Role         string     `json:"role,omitempty"`
+	Content      string     `json:"content,omitempty"`
+	ToolActivity *sdk.Event `json:"tool_activity,omitempty"`
+}
+
+// --- Handlers ---
+
+func (c *ChatCompletions) handleModels(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for name := range c.models {
+		names = append(names, name)
+	}
+	for name := range c.routed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+	data := make([]model, len(names))
+	for i, name := range names {
+		data[i] = model{ID: name, Object: "model", OwnedBy: "gokin"}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+func (c *ChatCompletions) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %s", err))
+		return
+	}
+
+	session, system, prompt, err := splitMessages(req.Messages)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	factory, err := c.factory(req.Model, prompt)
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "model_not_found", err.Error())
+		return
+	}
+
+	cc := &completionStream{
+		id:      "chatcmpl-" + uuid.NewString(),
+		model:   req.Model,
+		created: time.Now().Unix(),
+	}
+	opts := []sdk.AgentOption{
+		sdk.WithSession(session),
+		sdk.WithEventHandler(cc.observe),
+	}
+	if system != "" {
+		opts = append(opts, sdk.WithPinnedContext(system))
+	}
+	if req.Stream {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeOpenAIError(w, http.StatusInternalServerError, "server_error", "streaming not supported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		cc.w = w
+		cc.flusher = flusher
+		cc.toolDeltas = c.toolDeltas
+		cc.writeChunk(&chatDelta{Role: "assistant"}, nil)
+	}
+
+	agent, err := factory(opts...)
+	if err != nil {
+		cc.fail(w, http.StatusInternalServerError, fmt.Sprintf("failed to create agent: %s", err))
+		return
+	}
+	result, err := agent.Run(r.Context(), prompt)
+	if err == nil && result != nil && result.Error != nil {
+		err = result.Error
+	}
+	if err != nil {
+		cc.fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	content := cc.content(result.Text)
+	finish := "stop"
+	if !req.Stream {
+		writeJSON(w, http.StatusOK, &chatCompletion{
+			ID:      cc.id,
+			Object:  "chat.completion",
+			Created: cc.created,
+			Model:   cc.model,
+			Choices: []chatChoice{{
+				Message:      &chatReply{Role: "assistant", Content: content},
+				FinishReason: &finish,
+			}},
+			Usage: cc.totalUsage(),
+		})
+		return
+	}
+
+	cc.writeChunk(&chatDelta{}, &finish)
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		cc.write(&chatCompletion{
+			ID:      cc.id,
+			Object:  "chat.completion.chunk",
+			Created: cc.created,
+			Model:   cc.model,
+			Choices: []chatChoice{},
+			Usage:   cc.totalUsage(),
+		})
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	cc.flusher.Flush()
+}
+
+// factory resolves the agent factory for a model.
+func (c *ChatCompletions) factory(model, prompt string) (AgentFactory, error) {
+	if factory, ok := c.models[model]; ok {
+		return factory, nil
+	}
+	rm, ok := c.routed[model]
+	if !ok {
+		return nil, fmt.Errorf("model not found: %s", model)
+	}
+	agentType := sdk.AgentTypeGeneral
+	if rm.router != nil {
+		if decision := rm.router.Route(prompt); decision != nil {
+			agentType = sdk.ParseAgentType(decision.SubAgentType)
+		}
+	}
+	return func(opts ...sdk.AgentOption) (*sdk.Agent, error) {
+		return rm.runner.NewAgent(sdk.AgentTask{Prompt: prompt, Type: agentType}, opts...)
+	}, nil
+}
+
+// splitMessages turns request messages into a session holding the prior
+// turns, the combined system text and the final user prompt.
+func splitMessages(messages []chatMessage) (*sdk.Session, string, string, error) {
+	if len(messages) == 0 {
+		return nil, "", "", fmt.Errorf("messages must not be empty")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != "user" {
+		return nil, "", "", fmt.Errorf("last message must have role user, got %q", last.Role)
+	}
+
+	session := sdk.NewSession("")
+	session.SetMaxMessages(len(messages) + 1)
+	var system []string
+	for _, m := range messages[:len(messages)-1] {
+		switch m.Role {
+		case "system", "developer":
+			system = append(system, m.text())
+		case "user":
+			session.AddUserMessage(m.text())
+		case "assistant":
+			if text := m.text(); text != "" {
+				session.AddModelResponse(genai.NewContentFromText(text, "model"))
+			}
+		}
+	}
+	return session, strings.Join(system, "\n\n"), last.text(), nil
+}
+
+// completionStream accumulates usage and response text and, when
+// streaming, writes text and tool activity as completion chunks.
+type completionStream struct {
+	id      string
+	model   string
+	created int64
+
+	w          http.ResponseWriter
+	flusher    http.Flusher
+	toolDeltas bool
+
+	mu    sync.Mutex
+	usage chatUsage
+	text  strings.Builder
+}
+
+// observe handles agent events for the request.
+func (cc *completionStream) observe(ev sdk.Event) {
+	switch ev.Type {
+	case sdk.EventUsage:
+		cc.mu.Lock()
+		cc.usage.PromptTokens += ev.Usage.InputTokens
+		cc.usage.CompletionTokens += ev.Usage.OutputTokens
+		cc.mu.Unlock()
+	case sdk.EventText:
+		cc.mu.Lock()
+		cc.text.WriteString(ev.Text)
+		cc.mu.Unlock()
+		if cc.w != nil {
+			cc.writeChunk(&chatDelta{Content: ev.Text}, nil)
+		}
+	case sdk.EventToolCallStart, sdk.EventToolCallEnd:
+		if cc.w != nil && cc.toolDeltas {
+			cc.writeChunk(&chatDelta{ToolActivity: &ev}, nil)
+		}
+	}
+}
+
+// content returns the response text: the text deltas seen during the run,
+// or fallback when the run produced none, which is then also streamed.
+// Both the streamed and the non-streamed response are built from it.
+func (cc *completionStream) content(fallback string) string {
+	cc.mu.Lock()
+	text := cc.text.String()
+	cc.mu.Unlock()
+	if text != "" || fallback == "" {
+		return text
+	}
+	if cc.w != nil {
+		cc.writeChunk(&chatDelta{Content: fallback}, nil)
+	}
+	return fallback
+}
+
+func (cc *completionStream) totalUsage() *chatUsage {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	u := cc.usage
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return &u
+}
+
+func (cc *completionStream) writeChunk(delta *chatDelta, finish *string) {
+	cc.write(&chatCompletion{
+		ID:      cc.id,
+		Object:  "chat.completion.chunk",
+		Created: cc.created,
+		Model:   cc.model,
+		Choices: []chatChoice{{Delta: delta, FinishReason: finish}},
+	})
+}
+
+func (cc *completionStream) write(chunk *chatCompletion) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	fmt.Fprintf(cc.w, "data: %s\n\n", data)
+	cc.flusher.Flush()
+}
+
+// fail reports an error, in-band if the stream has already started.
+func (cc *completionStream) fail(w http.ResponseWriter, status int, msg string) {
+	if cc.w == nil {
+		writeOpenAIError(w, status, "server_error", msg)
+		return
+	}
+	data, _ := json.Marshal(openAIError(msg, "server_error"))
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	fmt.Fprintf(cc.w, "data: %s\n\n", data)
+	fmt.Fprint(cc.w, "data: [DONE]\n\n")
+	cc.flusher.Flush()
+}
+
+func openAIError(msg, typ string) map[string]any {
+	return map[string]any{"error": map[string]any{"message": msg, "type": typ}}
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, typ, msg string) {
+	writeJSON(w, status, openAIError(msg, typ))
+}
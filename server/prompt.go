@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/permission"
+)
+
+// PromptKind identifies what a prompt asks for.
+type PromptKind string
+
+const (
+	PromptKindQuestion   PromptKind = "question"   // free-form question or choice among Options
+	PromptKindConfirm    PromptKind = "confirm"    // yes/no question
+	PromptKindPermission PromptKind = "permission" // tool permission request
+)
+
+// Prompt is a question or permission request waiting for a client answer.
+// It is published on the run's event stream as a "prompt" event and
+// answered with POST /v1/runs/{id}/prompts/{prompt_id}.
+type Prompt struct {
+	ID       string         `json:"id"`
+	Kind     PromptKind     `json:"kind"`
+	Question string         `json:"question,omitempty"`
+	Options  []string       `json:"options,omitempty"`
+	Default  string         `json:"default,omitempty"`
+	Tool     string         `json:"tool,omitempty"`
+	Args     map[string]any `json:"args,omitempty"`
+	Risk     string         `json:"risk,omitempty"`
+	Reason   string         `json:"reason,omitempty"`
+	Diff     string         `json:"diff,omitempty"`
+}
+
+// PromptAnswer is the body used to answer a prompt.
+type PromptAnswer struct {
+	// Answer is the reply to a question prompt, or "yes"/"no" for a
+	// confirm prompt.
+	Answer string `json:"answer,omitempty"`
+
+	// Decision answers a permission prompt: "allow", "allow_session",
+	// "deny" or "deny_session".
+	Decision string `json:"decision,omitempty"`
+}
+
+type pendingPrompt struct {
+	prompt    *Prompt
+	answer    chan PromptAnswer
+	cancelled chan struct{}
+}
+
+type runKey struct{}
+
+func withRun(ctx context.Context, rn *run) context.Context {
+	return context.WithValue(ctx, runKey{}, rn)
+}
+
+func runFromContext(ctx context.Context) *run {
+	rn, _ := ctx.Value(runKey{}).(*run)
+	return rn
+}
+
+// errNoRun is returned by the prompt handlers outside a server run.
+var errNoRun = errors.New("not running inside a server run")
+
+// AskUser forwards an ask_user question to the client of the current run
+// and waits for the answer. It matches tools.QuestionHandler:
+//
+//	askUser.SetHandler(server.AskUser)
+func AskUser(ctx context.Context, question string, options []string, defaultOption string) (string, error) {
+	rn := runFromContext(ctx)
+	if rn == nil {
+		return "", errNoRun
+	}
+	answer, err := rn.ask(ctx, &Prompt{
+		Kind:     PromptKindQuestion,
+		Question: question,
+		Options:  options,
+		Default:  defaultOption,
+	})
+	if err != nil {
+		return "", err
+	}
+	if answer.Answer == "" {
+		return defaultOption, nil
+	}
+	return answer.Answer, nil
+}
+
+// PromptPermission forwards a permission request to the client of the
+// current run and waits for the decision. It is a permission.PromptHandler:
+//
+//	permissions.SetPromptHandler(server.PromptPermission)
+func PromptPermission(ctx context.Context, req *permission.Request) (permission.Decision, error) {
+	rn := runFromContext(ctx)
+	if rn == nil {
+		return permission.DecisionDeny, errNoRun
+	}
+	answer, err := rn.ask(ctx, &Prompt{
+		Kind:   PromptKindPermission,
+		Tool:   req.ToolName,
+		Args:   req.Args,
+		Risk:   req.RiskLevel.String(),
+		Reason: req.Reason,
+		Diff:   req.Diff,
+	})
+	if err != nil {
+		return permission.DecisionDeny, err
+	}
+	return parseDecision(answer.Decision)
+}
+
+var _ permission.PromptHandler = PromptPermission
+
+// RunInteraction is an sdk.Interaction that forwards to the client of the
+// current run. The server installs it on every agent it runs, so ask_user
+// and permission checks reach the client without extra wiring.
+// Notifications are published as "notification" events.
+var RunInteraction sdk.Interaction = runInteraction{}
+
+type runInteraction struct{}
+
+func (runInteraction) Ask(ctx context.Context, question, defaultAnswer string) (string, error) {
+	return AskUser(ctx, question, nil, defaultAnswer)
+}
+
+func (runInteraction) Choose(ctx context.Context, question string, options []string, defaultOption string) (string, error) {
+	answer, err := AskUser(ctx, question, options, defaultOption)
+	if err != nil {
+		return "", err
+	}
+	for _, opt := range options {
+		if strings.EqualFold(opt, answer) {
+			return opt, nil
+		}
+	}
+	return "", fmt.Errorf("answer %q is not one of the options", answer)
+}
+
+func (runInteraction) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	rn := runFromContext(ctx)
+	if rn == nil {
+		return false, errNoRun
+	}
+	def := "no"
+	if defaultYes {
+		def = "yes"
+	}
+	answer, err := rn.ask(ctx, &Prompt{
+		Kind:     PromptKindConfirm,
+		Question: question,
+		Options:  []string{"yes", "no"},
+		Default:  def,
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer.Answer) {
+	case "":
+		return defaultYes, nil
+	case "y", "yes", "true":
+		return true, nil
+	case "n", "no", "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("answer %q is not yes or no", answer.Answer)
+	}
+}
+
+func (runInteraction) Notify(ctx context.Context, message string) error {
+	rn := runFromContext(ctx)
+	if rn == nil {
+		return errNoRun
+	}
+	rn.publish("notification", map[string]string{"message": message})
+	return nil
+}
+
+func parseDecision(s string) (permission.Decision, error) {
+	switch s {
+	case "allow":
+		return permission.DecisionAllow, nil
+	case "allow_session":
+		return permission.DecisionAllowSession, nil
+	case "deny", "":
+		return permission.DecisionDeny, nil
+	case "deny_session":
+		return permission.DecisionDenySession, nil
+	default:
+		return permission.DecisionDeny, fmt.Errorf("unknown permission decision: %s", s)
+	}
+}
+
+// ask publishes p and blocks until it is answered, the run finishes or
+// ctx is done.
+func (rn *run) ask(ctx context.Context, p *Prompt) (PromptAnswer, error) {
+	p.ID = uuid.NewString()
+	pending := &pendingPrompt{
+		prompt:    p,
+		answer:    make(chan PromptAnswer, 1),
+		cancelled: make(chan struct{}),
+	}
+
+	rn.mu.Lock()
+	if rn.status != RunRunning {
+		rn.mu.Unlock()
+		return PromptAnswer{}, fmt.Errorf("run %s has finished", rn.id)
+	}
+	rn.prompts[p.ID] = pending
+	rn.mu.Unlock()
+	rn.publish("prompt", p)
+
+	select {
+	case answer := <-pending.answer:
+		return answer, nil
+	case <-pending.cancelled:
+		return PromptAnswer{}, fmt.Errorf("run %s finished before prompt was answered", rn.id)
+	case <-ctx.Done():
+		rn.mu.Lock()
+		delete(rn.prompts, p.ID)
+		rn.mu.Unlock()
+		return PromptAnswer{}, ctx.Err()
+	}
+}
+
+// answer resolves a pending prompt.
+func (rn *run) answer(promptID string, answer PromptAnswer) error {
+	rn.mu.Lock()
+	pending, ok := rn.prompts[promptID]
+	if ok {
+		delete(rn.prompts, promptID)
+	}
+	rn.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("prompt not found: %s", promptID)
+	}
+
+	pending.answer <- answer
+	rn.publish("prompt_answered", map[string]string{"id": promptID})
+	return nil
+}
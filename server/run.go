@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+// RunStatus is the state of a server run.
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunCompleted RunStatus = "completed"
+	RunFailed    RunStatus = "failed"
+	RunCancelled RunStatus = "cancelled"
+)
+
+// RunInfo describes a run.
+type RunInfo struct {
+	ID        string           `json:"id"`
+	Agent     string           `json:"agent"`
+	SessionID string           `json:"session_id"`
+	Status    RunStatus        `json:"status"`
+	StartedAt time.Time        `json:"started_at"`
+	Result    *sdk.ResultEvent `json:"result,omitempty"`
+	Prompts   []*Prompt        `json:"prompts,omitempty"` // unanswered prompts
+}
+
+// sseMessage is one entry of a run's event log.
+type sseMessage struct {
+	event string
+	data  []byte
+}
+
+// run is a single agent run. Its event log is kept in full so that SSE
+// clients connecting late see the whole run.
+type run struct {
+	id        string
+	agent     string
+	sessionID string
+	startedAt time.Time
+	cancel    context.CancelFunc
+
+	mu      sync.Mutex
+	log     []sseMessage
+	notify  chan struct{} // closed and replaced whenever the log grows
+	status  RunStatus
+	result  *sdk.ResultEvent
+	prompts map[string]*pendingPrompt
+}
+
+func newRun(agent, sessionID string, cancel context.CancelFunc) *run {
+	return &run{
+		id:        uuid.NewString(),
+		agent:     agent,
+		sessionID: sessionID,
+		startedAt: time.Now(),
+		cancel:    cancel,
+		notify:    make(chan struct{}),
+		status:    RunRunning,
+		prompts:   make(map[string]*pendingPrompt),
+	}
+}
+
+func (rn *run) info() RunInfo {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	info := RunInfo{
+		ID:        rn.id,
+		Agent:     rn.agent,
+		SessionID: rn.sessionID,
+		Status:    rn.status,
+		StartedAt: rn.startedAt,
+		Result:    rn.result,
+	}
+	for _, p := range rn.prompts {
+		info.Prompts = append(info.Prompts, p.prompt)
+	}
+	return info
+}
+
+func (rn *run) finished() bool {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	return rn.status != RunRunning
+}
+
+// publishEvent appends an agent event to the log.
+func (rn *run) publishEvent(ev sdk.Event) {
+	rn.publish(string(ev.Type), ev)
+}
+
+func (rn *run) publish(event string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.appendLocked(sseMessage{event: event, data: data})
+}
+
+func (rn *run) appendLocked(msg sseMessage) {
+	rn.log = append(rn.log, msg)
+	close(rn.notify)
+	rn.notify = make(chan struct{})
+}
+
+// finish records the run's outcome and wakes all subscribers.
+// The agent has already emitted its EventDone.
+func (rn *run) finish(result *sdk.AgentResult, err error) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	done := &sdk.ResultEvent{}
+	if result != nil {
+		done.Text = result.Text
+		done.Turns = result.Turns
+		done.Duration = result.Duration
+		if result.Error != nil {
+			done.Error = result.Error.Error()
+		}
+	}
+	if err != nil {
+		done.Error = err.Error()
+	}
+	rn.result = done
+
+	switch {
+	case errors.Is(err, context.Canceled) || (result != nil && errors.Is(result.Error, context.Canceled)):
+		rn.status = RunCancelled
+	case done.Error != "":
+		rn.status = RunFailed
+	default:
+		rn.status = RunCompleted
+	}
+	for id, p := range rn.prompts {
+		close(p.cancelled)
+		delete(rn.prompts, id)
+	}
+	close(rn.notify)
+	rn.notify = make(chan struct{})
+}
+
+// next returns log entries from index i onward, a channel that is closed
+// when more arrive, and whether the run has finished.
+func (rn *run) next(i int) ([]sseMessage, <-chan struct{}, bool) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	var msgs []sseMessage
+	if i < len(rn.log) {
+		msgs = rn.log[i:]
+	}
+	return msgs, rn.notify, rn.status != RunRunning
+}
+
+// handleEvents streams a run's events as Server-Sent Events. Each event
+// carries an id so clients can resume with Last-Event-ID.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	rn, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run not found: %s", r.PathValue("id")))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	pos := 0
+	if last := r.Header.Get("Last-Event-ID"); last != "" {
+		fmt.Sscanf(last, "%d", &pos)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		msgs, notify, done := rn.next(pos)
+		for _, msg := range msgs {
+			pos++
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", pos, msg.event, msg.data)
+		}
+		if len(msgs) > 0 {
+			flusher.Flush()
+		}
+		if done {
+			return
+		}
+		select {
+		case <-notify:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,555 @@
+// Package server exposes registered agents as an HTTP service with
+// Server-Sent Events streaming of run events.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+// AgentFactory builds a fresh agent for a run. The server appends options
+// that bind the run's session and event stream, so factories should pass
+// opts through to sdk.NewAgent.
+type AgentFactory func(opts ...sdk.AgentOption) (*sdk.Agent, error)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithSessionStore persists sessions so they survive server restarts.
+// Without a store, sessions live in memory only.
+func WithSessionStore(store *sdk.SessionStore) Option {
+	return func(s *Server) {
+		s.store = store
+	}
+}
+
+// WithMaxSessions limits the number of sessions kept in memory (default
+// 1000). The least recently used idle sessions are evicted first; with a
+// session store they are reloaded on their next use, without one they are
+// gone.
+func WithMaxSessions(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxSessions = n
+		}
+	}
+}
+
+// WithMaxRuns limits the number of finished runs kept for inspection.
+func WithMaxRuns(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxRuns = n
+		}
+	}
+}
+
+// Server serves agent runs over HTTP.
+//
+// Routes:
+//
+//	GET  /v1/agents                         list registered agents
+//	GET  /v1/sessions                       list and search sessions
+//	POST /v1/sessions                       create a session
+//	GET  /v1/sessions/{id}                  describe a session
+//	POST /v1/runs                           start a run
+//	GET  /v1/runs/{id}                      run status and result
+//	GET  /v1/runs/{id}/events               SSE stream of run events
+//	POST /v1/runs/{id}/cancel               cancel a run
+//	POST /v1/runs/{id}/prompts/{prompt_id}  answer a question or permission prompt
+type Server struct {
+	agents      map[string]AgentFactory
+	store       *sdk.SessionStore
+	maxRuns     int
+	maxSessions int
+
+	sessions map[string]*sdk.Session
+	lastUsed map[string]time.Time // session ID -> last use, for eviction
+	busy     map[string]string    // session ID -> active run ID
+	runs     map[string]*run
+	order    []string // run IDs, oldest first
+	mu       sync.Mutex
+
+	mux *http.ServeMux
+}
+
+// New creates a server with no registered agents.
+func New(opts ...Option) *Server {
+	s := &Server{
+		agents:      make(map[string]AgentFactory),
+		maxRuns:     100,
+		maxSessions: 1000,
+		sessions:    make(map[string]*sdk.Session),
+		lastUsed:    make(map[string]time.Time),
+		busy:        make(map[string]string),
+		runs:        make(map[string]*run),
+		mux:         http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /v1/agents", s.handleListAgents)
+	s.mux.HandleFunc("GET /v1/sessions", s.handleListSessions)
+	s.mux.HandleFunc("POST /v1/sessions", s.handleCreateSession)
+	s.mux.HandleFunc("GET /v1/sessions/{id}", s.handleGetSession)
+	s.mux.HandleFunc("POST /v1/runs", s.handleStartRun)
+	s.mux.HandleFunc("GET /v1/runs/{id}", s.handleGetRun)
+	s.mux.HandleFunc("GET /v1/runs/{id}/events", s.handleEvents)
+	s.mux.HandleFunc("POST /v1/runs/{id}/cancel", s.handleCancel)
+	s.mux.HandleFunc("POST /v1/runs/{id}/prompts/{prompt_id}", s.handleAnswer)
+	return s
+}
+
+// Register makes an agent available under name.
+func (s *Server) Register(name string, factory AgentFactory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents[name] = factory
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// --- Sessions ---
+
+// SessionInfo describes a session.
+type SessionInfo struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Messages  int      `json:"messages"`
+	Summary   string   `json:"summary"`
+	Version   int64    `json:"version"`
+	ActiveRun string   `json:"active_run,omitempty"`
+}
+
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.agents))
+	for name := range s.agents {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, map[string]any{"agents": names})
+}
+
+// handleListSessions queries sessions. Query parameters: tag (repeatable),
+// q (text search), updated_after and updated_before (RFC 3339), offset and
+// limit.
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := sdk.SessionQuery{
+		Tags: params["tag"],
+		Text: params.Get("q"),
+	}
+	var err error
+	if q.Offset, err = intParam(params.Get("offset")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %w", err))
+		return
+	}
+	if q.Limit, err = intParam(params.Get("limit")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+	if q.UpdatedAfter, err = timeParam(params.Get("updated_after")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid updated_after: %w", err))
+		return
+	}
+	if q.UpdatedBefore, err = timeParam(params.Get("updated_before")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid updated_before: %w", err))
+		return
+	}
+
+	if s.store != nil {
+		page, err := s.store.Query(q)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+		return
+	}
+
+	// Without a store, query the live sessions.
+	s.mu.Lock()
+	sessions := make([]*sdk.Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mu.Unlock()
+
+	var matches []sdk.SessionMeta
+	for _, session := range sessions {
+		meta := sdk.SessionMeta{
+			ID:           session.ID(),
+			Title:        session.Title(),
+			Tags:         session.Tags(),
+			CreatedAt:    session.CreatedAt(),
+			UpdatedAt:    session.UpdatedAt(),
+			MessageCount: session.Len(),
+			Version:      session.GetVersion(),
+		}
+		if q.Match(&meta, sessionText(session)) {
+			matches = append(matches, meta)
+		}
+	}
+	writeJSON(w, http.StatusOK, q.Page(matches))
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID    string   `json:"id"`
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+	if req.ID != "" {
+		if err := sdk.ValidateSessionID(req.ID); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	session := sdk.NewSession(req.ID)
+	if req.Title != "" {
+		session.SetTitle(req.Title)
+	}
+	if len(req.Tags) > 0 {
+		session.SetTags(req.Tags...)
+	}
+	s.mu.Lock()
+	if _, exists := s.sessions[session.ID()]; exists {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("session already exists: %s", session.ID()))
+		return
+	}
+	s.trackSessionLocked(session)
+	s.mu.Unlock()
+
+	if s.store != nil {
+		if err := s.store.Save(session); err != nil {
+			s.mu.Lock()
+			s.forgetSessionLocked(session.ID())
+			s.mu.Unlock()
+			status := http.StatusInternalServerError
+			if errors.Is(err, sdk.ErrSessionConflict) {
+				status = http.StatusConflict
+			}
+			writeError(w, status, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, s.sessionInfo(session))
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := sdk.ValidateSessionID(id); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	session, err := s.session(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.sessionInfo(session))
+}
+
+func (s *Server) sessionInfo(session *sdk.Session) SessionInfo {
+	s.mu.Lock()
+	active := s.busy[session.ID()]
+	s.mu.Unlock()
+	return SessionInfo{
+		ID:        session.ID(),
+		Title:     session.Title(),
+		Tags:      session.Tags(),
+		Messages:  session.Len(),
+		Summary:   session.Summary(),
+		Version:   session.GetVersion(),
+		ActiveRun: active,
+	}
+}
+
+// session returns a live session, loading it from the store if needed.
+func (s *Server) session(id string) (*sdk.Session, error) {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	if ok {
+		s.lastUsed[id] = time.Now()
+	}
+	s.mu.Unlock()
+	if ok {
+		return session, nil
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+
+	session, err := s.store.Load(id)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.sessions[id]; ok {
+		return existing, nil
+	}
+	s.trackSessionLocked(session)
+	return session, nil
+}
+
+// trackSessionLocked keeps session in memory as its most recent use and
+// evicts the least recently used idle sessions over the limit. The caller
+// must hold s.mu.
+func (s *Server) trackSessionLocked(session *sdk.Session) {
+	s.sessions[session.ID()] = session
+	s.lastUsed[session.ID()] = time.Now()
+
+	for len(s.sessions) > s.maxSessions {
+		oldest := ""
+		for id, used := range s.lastUsed {
+			if _, busy := s.busy[id]; busy || id == session.ID() {
+				continue
+			}
+			if oldest == "" || used.Before(s.lastUsed[oldest]) {
+				oldest = id
+			}
+		}
+		if oldest == "" {
+			return // every other session is running
+		}
+		s.forgetSessionLocked(oldest)
+	}
+}
+
+// forgetSessionLocked drops a session from memory. The caller must hold s.mu.
+func (s *Server) forgetSessionLocked(id string) {
+	delete(s.sessions, id)
+	delete(s.lastUsed, id)
+}
+
+// --- Runs ---
+
+// StartRunRequest is the body of POST /v1/runs.
+type StartRunRequest struct {
+	Agent     string `json:"agent"`
+	Message   string `json:"message"`
+	SessionID string `json:"session_id,omitempty"` // continue this session; a new one is created if empty
+}
+
+func (s *Server) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	var req StartRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, errors.New("message is required"))
+		return
+	}
+	if req.SessionID != "" {
+		if err := sdk.ValidateSessionID(req.SessionID); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	run, status, err := s.startRun(req)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, run.info())
+}
+
+// startRun creates the agent and launches the run in the background.
+// The run outlives the request that started it.
+func (s *Server) startRun(req StartRunRequest) (*run, int, error) {
+	s.mu.Lock()
+	factory, ok := s.agents[req.Agent]
+	s.mu.Unlock()
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("unknown agent: %s", req.Agent)
+	}
+
+	var session *sdk.Session
+	if req.SessionID != "" {
+		var err error
+		if session, err = s.session(req.SessionID); err != nil {
+			return nil, http.StatusNotFound, err
+		}
+	} else {
+		session = sdk.NewSession("")
+		s.mu.Lock()
+		s.trackSessionLocked(session)
+		s.mu.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rn := newRun(req.Agent, session.ID(), cancel)
+
+	s.mu.Lock()
+	if active, ok := s.busy[session.ID()]; ok {
+		s.mu.Unlock()
+		cancel()
+		return nil, http.StatusConflict, fmt.Errorf("session %s is busy with run %s", session.ID(), active)
+	}
+	s.busy[session.ID()] = rn.id
+	s.mu.Unlock()
+
+	opts := []sdk.AgentOption{
+		sdk.WithSession(session),
+		sdk.WithEventHandler(rn.publishEvent),
+		sdk.WithInteraction(RunInteraction),
+	}
+	if s.store != nil {
+		opts = append(opts, sdk.WithSessionStore(s.store))
+	}
+	agent, err := factory(opts...)
+	if err != nil {
+		s.release(session.ID())
+		cancel()
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create agent: %w", err)
+	}
+
+	s.addRun(rn)
+	go func() {
+		defer cancel()
+		defer s.release(session.ID())
+		result, err := agent.Run(withRun(ctx, rn), req.Message)
+		rn.finish(result, err)
+	}()
+	return rn, 0, nil
+}
+
+func (s *Server) release(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.busy, sessionID)
+}
+
+// addRun records rn and evicts the oldest finished runs over the limit.
+func (s *Server) addRun(rn *run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[rn.id] = rn
+	s.order = append(s.order, rn.id)
+
+	for i := 0; len(s.runs) > s.maxRuns && i < len(s.order); {
+		id := s.order[i]
+		if old := s.runs[id]; old != nil && old.finished() {
+			delete(s.runs, id)
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			continue
+		}
+		i++
+	}
+}
+
+func (s *Server) run(id string) (*run, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rn, ok := s.runs[id]
+	return rn, ok
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	rn, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run not found: %s", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, rn.info())
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	rn, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run not found: %s", r.PathValue("id")))
+		return
+	}
+	rn.cancel()
+	writeJSON(w, http.StatusOK, rn.info())
+}
+
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	rn, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run not found: %s", r.PathValue("id")))
+		return
+	}
+	var answer PromptAnswer
+	if err := json.NewDecoder(r.Body).Decode(&answer); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if err := rn.answer(r.PathValue("prompt_id"), answer); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- helpers ---
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// sessionText returns the lower-cased text of a session's messages.
+func sessionText(session *sdk.Session) string {
+	var sb strings.Builder
+	for _, msg := range session.GetHistory() {
+		for _, part := range msg.Parts {
+			if part.Text != "" {
+				sb.WriteString(strings.ToLower(part.Text))
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func intParam(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n < 0 {
+		err = errors.New("must not be negative")
+	}
+	return n, err
+}
+
+func timeParam(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
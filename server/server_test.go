@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+func TestSessionIDTraversalRejected(t *testing.T) {
+	root := t.TempDir()
+	storeDir := filepath.Join(root, "sessions")
+	srv := New(WithSessionStore(sdk.NewSessionStore(storeDir)))
+
+	for _, tc := range []struct {
+		method, path, body string
+	}{
+		{"POST", "/v1/sessions", `{"id": "../../escaped"}`},
+		{"POST", "/v1/sessions", `{"id": "a/b"}`},
+		{"GET", "/v1/sessions/..%2F..%2Fescaped", ""},
+		{"POST", "/v1/runs", `{"agent": "a", "message": "hi", "session_id": "../escaped"}`},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: status %d, want %d", tc.method, tc.path, tc.body, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escaped.json")); !os.IsNotExist(err) {
+		t.Errorf("session file was written outside the store: %v", err)
+	}
+}
+
+func TestSessionIDValid(t *testing.T) {
+	srv := New(WithSessionStore(sdk.NewSessionStore(t.TempDir())))
+
+	req := httptest.NewRequest("POST", "/v1/sessions", strings.NewReader(`{"id": "demo_session-1"}`))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/sessions/demo_session-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: status %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestFileBackendRejectsTraversal(t *testing.T) {
+	backend := sdk.NewFileSessionBackend(t.TempDir())
+	rec := &sdk.SessionRecord{SessionMeta: sdk.SessionMeta{ID: "../escaped"}}
+	if err := backend.Put(rec, -1); err == nil {
+		t.Error("Put accepted a traversal id")
+	}
+	if _, err := backend.Get("../escaped"); err == nil {
+		t.Error("Get accepted a traversal id")
+	}
+}
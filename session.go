@@ -13,17 +13,29 @@ import (
 type Session struct {
 	id        string
 	history   []*genai.Content
+	times     []time.Time // when each history entry was added
 	version   atomic.Int64
 	createdAt time.Time
+	updatedAt time.Time
 	mu        sync.RWMutex
 
-	maxMessages  int
-	onChange     func(event ChangeEvent)
+	maxMessages int
+	onChange    func(event ChangeEvent)
+
+	// Persistence metadata
+	title        string
+	tags         []string
+	savedVersion int64      // version last loaded from or saved to a store
+	saveMu       sync.Mutex // serializes saves of this session
+
+	// Branching; the active branch's history lives in history
+	branch   string
+	branches map[string]*sessionBranch
 }
 
 // ChangeEvent describes a change to the session history.
 type ChangeEvent struct {
-	Type    string // "add", "clear", "replace", "restore"
+	Type    string // "add", "clear", "replace", "restore", "fork", "checkout", "rewind", "delete_branch"
 	Version int64
 }
 
@@ -32,11 +44,15 @@ func NewSession(id string) *Session {
 	if id == "" {
 		id = generateID()
 	}
+	now := time.Now()
 	return &Session{
 		id:          id,
 		history:     make([]*genai.Content, 0),
-		createdAt:   time.Now(),
+		createdAt:   now,
+		updatedAt:   now,
 		maxMessages: 100,
+		branch:      DefaultBranch,
+		branches:    map[string]*sessionBranch{DefaultBranch: {createdAt: now}},
 	}
 }
 
@@ -51,9 +67,8 @@ func (s *Session) AddUserMessage(msg string) {
 	defer s.mu.Unlock()
 
 	content := genai.NewContentFromText(msg, "user")
-	s.history = append(s.history, content)
-	s.trimLocked()
-	s.version.Add(1)
+	s.appendLocked(content)
+	s.changedLocked()
 
 	if s.onChange != nil {
 		go s.onChange(ChangeEvent{Type: "add", Version: s.version.Load()})
@@ -68,9 +83,8 @@ func (s *Session) AddModelResponse(content *genai.Content) {
 	if content.Role == "" {
 		content.Role = "model"
 	}
-	s.history = append(s.history, content)
-	s.trimLocked()
-	s.version.Add(1)
+	s.appendLocked(content)
+	s.changedLocked()
 
 	if s.onChange != nil {
 		go s.onChange(ChangeEvent{Type: "add", Version: s.version.Load()})
@@ -82,9 +96,8 @@ func (s *Session) AddContent(content *genai.Content) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.history = append(s.history, content)
-	s.trimLocked()
-	s.version.Add(1)
+	s.appendLocked(content)
+	s.changedLocked()
 
 	if s.onChange != nil {
 		go s.onChange(ChangeEvent{Type: "add", Version: s.version.Load()})
@@ -119,7 +132,8 @@ func (s *Session) Clear() {
 	defer s.mu.Unlock()
 
 	s.history = make([]*genai.Content, 0)
-	s.version.Add(1)
+	s.times = nil
+	s.changedLocked()
 
 	if s.onChange != nil {
 		go s.onChange(ChangeEvent{Type: "clear", Version: s.version.Load()})
@@ -134,7 +148,9 @@ func (s *Session) ReplaceWithSummary(summary *genai.Content, recentMessages []*g
 	s.history = make([]*genai.Content, 0, len(recentMessages)+1)
 	s.history = append(s.history, summary)
 	s.history = append(s.history, recentMessages...)
-	s.version.Add(1)
+	s.times = s.times[len(s.times)-min(len(s.times), len(recentMessages)):]
+	s.times = append([]time.Time{time.Now()}, s.times...)
+	s.changedLocked()
 
 	if s.onChange != nil {
 		go s.onChange(ChangeEvent{Type: "replace", Version: s.version.Load()})
@@ -157,11 +173,48 @@ func (s *Session) SetMaxMessages(n int) {
 	}
 }
 
+// Title returns the session's title, or "" if none was set.
+func (s *Session) Title() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.title
+}
+
+// SetTitle sets the title stored with the session.
+func (s *Session) SetTitle(title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.title = title
+	s.changedLocked()
+}
+
+// Tags returns a copy of the session's tags.
+func (s *Session) Tags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.tags...)
+}
+
+// SetTags replaces the tags stored with the session.
+func (s *Session) SetTags(tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags = append([]string(nil), tags...)
+	s.changedLocked()
+}
+
 // CreatedAt returns when the session was created.
 func (s *Session) CreatedAt() time.Time {
 	return s.createdAt
 }
 
+// UpdatedAt returns when the session was last changed.
+func (s *Session) UpdatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updatedAt
+}
+
 // Summary returns a brief text summary of the session.
 func (s *Session) Summary() string {
 	s.mu.RLock()
@@ -189,6 +242,18 @@ func (s *Session) Summary() string {
 	return fmt.Sprintf("(%d messages)", len(s.history))
 }
 
+// changedLocked records a modification of the session.
+func (s *Session) changedLocked() {
+	s.version.Add(1)
+	s.updatedAt = time.Now()
+}
+
+func (s *Session) appendLocked(content *genai.Content) {
+	s.history = append(s.history, content)
+	s.times = append(s.times, time.Now())
+	s.trimLocked()
+}
+
 func (s *Session) trimLocked() {
 	if s.maxMessages <= 0 || len(s.history) <= s.maxMessages {
 		return
@@ -196,4 +261,5 @@ func (s *Session) trimLocked() {
 	// Keep most recent messages
 	excess := len(s.history) - s.maxMessages
 	s.history = s.history[excess:]
+	s.times = s.times[min(excess, len(s.times)):]
 }
@@ -0,0 +1,408 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSessionNotFound is returned when a session does not exist in a backend.
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrSessionConflict is returned when a session was changed in the
+	// backend since it was loaded.
+	ErrSessionConflict = errors.New("session version conflict")
+
+	// ErrInvalidSessionID is returned for session IDs rejected by
+	// ValidateSessionID.
+	ErrInvalidSessionID = errors.New("invalid session id")
+)
+
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// ValidateSessionID checks that id is 1 to 128 ASCII letters, digits, '-'
+// or '_'. Backends use IDs as file names and keys, so anything else, such
+// as a path like "../x", is rejected with ErrInvalidSessionID.
+func ValidateSessionID(id string) error {
+	if !sessionIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidSessionID, id)
+	}
+	return nil
+}
+
+// SessionMeta describes a stored session without its messages.
+type SessionMeta struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+	MessageCount int       `json:"message_count,omitempty"`
+	Version      int64     `json:"version,omitempty"`
+}
+
+// SessionRecord is the stored form of a session. Messages is encoded by
+// SessionStore and opaque to backends; Text holds the searchable text of
+// all messages and is not part of the encoded record.
+type SessionRecord struct {
+	SessionMeta
+	Messages json.RawMessage `json:"messages"`
+	Branches json.RawMessage `json:"branches,omitempty"` // branch tree, encoded by SessionStore
+	State    json.RawMessage `json:"state,omitempty"`    // application state stored with the session
+	Text     string          `json:"-"`
+}
+
+// SessionQuery filters and pages stored sessions. Results are ordered by
+// most recently updated first.
+type SessionQuery struct {
+	Tags          []string  // Sessions must carry all of these tags
+	Text          string    // Case-insensitive substring of title or message text
+	UpdatedAfter  time.Time // Zero means no lower bound
+	UpdatedBefore time.Time // Zero means no upper bound
+	Offset        int
+	Limit         int // 0 means no limit
+}
+
+// SessionPage is one page of query results.
+type SessionPage struct {
+	Sessions   []SessionMeta `json:"sessions"`
+	Total      int           `json:"total"`
+	NextOffset int           `json:"next_offset,omitempty"` // 0 when there are no more results
+}
+
+// SessionBackend stores session records. Implementations must be safe for
+// concurrent use.
+type SessionBackend interface {
+	// Put stores rec if the stored version equals expectedVersion (0 when
+	// the session does not exist yet); otherwise it returns
+	// ErrSessionConflict. A negative expectedVersion skips the check.
+	Put(rec *SessionRecord, expectedVersion int64) error
+
+	// Get returns the record for id, or ErrSessionNotFound.
+	Get(id string) (*SessionRecord, error)
+
+	// Delete removes id. Deleting a missing session is not an error.
+	Delete(id string) error
+
+	// Query returns the sessions matching q.
+	Query(q SessionQuery) (*SessionPage, error)
+
+	// Close releases the backend's resources.
+	Close() error
+}
+
+// Match reports whether a session matches the query's filters. text is the
+// session's searchable text, already lower-cased.
+func (q SessionQuery) Match(meta *SessionMeta, text string) bool {
+	if !q.UpdatedAfter.IsZero() && !meta.UpdatedAt.After(q.UpdatedAfter) {
+		return false
+	}
+	if !q.UpdatedBefore.IsZero() && !meta.UpdatedAt.Before(q.UpdatedBefore) {
+		return false
+	}
+	for _, want := range q.Tags {
+		found := false
+		for _, tag := range meta.Tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Text != "" {
+		needle := strings.ToLower(q.Text)
+		if !strings.Contains(text, needle) && !strings.Contains(strings.ToLower(meta.Title), needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// Page sorts matching sessions by update time and applies Offset and Limit.
+func (q SessionQuery) Page(matches []SessionMeta) *SessionPage {
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].UpdatedAt.Equal(matches[j].UpdatedAt) {
+			return matches[i].UpdatedAt.After(matches[j].UpdatedAt)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	page := &SessionPage{Total: len(matches), Sessions: []SessionMeta{}}
+	if q.Offset >= len(matches) {
+		return page
+	}
+	end := len(matches)
+	if q.Limit > 0 && q.Offset+q.Limit < end {
+		end = q.Offset + q.Limit
+		page.NextOffset = end
+	}
+	page.Sessions = matches[q.Offset:end]
+	return page
+}
+
+func checkSessionVersion(id string, stored, expected int64) error {
+	if expected >= 0 && stored != expected {
+		return fmt.Errorf("%w: session %s is at version %d, expected %d", ErrSessionConflict, id, stored, expected)
+	}
+	return nil
+}
+
+// --- In-memory backend ---
+
+// MemorySessionBackend keeps sessions in memory. It is useful for tests and
+// for servers that do not need persistence.
+type MemorySessionBackend struct {
+	records map[string]*SessionRecord
+	mu      sync.RWMutex
+}
+
+// NewMemorySessionBackend creates an empty in-memory backend.
+func NewMemorySessionBackend() *MemorySessionBackend {
+	return &MemorySessionBackend{records: make(map[string]*SessionRecord)}
+}
+
+func (b *MemorySessionBackend) Put(rec *SessionRecord, expectedVersion int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var stored int64
+	if old, ok := b.records[rec.ID]; ok {
+		stored = old.Version
+	}
+	if err := checkSessionVersion(rec.ID, stored, expectedVersion); err != nil {
+		return err
+	}
+	cp := copySessionRecord(rec)
+	cp.Text = strings.ToLower(rec.Text)
+	b.records[rec.ID] = cp
+	return nil
+}
+
+func (b *MemorySessionBackend) Get(id string) (*SessionRecord, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	rec, ok := b.records[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return copySessionRecord(rec), nil
+}
+
+func (b *MemorySessionBackend) Delete(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.records, id)
+	return nil
+}
+
+func (b *MemorySessionBackend) Query(q SessionQuery) (*SessionPage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var matches []SessionMeta
+	for _, rec := range b.records {
+		if q.Match(&rec.SessionMeta, rec.Text) {
+			matches = append(matches, copySessionMeta(rec.SessionMeta))
+		}
+	}
+	return q.Page(matches), nil
+}
+
+func (b *MemorySessionBackend) Close() error { return nil }
+
+func copySessionMeta(m SessionMeta) SessionMeta {
+	m.Tags = append([]string(nil), m.Tags...)
+	return m
+}
+
+func copySessionRecord(rec *SessionRecord) *SessionRecord {
+	return &SessionRecord{
+		SessionMeta: copySessionMeta(rec.SessionMeta),
+		Messages:    append(json.RawMessage(nil), rec.Messages...),
+		Branches:    append(json.RawMessage(nil), rec.Branches...),
+		State:       append(json.RawMessage(nil), rec.State...),
+		Text:        rec.Text,
+	}
+}
+
+// --- Filesystem backend ---
+
+// FileSessionBackend stores one JSON file per session in a directory, the
+// format SessionStore has always used. Metadata and search text are indexed
+// in memory on the first query, so the directory is scanned only once.
+type FileSessionBackend struct {
+	dir   string
+	index map[string]*fileSessionEntry // nil until the first query
+	mu    sync.Mutex
+}
+
+type fileSessionEntry struct {
+	meta SessionMeta
+	text string // lower-cased
+}
+
+// NewFileSessionBackend creates a backend that stores sessions in dir.
+func NewFileSessionBackend(dir string) *FileSessionBackend {
+	return &FileSessionBackend{dir: dir}
+}
+
+func (b *FileSessionBackend) path(id string) string {
+	return filepath.Join(b.dir, id+".json")
+}
+
+func (b *FileSessionBackend) Put(rec *SessionRecord, expectedVersion int64) error {
+	if err := ValidateSessionID(rec.ID); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var stored int64
+	if entry, ok := b.index[rec.ID]; ok {
+		stored = entry.meta.Version
+	} else if b.index == nil {
+		old, err := b.read(rec.ID)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+		if old != nil {
+			stored = old.Version
+		}
+	}
+	if err := checkSessionVersion(rec.ID, stored, expectedVersion); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(b.dir, 0700); err != nil {
+		return fmt.Errorf("creating session directory: %w", err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling session: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := b.path(rec.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing session file: %w", err)
+	}
+	if err := os.Rename(tmp, b.path(rec.ID)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing session file: %w", err)
+	}
+
+	if b.index != nil {
+		b.index[rec.ID] = &fileSessionEntry{meta: copySessionMeta(rec.SessionMeta), text: strings.ToLower(rec.Text)}
+	}
+	return nil
+}
+
+func (b *FileSessionBackend) Get(id string) (*SessionRecord, error) {
+	if err := ValidateSessionID(id); err != nil {
+		return nil, err
+	}
+	return b.read(id)
+}
+
+// read loads a session file, filling in metadata missing from files
+// written by older versions.
+func (b *FileSessionBackend) read(id string) (*SessionRecord, error) {
+	data, err := os.ReadFile(b.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+		}
+		return nil, fmt.Errorf("reading session file: %w", err)
+	}
+
+	var rec SessionRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("unmarshaling session: %w", err)
+	}
+	messages, err := decodeSessionMessages(rec.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling session: %w", err)
+	}
+	if rec.MessageCount == 0 {
+		rec.MessageCount = len(messages)
+	}
+	if rec.Title == "" {
+		rec.Title = defaultSessionTitle(messages)
+	}
+	if rec.UpdatedAt.IsZero() {
+		if info, err := os.Stat(b.path(id)); err == nil {
+			rec.UpdatedAt = info.ModTime()
+		}
+	}
+	rec.Text = sessionMessagesText(messages)
+	return &rec, nil
+}
+
+func (b *FileSessionBackend) Delete(id string) error {
+	if err := ValidateSessionID(id); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := os.Remove(b.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	delete(b.index, id)
+	return nil
+}
+
+func (b *FileSessionBackend) Query(q SessionQuery) (*SessionPage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.loadIndexLocked(); err != nil {
+		return nil, err
+	}
+
+	var matches []SessionMeta
+	for _, entry := range b.index {
+		if q.Match(&entry.meta, entry.text) {
+			matches = append(matches, copySessionMeta(entry.meta))
+		}
+	}
+	return q.Page(matches), nil
+}
+
+// loadIndexLocked scans the directory once to build the in-memory index.
+func (b *FileSessionBackend) loadIndexLocked() error {
+	if b.index != nil {
+		return nil
+	}
+	index := make(map[string]*fileSessionEntry)
+
+	entries, err := os.ReadDir(b.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading session directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		rec, err := b.read(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue // Skip unreadable files
+		}
+		index[rec.ID] = &fileSessionEntry{meta: rec.SessionMeta, text: strings.ToLower(rec.Text)}
+	}
+	b.index = index
+	return nil
+}
+
+func (b *FileSessionBackend) Close() error { return nil }
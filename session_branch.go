@@ -0,0 +1,301 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/genai"
+
+	"github.com/ginkida/gokin-sdk/undo"
+)
+
+// DefaultBranch is the name of a session's initial branch.
+const DefaultBranch = "main"
+
+// sessionBranch is a branch of a session's conversation. The active
+// branch's messages live in Session.history; inactive branches keep theirs
+// here.
+type sessionBranch struct {
+	parent    string
+	forkIndex int // messages shared with the parent when forked
+	createdAt time.Time
+	history   []*genai.Content
+	times     []time.Time
+}
+
+// BranchInfo describes a branch of a session.
+type BranchInfo struct {
+	Name      string    `json:"name"`
+	Parent    string    `json:"parent,omitempty"`
+	ForkIndex int       `json:"fork_index"`
+	Messages  int       `json:"messages"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+// BranchOption configures Fork and Rewind.
+type BranchOption func(*branchOptions)
+
+type branchOptions struct {
+	name string
+	undo *undo.Manager
+}
+
+// WithBranchName names the branch created by Fork. Without it, branches
+// are named "branch-1", "branch-2" and so on.
+func WithBranchName(name string) BranchOption {
+	return func(o *branchOptions) {
+		o.name = name
+	}
+}
+
+// WithFileRestore also reverts the file changes recorded in m after the
+// fork or rewind point, so the workspace matches the conversation. The
+// reverted changes stay on m's redo stack.
+func WithFileRestore(m *undo.Manager) BranchOption {
+	return func(o *branchOptions) {
+		o.undo = m
+	}
+}
+
+// Fork starts a new branch from the first atIndex messages of the current
+// branch and makes it current. The original branch is kept unchanged and
+// can be returned to with Checkout. It returns the new branch's name.
+func (s *Session) Fork(atIndex int, opts ...BranchOption) (string, error) {
+	var o branchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.mu.Lock()
+	if atIndex < 0 || atIndex > len(s.history) {
+		s.mu.Unlock()
+		return "", fmt.Errorf("fork index %d out of range [0, %d]", atIndex, len(s.history))
+	}
+	name := o.name
+	if name == "" {
+		name = s.nextBranchNameLocked()
+	}
+	if _, exists := s.branches[name]; exists {
+		s.mu.Unlock()
+		return "", fmt.Errorf("branch already exists: %s", name)
+	}
+	forkTime := s.pointTimeLocked(atIndex)
+	s.mu.Unlock()
+
+	if o.undo != nil {
+		if _, err := o.undo.UndoSince(forkTime); err != nil {
+			return "", fmt.Errorf("restoring files: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if atIndex > len(s.history) {
+		return "", fmt.Errorf("fork index %d out of range [0, %d]", atIndex, len(s.history))
+	}
+	if _, exists := s.branches[name]; exists {
+		return "", fmt.Errorf("branch already exists: %s", name)
+	}
+
+	history := make([]*genai.Content, atIndex)
+	copy(history, s.history)
+	times := make([]time.Time, 0, atIndex)
+	times = append(times, s.times[:min(atIndex, len(s.times))]...)
+
+	s.stashLocked()
+	s.branches[name] = &sessionBranch{
+		parent:    s.branch,
+		forkIndex: atIndex,
+		createdAt: time.Now(),
+	}
+	s.branch = name
+	s.history = history
+	s.times = times
+	s.changedLocked()
+
+	if s.onChange != nil {
+		go s.onChange(ChangeEvent{Type: "fork", Version: s.version.Load()})
+	}
+	return name, nil
+}
+
+// Rewind drops the last n messages of the current branch. Fork first to
+// keep them.
+func (s *Session) Rewind(n int, opts ...BranchOption) error {
+	var o branchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.mu.Lock()
+	if n < 0 || n > len(s.history) {
+		s.mu.Unlock()
+		return fmt.Errorf("cannot rewind %d of %d messages", n, len(s.history))
+	}
+	keep := len(s.history) - n
+	rewindTime := s.pointTimeLocked(keep)
+	s.mu.Unlock()
+
+	if o.undo != nil {
+		if _, err := o.undo.UndoSince(rewindTime); err != nil {
+			return fmt.Errorf("restoring files: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep = min(keep, len(s.history))
+	s.history = s.history[:keep:keep]
+	s.times = s.times[:min(keep, len(s.times))]
+	s.changedLocked()
+
+	if s.onChange != nil {
+		go s.onChange(ChangeEvent{Type: "rewind", Version: s.version.Load()})
+	}
+	return nil
+}
+
+// Checkout makes the named branch current.
+func (s *Session) Checkout(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == s.branch {
+		return nil
+	}
+	target, ok := s.branches[name]
+	if !ok {
+		return fmt.Errorf("branch not found: %s", name)
+	}
+
+	s.stashLocked()
+	s.branch = name
+	s.history = target.history
+	s.times = target.times
+	target.history = nil
+	target.times = nil
+	if s.history == nil {
+		s.history = make([]*genai.Content, 0)
+	}
+	s.changedLocked()
+
+	if s.onChange != nil {
+		go s.onChange(ChangeEvent{Type: "checkout", Version: s.version.Load()})
+	}
+	return nil
+}
+
+// CurrentBranch returns the name of the current branch.
+func (s *Session) CurrentBranch() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.branch
+}
+
+// Branches returns the session's branches, oldest first.
+func (s *Session) Branches() []BranchInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]BranchInfo, 0, len(s.branches))
+	for name, b := range s.branches {
+		info := BranchInfo{
+			Name:      name,
+			Parent:    b.parent,
+			ForkIndex: b.forkIndex,
+			Messages:  len(b.history),
+			CreatedAt: b.createdAt,
+			Current:   name == s.branch,
+		}
+		if info.Current {
+			info.Messages = len(s.history)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].CreatedAt.Equal(infos[j].CreatedAt) {
+			return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// BranchHistory returns a copy of the named branch's history.
+func (s *Session) BranchHistory(name string) ([]*genai.Content, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if name == s.branch {
+		return append([]*genai.Content(nil), s.history...), nil
+	}
+	b, ok := s.branches[name]
+	if !ok {
+		return nil, fmt.Errorf("branch not found: %s", name)
+	}
+	return append([]*genai.Content(nil), b.history...), nil
+}
+
+// DeleteBranch removes a branch other than the current one. Branches
+// forked from it are re-parented to its parent.
+func (s *Session) DeleteBranch(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == s.branch {
+		return fmt.Errorf("cannot delete the current branch: %s", name)
+	}
+	b, ok := s.branches[name]
+	if !ok {
+		return fmt.Errorf("branch not found: %s", name)
+	}
+	for _, child := range s.branches {
+		if child.parent == name {
+			child.parent = b.parent
+			child.forkIndex = min(child.forkIndex, b.forkIndex)
+		}
+	}
+	delete(s.branches, name)
+	s.changedLocked()
+
+	if s.onChange != nil {
+		go s.onChange(ChangeEvent{Type: "delete_branch", Version: s.version.Load()})
+	}
+	return nil
+}
+
+// stashLocked moves the active history into its branch entry.
+func (s *Session) stashLocked() {
+	current, ok := s.branches[s.branch]
+	if !ok {
+		current = &sessionBranch{createdAt: s.createdAt}
+		s.branches[s.branch] = current
+	}
+	current.history = s.history
+	current.times = s.times
+}
+
+// pointTimeLocked returns when the message before index i was added, the
+// moment the conversation stood at i messages.
+func (s *Session) pointTimeLocked(i int) time.Time {
+	if i == 0 || len(s.times) == 0 {
+		return s.createdAt
+	}
+	// times may be shorter than history after a summary replacement
+	i -= len(s.history) - len(s.times)
+	if i <= 0 {
+		return s.times[0]
+	}
+	return s.times[i-1]
+}
+
+func (s *Session) nextBranchNameLocked() string {
+	for n := len(s.branches); ; n++ {
+		name := fmt.Sprintf("branch-%d", n)
+		if _, exists := s.branches[name]; !exists {
+			return name
+		}
+	}
+}
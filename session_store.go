@@ -3,34 +3,50 @@ package sdk
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"google.golang.org/genai"
 )
 
-// SessionStore provides file-based persistence for sessions.
+// SessionStore persists sessions in a SessionBackend.
 type SessionStore struct {
-	dir string
+	backend SessionBackend
 }
 
 // NewSessionStore creates a new store that saves sessions in the given directory.
 func NewSessionStore(dir string) *SessionStore {
-	return &SessionStore{dir: dir}
+	return &SessionStore{backend: NewFileSessionBackend(dir)}
 }
 
-// sessionState is the JSON-serializable session format.
-type sessionState struct {
-	ID        string             `json:"id"`
-	CreatedAt time.Time          `json:"created_at"`
-	Messages  []serializedContent `json:"messages"`
+// NewSessionStoreWithBackend creates a store on top of the given backend.
+func NewSessionStoreWithBackend(backend SessionBackend) *SessionStore {
+	return &SessionStore{backend: backend}
+}
+
+// maxTitleLength bounds titles derived from the first user message.
+const maxTitleLength = 80
+
+// serializedBranches is the stored branch tree. The current branch's
+// messages are the record's messages and are not repeated here.
+type serializedBranches struct {
+	Current  string             `json:"current"`
+	Branches []serializedBranch `json:"branches"`
+}
+
+type serializedBranch struct {
+	Name      string              `json:"name"`
+	Parent    string              `json:"parent,omitempty"`
+	ForkIndex int                 `json:"fork_index"`
+	CreatedAt time.Time           `json:"created_at"`
+	Messages  []serializedContent `json:"messages,omitempty"`
 }
 
 type serializedContent struct {
 	Role  string           `json:"role"`
 	Parts []serializedPart `json:"parts"`
+	Time  time.Time        `json:"time"` // when the message was added
 }
 
 type serializedPart struct {
@@ -42,97 +58,249 @@ type serializedPart struct {
 	FunctionResponse map[string]any `json:"function_response,omitempty"`
 }
 
-// Save persists a session to disk.
+// Save persists a session. It fails with ErrSessionConflict if the stored
+// copy was changed by someone else since this session was loaded or last
+// saved.
 func (ss *SessionStore) Save(session *Session) error {
-	if err := os.MkdirAll(ss.dir, 0700); err != nil {
-		return fmt.Errorf("creating session directory: %w", err)
-	}
+	session.saveMu.Lock()
+	defer session.saveMu.Unlock()
 
-	state := sessionState{
-		ID:        session.ID(),
-		CreatedAt: session.CreatedAt(),
-	}
+	session.mu.RLock()
+	messages := serializeHistory(session.history, session.times)
+	version := session.version.Load()
+	updatedAt := session.updatedAt
+	expected := session.savedVersion
+	title := session.title
+	tags := append([]string(nil), session.tags...)
+	branches := serializeBranchesLocked(session)
+	session.mu.RUnlock()
 
-	history := session.GetHistory()
-	for _, msg := range history {
-		sc := serializeContent(msg)
-		state.Messages = append(state.Messages, sc)
-	}
-
-	data, err := json.MarshalIndent(state, "", "  ")
+	data, err := json.Marshal(messages)
 	if err != nil {
 		return fmt.Errorf("marshaling session: %w", err)
 	}
 
-	path := filepath.Join(ss.dir, session.ID()+".json")
-	if err := os.WriteFile(path, data, 0600); err != nil {
-		return fmt.Errorf("writing session file: %w", err)
+	if title == "" {
+		title = defaultSessionTitle(messages)
+	}
+	rec := &SessionRecord{
+		SessionMeta: SessionMeta{
+			ID:           session.ID(),
+			Title:        title,
+			Tags:         tags,
+			CreatedAt:    session.CreatedAt(),
+			UpdatedAt:    updatedAt,
+			MessageCount: len(messages),
+			Version:      version,
+		},
+		Messages: data,
+		Text:     sessionMessagesText(messages),
+	}
+	if branches != nil {
+		if rec.Branches, err = json.Marshal(branches); err != nil {
+			return fmt.Errorf("marshaling session branches: %w", err)
+		}
+	}
+	if err := ss.backend.Put(rec, expected); err != nil {
+		return err
 	}
 
+	session.mu.Lock()
+	session.savedVersion = version
+	session.mu.Unlock()
 	return nil
 }
 
-// Load reads a session from disk.
+// Load reads a session from the store.
 func (ss *SessionStore) Load(id string) (*Session, error) {
-	path := filepath.Join(ss.dir, id+".json")
-	data, err := os.ReadFile(path)
+	rec, err := ss.backend.Get(id)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("session not found: %s", id)
-		}
-		return nil, fmt.Errorf("reading session file: %w", err)
+		return nil, err
 	}
-
-	var state sessionState
-	if err := json.Unmarshal(data, &state); err != nil {
+	messages, err := decodeSessionMessages(rec.Messages)
+	if err != nil {
 		return nil, fmt.Errorf("unmarshaling session: %w", err)
 	}
 
-	session := NewSession(state.ID)
-	session.createdAt = state.CreatedAt
+	session := NewSession(rec.ID)
+	session.createdAt = rec.CreatedAt
 
-	for _, sc := range state.Messages {
-		content := deserializeContent(sc)
-		session.AddContent(content)
+	session.mu.Lock()
+	session.history, session.times = deserializeHistory(messages, rec.UpdatedAt)
+	session.trimLocked()
+	if len(rec.Branches) > 0 {
+		var branches serializedBranches
+		if err := json.Unmarshal(rec.Branches, &branches); err != nil {
+			session.mu.Unlock()
+			return nil, fmt.Errorf("unmarshaling session branches: %w", err)
+		}
+		restoreBranchesLocked(session, &branches)
+	}
+	session.title = rec.Title
+	session.tags = rec.Tags
+	if !rec.UpdatedAt.IsZero() {
+		session.updatedAt = rec.UpdatedAt
 	}
+	session.version.Store(rec.Version)
+	session.savedVersion = rec.Version
+	session.mu.Unlock()
 
 	return session, nil
 }
 
 // List returns all saved session IDs.
 func (ss *SessionStore) List() ([]string, error) {
-	entries, err := os.ReadDir(ss.dir)
+	page, err := ss.backend.Query(SessionQuery{})
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("reading session directory: %w", err)
+		return nil, err
 	}
 
 	var ids []string
-	for _, entry := range entries {
-		if entry.IsDir() {
+	for _, meta := range page.Sessions {
+		ids = append(ids, meta.ID)
+	}
+
+	return ids, nil
+}
+
+// Query returns metadata for the saved sessions matching q.
+func (ss *SessionStore) Query(q SessionQuery) (*SessionPage, error) {
+	return ss.backend.Query(q)
+}
+
+// Delete removes a session from the store.
+func (ss *SessionStore) Delete(id string) error {
+	return ss.backend.Delete(id)
+}
+
+// Close closes the underlying backend.
+func (ss *SessionStore) Close() error {
+	return ss.backend.Close()
+}
+
+// serializeBranchesLocked encodes the session's branch tree, or returns nil
+// when the session has never been forked.
+func serializeBranchesLocked(session *Session) *serializedBranches {
+	if session.branch == DefaultBranch && len(session.branches) <= 1 {
+		return nil
+	}
+	out := &serializedBranches{Current: session.branch}
+	for name, b := range session.branches {
+		sb := serializedBranch{
+			Name:      name,
+			Parent:    b.parent,
+			ForkIndex: b.forkIndex,
+			CreatedAt: b.createdAt,
+		}
+		if name != session.branch && len(b.history) > 0 {
+			sb.Messages = serializeHistory(b.history, b.times)
+		}
+		out.Branches = append(out.Branches, sb)
+	}
+	sort.Slice(out.Branches, func(i, j int) bool {
+		return out.Branches[i].CreatedAt.Before(out.Branches[j].CreatedAt)
+	})
+	return out
+}
+
+// restoreBranchesLocked rebuilds the branch tree of a freshly loaded
+// session whose history holds the current branch.
+func restoreBranchesLocked(session *Session, branches *serializedBranches) {
+	session.branches = make(map[string]*sessionBranch, len(branches.Branches))
+	for _, sb := range branches.Branches {
+		b := &sessionBranch{
+			parent:    sb.Parent,
+			forkIndex: sb.ForkIndex,
+			createdAt: sb.CreatedAt,
+		}
+		if sb.Name != branches.Current {
+			b.history, b.times = deserializeHistory(sb.Messages, sb.CreatedAt)
+		}
+		session.branches[sb.Name] = b
+	}
+	session.branch = branches.Current
+	if _, ok := session.branches[session.branch]; !ok {
+		session.branches[session.branch] = &sessionBranch{createdAt: session.createdAt}
+	}
+}
+
+// defaultSessionTitle derives a title from the first user text message.
+func defaultSessionTitle(messages []serializedContent) string {
+	for _, msg := range messages {
+		if msg.Role != "user" {
 			continue
 		}
-		name := entry.Name()
-		if strings.HasSuffix(name, ".json") {
-			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		for _, part := range msg.Parts {
+			if part.Type != "text" {
+				continue
+			}
+			text := strings.Join(strings.Fields(part.Text), " ")
+			if text == "" {
+				continue
+			}
+			if runes := []rune(text); len(runes) > maxTitleLength {
+				text = string(runes[:maxTitleLength-3]) + "..."
+			}
+			return text
 		}
 	}
+	return ""
+}
 
-	return ids, nil
+// sessionMessagesText joins the text parts of all messages for search.
+func sessionMessagesText(messages []serializedContent) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type == "text" && strings.TrimSpace(part.Text) != "" {
+				sb.WriteString(part.Text)
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return sb.String()
 }
 
-// Delete removes a session from disk.
-func (ss *SessionStore) Delete(id string) error {
-	path := filepath.Join(ss.dir, id+".json")
-	if err := os.Remove(path); err != nil {
-		if os.IsNotExist(err) {
-			return nil
+func decodeSessionMessages(data json.RawMessage) ([]serializedContent, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var messages []serializedContent
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// serializeHistory encodes messages with the times they were added. times
+// lines up with the end of history and may be shorter.
+func serializeHistory(history []*genai.Content, times []time.Time) []serializedContent {
+	messages := make([]serializedContent, 0, len(history))
+	offset := len(history) - len(times)
+	for i, msg := range history {
+		sc := serializeContent(msg)
+		if i >= offset {
+			sc.Time = times[i-offset]
 		}
-		return fmt.Errorf("deleting session: %w", err)
+		messages = append(messages, sc)
 	}
-	return nil
+	return messages
+}
+
+// deserializeHistory decodes messages and the times they were added.
+// Messages stored without a time, as written by older versions, are dated
+// fallback.
+func deserializeHistory(messages []serializedContent, fallback time.Time) ([]*genai.Content, []time.Time) {
+	history := make([]*genai.Content, 0, len(messages))
+	times := make([]time.Time, 0, len(messages))
+	for _, sc := range messages {
+		history = append(history, deserializeContent(sc))
+		if sc.Time.IsZero() {
+			sc.Time = fallback
+		}
+		times = append(times, sc.Time)
+	}
+	return history, times
 }
 
 func serializeContent(content *genai.Content) serializedContent {
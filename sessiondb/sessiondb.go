@@ -0,0 +1,185 @@
+// Package sessiondb provides a single-file embedded session backend built
+// on bbolt.
+//
+//	db, err := sessiondb.Open("sessions.db")
+//	if err != nil {
+//		return err
+//	}
+//	store := sdk.NewSessionStoreWithBackend(db)
+//	defer store.Close()
+package sessiondb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+var (
+	bucketMeta     = []byte("meta")
+	bucketMessages = []byte("messages")
+	bucketBranches = []byte("branches")
+	bucketState    = []byte("state")
+	bucketText     = []byte("text")
+)
+
+var buckets = [][]byte{bucketMeta, bucketMessages, bucketBranches, bucketState, bucketText}
+
+// DB is a sdk.SessionBackend stored in a single bbolt file. Metadata is
+// kept apart from messages so queries never decode message bodies.
+type DB struct {
+	db *bolt.DB
+}
+
+var _ sdk.SessionBackend = (*DB)(nil)
+
+// Open opens or creates the database file at path.
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening session database: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing session database: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Put stores rec if its stored version matches expectedVersion.
+func (d *DB) Put(rec *sdk.SessionRecord, expectedVersion int64) error {
+	if err := sdk.ValidateSessionID(rec.ID); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(rec.SessionMeta)
+	if err != nil {
+		return fmt.Errorf("marshaling session: %w", err)
+	}
+	key := []byte(rec.ID)
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		var stored int64
+		if data := tx.Bucket(bucketMeta).Get(key); data != nil {
+			var old sdk.SessionMeta
+			if err := json.Unmarshal(data, &old); err != nil {
+				return fmt.Errorf("unmarshaling session: %w", err)
+			}
+			stored = old.Version
+		}
+		if expectedVersion >= 0 && stored != expectedVersion {
+			return fmt.Errorf("%w: session %s is at version %d, expected %d",
+				sdk.ErrSessionConflict, rec.ID, stored, expectedVersion)
+		}
+
+		if err := tx.Bucket(bucketMeta).Put(key, meta); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketMessages).Put(key, rec.Messages); err != nil {
+			return err
+		}
+		if err := putOptional(tx.Bucket(bucketBranches), key, rec.Branches); err != nil {
+			return err
+		}
+		if err := putOptional(tx.Bucket(bucketState), key, rec.State); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketText).Put(key, []byte(strings.ToLower(rec.Text)))
+	})
+}
+
+// putOptional stores value under key, or removes key when value is empty.
+func putOptional(b *bolt.Bucket, key []byte, value []byte) error {
+	if len(value) == 0 {
+		return b.Delete(key)
+	}
+	return b.Put(key, value)
+}
+
+// Get returns the record for id.
+func (d *DB) Get(id string) (*sdk.SessionRecord, error) {
+	if err := sdk.ValidateSessionID(id); err != nil {
+		return nil, err
+	}
+	var rec sdk.SessionRecord
+	err := d.db.View(func(tx *bolt.Tx) error {
+		key := []byte(id)
+		data := tx.Bucket(bucketMeta).Get(key)
+		if data == nil {
+			return fmt.Errorf("%w: %s", sdk.ErrSessionNotFound, id)
+		}
+		if err := json.Unmarshal(data, &rec.SessionMeta); err != nil {
+			return fmt.Errorf("unmarshaling session: %w", err)
+		}
+		// Values are only valid inside the transaction
+		rec.Messages = append(json.RawMessage(nil), tx.Bucket(bucketMessages).Get(key)...)
+		rec.Branches = append(json.RawMessage(nil), tx.Bucket(bucketBranches).Get(key)...)
+		rec.State = append(json.RawMessage(nil), tx.Bucket(bucketState).Get(key)...)
+		rec.Text = string(tx.Bucket(bucketText).Get(key))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Delete removes id.
+func (d *DB) Delete(id string) error {
+	if err := sdk.ValidateSessionID(id); err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		key := []byte(id)
+		for _, name := range buckets {
+			if err := tx.Bucket(name).Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Query returns the sessions matching q. Message text is only read when
+// q.Text is set.
+func (d *DB) Query(q sdk.SessionQuery) (*sdk.SessionPage, error) {
+	var matches []sdk.SessionMeta
+	err := d.db.View(func(tx *bolt.Tx) error {
+		texts := tx.Bucket(bucketText)
+		return tx.Bucket(bucketMeta).ForEach(func(k, v []byte) error {
+			var meta sdk.SessionMeta
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return fmt.Errorf("unmarshaling session %s: %w", k, err)
+			}
+			var text string
+			if q.Text != "" {
+				text = string(texts.Get(k))
+			}
+			if q.Match(&meta, text) {
+				matches = append(matches, meta)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q.Page(matches), nil
+}
+
+// Close closes the database file.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -30,6 +31,10 @@ func (sm *StrategyMetrics) SuccessRate() float64 {
 	return float64(sm.SuccessCount) / float64(total)
 }
 
+// simulationPrefix namespaces simulation-mode metrics so they are kept
+// apart from regular strategies in GetBestStrategy.
+const simulationPrefix = "simulation:"
+
 // StrategyOptimizer tracks and optimizes strategy choices with composite scoring.
 type StrategyOptimizer struct {
 	metrics   map[string]*StrategyMetrics
@@ -104,6 +109,9 @@ func (so *StrategyOptimizer) GetBestStrategy(taskType string) string {
 
 	var scores []scored
 	for name, m := range so.metrics {
+		if strings.HasPrefix(name, simulationPrefix) {
+			continue
+		}
 		baseScore := m.SuccessRate()
 
 		// Experience boost for this task type
@@ -136,6 +144,36 @@ func (so *StrategyOptimizer) GetBestStrategy(taskType string) string {
 	return scores[0].name
 }
 
+// RecordSimulationOutcome records the outcome of a plan built with the given
+// MCTS simulation mode.
+func (so *StrategyOptimizer) RecordSimulationOutcome(taskType string, mode SimulationMode, success bool, duration time.Duration) {
+	so.RecordOutcome(taskType, simulationPrefix+string(mode), success, duration)
+}
+
+// BestSimulationMode returns the simulation mode with the highest success
+// rate for a task type, or false if no outcomes have been recorded yet.
+func (so *StrategyOptimizer) BestSimulationMode(taskType string) (SimulationMode, bool) {
+	so.mu.RLock()
+	defer so.mu.RUnlock()
+
+	var best SimulationMode
+	bestScore := -1.0
+	for name, m := range so.metrics {
+		if !strings.HasPrefix(name, simulationPrefix) {
+			continue
+		}
+		score := m.SuccessRate()
+		if m.TaskTypes[taskType] == 0 {
+			score *= 0.9 // prefer modes with experience on this task type
+		}
+		if score > bestScore {
+			bestScore = score
+			best = SimulationMode(strings.TrimPrefix(name, simulationPrefix))
+		}
+	}
+	return best, best != ""
+}
+
 // GetStrategies returns all strategy metrics.
 func (so *StrategyOptimizer) GetStrategies() map[string]*StrategyMetrics {
 	so.mu.RLock()
@@ -12,9 +12,11 @@ type CompletionHandler func(task *Task)
 
 // Manager manages background tasks.
 type Manager struct {
-	tasks   map[string]*Task
-	workDir string
-	counter int
+	tasks       map[string]*Task
+	workDir     string
+	counter     int
+	outputLimit int
+	closed      bool
 
 	onComplete CompletionHandler
 
@@ -29,6 +31,28 @@ func NewManager(workDir string) *Manager {
 	}
 }
 
+// NewManagerWithContext creates a task manager that shuts down, stopping
+// all running tasks, when ctx is done. Use it with the agent's lifetime
+// context so that servers and watchers do not outlive the agent.
+func NewManagerWithContext(ctx context.Context, workDir string) *Manager {
+	m := NewManager(workDir)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = m.Shutdown(shutdownCtx)
+	}()
+	return m
+}
+
+// SetOutputLimit sets how many output bytes new tasks keep; older output
+// is dropped (default: DefaultOutputLimit).
+func (m *Manager) SetOutputLimit(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outputLimit = limit
+}
+
 // SetCompletionHandler sets the handler called when tasks complete.
 func (m *Manager) SetCompletionHandler(handler CompletionHandler) {
 	m.mu.Lock()
@@ -36,39 +60,51 @@ func (m *Manager) SetCompletionHandler(handler CompletionHandler) {
 	m.onComplete = handler
 }
 
-// Start starts a new background task and returns its ID.
-func (m *Manager) Start(ctx context.Context, command string) (string, error) {
-	m.mu.Lock()
-	m.counter++
-	id := fmt.Sprintf("task_%d_%d", time.Now().Unix(), m.counter)
-
-	task := NewTask(id, command, m.workDir)
-	m.tasks[id] = task
-	onComplete := m.onComplete
-	m.mu.Unlock()
-
-	// Start the task
-	if err := task.Start(ctx); err != nil {
-		m.mu.Lock()
-		delete(m.tasks, id)
-		m.mu.Unlock()
-		return "", err
-	}
+// StartOptions describes a task to start with StartWithOptions.
+type StartOptions struct {
+	// Command runs through sh -c. If Program is set, Program runs with
+	// Args directly instead, without shell interpretation.
+	Command string
+	Program string
+	Args    []string
 
-	// Monitor for completion
-	go m.monitorTask(task, onComplete)
+	// Stdin keeps the task's standard input open for Task.WriteInput.
+	Stdin bool
+}
 
-	return id, nil
+// Start starts a new background task and returns its ID.
+func (m *Manager) Start(ctx context.Context, command string) (string, error) {
+	return m.StartWithOptions(ctx, StartOptions{Command: command})
 }
 
 // StartWithArgs starts a new background task using direct exec (no shell interpretation).
 // This prevents command injection attacks when constructing commands from user input.
 func (m *Manager) StartWithArgs(ctx context.Context, program string, args []string) (string, error) {
+	return m.StartWithOptions(ctx, StartOptions{Program: program, Args: args})
+}
+
+// StartWithOptions starts a new background task and returns its ID. The
+// task is stopped when ctx is cancelled; pass a context that lives as long
+// as the task should, not the context of a single request.
+func (m *Manager) StartWithOptions(ctx context.Context, opts StartOptions) (string, error) {
 	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return "", fmt.Errorf("task manager is shut down")
+	}
 	m.counter++
 	id := fmt.Sprintf("task_%d_%d", time.Now().Unix(), m.counter)
 
-	task := NewTaskWithArgs(id, program, args, m.workDir)
+	var task *Task
+	if opts.Program != "" {
+		task = NewTaskWithArgs(id, opts.Program, opts.Args, m.workDir)
+	} else {
+		task = NewTask(id, opts.Command, m.workDir)
+	}
+	task.Stdin = opts.Stdin
+	if m.outputLimit > 0 {
+		task.Output = NewOutputBuffer(m.outputLimit)
+	}
 	m.tasks[id] = task
 	onComplete := m.onComplete
 	m.mu.Unlock()
@@ -89,10 +125,7 @@ func (m *Manager) StartWithArgs(ctx context.Context, program string, args []stri
 
 // monitorTask waits for task completion and calls the handler.
 func (m *Manager) monitorTask(task *Task, onComplete CompletionHandler) {
-	// Poll for completion
-	for !task.IsComplete() {
-		time.Sleep(100 * time.Millisecond)
-	}
+	<-task.Done()
 
 	if onComplete != nil {
 		onComplete(task)
@@ -218,6 +251,30 @@ func (m *Manager) CancelAll() {
 	}
 }
 
+// Shutdown stops all running tasks and waits for them to exit, until ctx
+// is done. No tasks can be started afterwards.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.CancelAll()
+
+	m.mu.RLock()
+	tasks := make([]*Task, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		tasks = append(tasks, task)
+	}
+	m.mu.RUnlock()
+
+	for _, task := range tasks {
+		if err := task.Wait(ctx); err != nil {
+			return fmt.Errorf("waiting for task %s: %w", task.ID, err)
+		}
+	}
+	return nil
+}
+
 // Count returns the number of tasks.
 func (m *Manager) Count() int {
 	m.mu.RLock()
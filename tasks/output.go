@@ -0,0 +1,142 @@
+package tasks
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultOutputLimit is the number of output bytes kept per task.
+const DefaultOutputLimit = 1 << 20
+
+// OutputBuffer collects a task's output, keeping only the most recent
+// bytes once it reaches its limit. Positions in the output are absolute
+// offsets into everything ever written, so readers can resume where they
+// left off even after old output was dropped.
+type OutputBuffer struct {
+	data    []byte
+	start   int // index of the oldest byte in data, once it has wrapped
+	written int64
+	limit   int
+
+	mu sync.RWMutex
+}
+
+// NewOutputBuffer creates a buffer keeping up to limit bytes. A limit of 0
+// or less uses DefaultOutputLimit.
+func NewOutputBuffer(limit int) *OutputBuffer {
+	if limit <= 0 {
+		limit = DefaultOutputLimit
+	}
+	return &OutputBuffer{limit: limit}
+}
+
+// Write appends p, dropping the oldest output beyond the limit.
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(p)
+	b.written += int64(n)
+	if n >= b.limit {
+		b.data = append(b.data[:0], p[n-b.limit:]...)
+		b.start = 0
+		return n, nil
+	}
+	for len(p) > 0 {
+		if len(b.data) < b.limit {
+			// Still growing
+			room := b.limit - len(b.data)
+			if room > len(p) {
+				room = len(p)
+			}
+			b.data = append(b.data, p[:room]...)
+			p = p[room:]
+			continue
+		}
+		// Full: overwrite the oldest bytes
+		copied := copy(b.data[b.start:], p)
+		b.start = (b.start + copied) % b.limit
+		p = p[copied:]
+	}
+	return n, nil
+}
+
+// String returns the output that is kept.
+func (b *OutputBuffer) String() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.stringLocked()
+}
+
+// Bytes returns a copy of the output that is kept.
+func (b *OutputBuffer) Bytes() []byte {
+	return []byte(b.String())
+}
+
+// Len returns the number of bytes kept, as bytes.Buffer.Len does.
+func (b *OutputBuffer) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.data)
+}
+
+// Written returns the total number of bytes written, including dropped
+// ones. It is the offset the next write starts at.
+func (b *OutputBuffer) Written() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.written
+}
+
+// OutputChunk is a part of a task's output.
+type OutputChunk struct {
+	Data    string
+	Offset  int64 // offset of Data in the output
+	Next    int64 // offset to continue reading from
+	Dropped int64 // bytes after the requested offset that were no longer kept
+}
+
+// ReadFrom returns up to max bytes of output from offset on; max <= 0
+// means no limit. Offsets past the end return an empty chunk.
+func (b *OutputBuffer) ReadFrom(offset int64, max int) OutputChunk {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	first := b.written - int64(len(b.data))
+	chunk := OutputChunk{Offset: offset}
+	if offset < first {
+		chunk.Dropped = first - offset
+		chunk.Offset = first
+	}
+	if chunk.Offset > b.written {
+		chunk.Offset = b.written
+	}
+
+	data := b.stringLocked()[chunk.Offset-first:]
+	if max > 0 && len(data) > max {
+		data = data[:max]
+	}
+	chunk.Data = data
+	chunk.Next = chunk.Offset + int64(len(data))
+	return chunk
+}
+
+// Tail returns the last n lines of the kept output.
+func (b *OutputBuffer) Tail(n int) string {
+	out := strings.TrimRight(b.String(), "\n")
+	if n <= 0 || out == "" {
+		return out
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *OutputBuffer) stringLocked() string {
+	if b.start == 0 {
+		return string(b.data)
+	}
+	return string(b.data[b.start:]) + string(b.data[:b.start])
+}
@@ -0,0 +1,116 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// probeInterval is how often readiness probes check a task.
+const probeInterval = 100 * time.Millisecond
+
+// maxPendingLine caps the unterminated output line kept between checks of
+// WaitForOutput.
+const maxPendingLine = 64 << 10
+
+// WaitForOutput waits until the task's output matches pattern and returns
+// the matching text. Output written before the call counts too. It fails
+// when the task exits without a match or ctx is done; use a context with a
+// timeout to bound the wait.
+func (t *Task) WaitForOutput(ctx context.Context, pattern *regexp.Regexp) (string, error) {
+	var offset int64
+	var pending string // the last, unterminated line
+
+	check := func() (string, bool) {
+		chunk := t.Output.ReadFrom(offset, 0)
+		offset = chunk.Next
+		if chunk.Data == "" {
+			return "", false
+		}
+		text := pending + chunk.Data
+		if match := pattern.FindString(text); match != "" {
+			return match, true
+		}
+		pending = text[strings.LastIndexByte(text, '\n')+1:]
+		if len(pending) > maxPendingLine {
+			pending = pending[len(pending)-maxPendingLine:]
+		}
+		return "", false
+	}
+
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for {
+		if match, ok := check(); ok {
+			return match, nil
+		}
+		select {
+		case <-t.Done():
+			// Output may have arrived between the check and the exit
+			if match, ok := check(); ok {
+				return match, nil
+			}
+			return "", fmt.Errorf("task %s %s before its output matched %q", t.ID, t.exitDescription(), pattern)
+		case <-ctx.Done():
+			return "", fmt.Errorf("no output matching %q: %w", pattern, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForPort waits until a TCP connection to address, such as
+// "localhost:8080", succeeds. It fails when the task exits first or ctx is
+// done; use a context with a timeout to bound the wait.
+//
+// A connection does not tell which process accepted it: check with
+// PortInUse before starting the task that the port is free, or another
+// process can make the task look ready.
+func (t *Task) WaitForPort(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: time.Second}
+
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for {
+		if conn, err := dialer.DialContext(ctx, "tcp", address); err == nil {
+			conn.Close()
+			return nil
+		}
+		select {
+		case <-t.Done():
+			return fmt.Errorf("task %s %s before %s accepted connections", t.ID, t.exitDescription(), address)
+		case <-ctx.Done():
+			return fmt.Errorf("%s is not accepting connections: %w", address, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// PortInUse reports whether something already accepts TCP connections at
+// address.
+func PortInUse(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// exitDescription describes how a finished task ended, e.g.
+// "exited with code 1".
+func (t *Task) exitDescription() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	switch t.Status {
+	case StatusCompleted:
+		return "exited"
+	case StatusCancelled:
+		return "was cancelled"
+	default:
+		return fmt.Sprintf("exited with code %d", t.ExitCode)
+	}
+}
@@ -1,9 +1,9 @@
 package tasks
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -109,11 +109,15 @@ func (s Status) String() string {
 }
 
 // Task represents a background task.
+//
+// Output used to be a bytes.Buffer. It is now a *OutputBuffer, which is
+// safe to read while the task runs and keeps String, Len and Bytes; code
+// that took its address or wrote to it must use the pointer directly.
 type Task struct {
 	ID        string
 	Command   string
 	Status    Status
-	Output    bytes.Buffer
+	Output    *OutputBuffer
 	Error     string
 	ExitCode  int
 	StartTime time.Time
@@ -124,8 +128,14 @@ type Task struct {
 	Program string
 	Args    []string
 
+	// Stdin keeps the task's standard input open for WriteInput. Without
+	// it, the task reads from an empty input.
+	Stdin bool
+
 	cmd        *exec.Cmd
+	stdin      io.WriteCloser
 	cancelFunc context.CancelFunc
+	done       chan struct{}
 	mu         sync.RWMutex
 }
 
@@ -135,7 +145,9 @@ func NewTask(id, command, workDir string) *Task {
 		ID:      id,
 		Command: command,
 		Status:  StatusPending,
+		Output:  NewOutputBuffer(0),
 		WorkDir: workDir,
+		done:    make(chan struct{}),
 	}
 }
 
@@ -148,7 +160,9 @@ func NewTaskWithArgs(id, program string, args []string, workDir string) *Task {
 		Program: program,
 		Args:    args,
 		Status:  StatusPending,
+		Output:  NewOutputBuffer(0),
 		WorkDir: workDir,
+		done:    make(chan struct{}),
 	}
 }
 
@@ -172,8 +186,17 @@ func (t *Task) Start(ctx context.Context) error {
 		t.cmd = exec.CommandContext(execCtx, "sh", "-c", t.Command)
 	}
 	t.cmd.Dir = t.WorkDir
-	t.cmd.Stdout = &t.Output
-	t.cmd.Stderr = &t.Output
+	t.cmd.Stdout = t.Output
+	t.cmd.Stderr = t.Output
+	if t.Stdin {
+		stdin, err := t.cmd.StdinPipe()
+		if err != nil {
+			cancel()
+			t.mu.Unlock()
+			return fmt.Errorf("failed to open stdin: %w", err)
+		}
+		t.stdin = stdin
+	}
 
 	// Use sanitized environment to prevent leaking sensitive env vars
 	t.cmd.Env = buildSafeEnv()
@@ -181,19 +204,30 @@ func (t *Task) Start(ctx context.Context) error {
 	// Set up process group for proper cleanup of child processes
 	setProcAttr(t.cmd)
 
+	if err := t.cmd.Start(); err != nil {
+		cancel()
+		t.Status = StatusFailed
+		t.Error = err.Error()
+		t.ExitCode = -1
+		t.mu.Unlock()
+		close(t.done)
+		return err
+	}
+
 	t.Status = StatusRunning
 	t.StartTime = time.Now()
 	t.mu.Unlock()
 
-	// Run in background
+	// Wait in background
 	go t.run()
 
 	return nil
 }
 
-// run executes the command and updates status.
+// run waits for the command and updates status.
 func (t *Task) run() {
-	err := t.cmd.Run()
+	defer close(t.done)
+	err := t.cmd.Wait()
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -241,11 +275,60 @@ func (t *Task) GetStatus() Status {
 
 // GetOutput returns the current output.
 func (t *Task) GetOutput() string {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
 	return t.Output.String()
 }
 
+// ReadOutput returns up to max bytes of output from offset on; max <= 0
+// means no limit.
+func (t *Task) ReadOutput(offset int64, max int) OutputChunk {
+	return t.Output.ReadFrom(offset, max)
+}
+
+// WriteInput writes s to the task's standard input. The task must have
+// been started with Stdin set.
+func (t *Task) WriteInput(s string) error {
+	t.mu.RLock()
+	stdin, status := t.stdin, t.Status
+	t.mu.RUnlock()
+
+	if stdin == nil {
+		return fmt.Errorf("task %s was not started with stdin", t.ID)
+	}
+	if status != StatusRunning {
+		return fmt.Errorf("task %s is %s", t.ID, status)
+	}
+	_, err := io.WriteString(stdin, s)
+	return err
+}
+
+// CloseInput closes the task's standard input, signalling end of input.
+func (t *Task) CloseInput() error {
+	t.mu.RLock()
+	stdin := t.stdin
+	t.mu.RUnlock()
+
+	if stdin == nil {
+		return fmt.Errorf("task %s was not started with stdin", t.ID)
+	}
+	return stdin.Close()
+}
+
+// Done returns a channel that is closed when the task's process has
+// exited.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait blocks until the task's process has exited or ctx is done.
+func (t *Task) Wait(ctx context.Context) error {
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GetError returns the error message if failed.
 func (t *Task) GetError() string {
 	t.mu.RLock()
@@ -271,7 +354,10 @@ func (t *Task) IsComplete() bool {
 func (t *Task) Duration() time.Duration {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
+	return t.durationLocked()
+}
 
+func (t *Task) durationLocked() time.Duration {
 	if t.StartTime.IsZero() {
 		return 0
 	}
@@ -306,7 +392,7 @@ func (t *Task) GetInfo() Info {
 		Output:    t.Output.String(),
 		Error:     t.Error,
 		ExitCode:  t.ExitCode,
-		Duration:  t.Duration(),
+		Duration:  t.durationLocked(),
 		StartTime: t.StartTime,
 		EndTime:   t.EndTime,
 	}
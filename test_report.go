@@ -0,0 +1,130 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TestStatus is the outcome of a single test.
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "pass"
+	TestFailed  TestStatus = "fail"
+	TestSkipped TestStatus = "skip"
+)
+
+// TestCase is the result of one test in a TestReport.
+type TestCase struct {
+	Name     string        `json:"name"`
+	Package  string        `json:"package,omitempty"` // Go package, or the test file for pytest and jest
+	Status   TestStatus    `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Message  string        `json:"message,omitempty"` // failure message
+	File     string        `json:"file,omitempty"`
+	Line     int           `json:"line,omitempty"`
+	Flaky    bool          `json:"flaky,omitempty"` // failed, then passed when re-run
+}
+
+// Location returns the test's failure location as "file:line", or "" when
+// it is unknown.
+func (c TestCase) Location() string {
+	if c.File == "" {
+		return ""
+	}
+	if c.Line > 0 {
+		return fmt.Sprintf("%s:%d", c.File, c.Line)
+	}
+	return c.File
+}
+
+// FileCoverage is the statement coverage of one source file.
+type FileCoverage struct {
+	File       string  `json:"file"`
+	Percent    float64 `json:"percent"`
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+}
+
+// CoverageReport is the statement coverage of a test run.
+type CoverageReport struct {
+	Percent float64        `json:"percent"`
+	Files   []FileCoverage `json:"files"`
+}
+
+// TestReport is the structured result of the run_tests tool, returned in
+// ToolResult.Data.
+type TestReport struct {
+	Framework      string          `json:"framework"`
+	Success        bool            `json:"success"`
+	Passed         int             `json:"passed"`
+	Failed         int             `json:"failed"`
+	Skipped        int             `json:"skipped"`
+	Duration       time.Duration   `json:"duration"`
+	Tests          []TestCase      `json:"tests"`
+	FailedPackages []string        `json:"failed_packages,omitempty"` // packages or test files that failed outside any test, e.g. to build
+	Coverage       *CoverageReport `json:"coverage,omitempty"`
+	Reruns         int             `json:"reruns,omitempty"` // re-runs of failed tests
+}
+
+// Failures returns the tests that failed.
+func (r *TestReport) Failures() []TestCase {
+	return r.filter(func(c TestCase) bool { return c.Status == TestFailed })
+}
+
+// FlakyTests returns the tests that failed and then passed when re-run.
+func (r *TestReport) FlakyTests() []TestCase {
+	return r.filter(func(c TestCase) bool { return c.Flaky })
+}
+
+// Count recomputes Passed, Failed and Skipped from Tests.
+func (r *TestReport) Count() {
+	r.Passed, r.Failed, r.Skipped = 0, 0, 0
+	for _, c := range r.Tests {
+		switch c.Status {
+		case TestPassed:
+			r.Passed++
+		case TestFailed:
+			r.Failed++
+		case TestSkipped:
+			r.Skipped++
+		}
+	}
+}
+
+// FailureSummary describes what failed in one line, such as
+// "tests failed: TestParse (parse_test.go:12), TestLex".
+func (r *TestReport) FailureSummary() string {
+	failures := r.Failures()
+	if len(failures) == 0 {
+		if len(r.FailedPackages) > 0 {
+			return "packages failed: " + strings.Join(r.FailedPackages, ", ")
+		}
+		return "tests failed"
+	}
+
+	var names []string
+	for i, c := range failures {
+		if i == 5 {
+			names = append(names, fmt.Sprintf("and %d more", len(failures)-i))
+			break
+		}
+		if loc := c.Location(); loc != "" {
+			names = append(names, fmt.Sprintf("%s (%s)", c.Name, loc))
+		} else {
+			names = append(names, c.Name)
+		}
+	}
+	return "tests failed: " + strings.Join(names, ", ")
+}
+
+func (r *TestReport) filter(keep func(TestCase) bool) []TestCase {
+	var cases []TestCase
+	for _, c := range r.Tests {
+		if keep(c) {
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"google.golang.org/genai"
+
+	"github.com/ginkida/gokin-sdk/security"
 )
 
 // Tool defines the interface for all tools.
@@ -44,6 +46,20 @@ type ExecutionSummary struct {
 	RiskLevel        SafetyLevel   `json:"risk_level"`
 	UserVisible      bool          `json:"user_visible"`
 	RequiresApproval bool          `json:"requires_approval"`
+
+	// Diff is the unified diff of a file change, computed before the
+	// change is applied.
+	Diff string `json:"diff,omitempty"`
+}
+
+// CommandResult is the structured result of the bash tool, returned in
+// ToolResult.Data.
+type CommandResult struct {
+	ExitCode int `json:"exit_code"`
+
+	// Sandbox is the isolation the command ran with: the features applied
+	// and those requested but unavailable. Nil when it was not sandboxed.
+	Sandbox *security.SandboxReport `json:"sandbox,omitempty"`
 }
 
 // MultimodalPart represents a non-text part of a tool result (e.g., image, binary).
@@ -12,16 +12,19 @@ type ToolDependencyClassifier struct {
 func NewToolDependencyClassifier() *ToolDependencyClassifier {
 	return &ToolDependencyClassifier{
 		writeTools: map[string]bool{
-			"write":      true,
-			"edit":       true,
-			"bash":       true,
-			"delete":     true,
-			"move":       true,
-			"copy":       true,
-			"mkdir":      true,
-			"git_commit": true,
-			"git_add":    true,
-			"ssh":        true,
+			"write":       true,
+			"edit":        true,
+			"apply_patch": true,
+			"lsp_rename":  true,
+			"go_edit":     true,
+			"bash":        true,
+			"delete":      true,
+			"move":        true,
+			"copy":        true,
+			"mkdir":       true,
+			"git_commit":  true,
+			"git_add":     true,
+			"ssh":         true,
 		},
 	}
 }
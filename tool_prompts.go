@@ -247,14 +247,19 @@ func GetToolGuide(toolName string) (ToolUsageGuide, bool) {
 // GetToolResponseHint returns a brief hint about how to respond after using a tool.
 func GetToolResponseHint(toolName string) string {
 	hints := map[string]string{
-		"read":  "[HINT: Explain what this file contains, key sections, and how it answers the user's question]",
-		"grep":  "[HINT: Summarize matches, group by purpose, explain patterns found]",
-		"glob":  "[HINT: Categorize files found, highlight important ones, suggest which to read]",
-		"bash":  "[HINT: Summarize command output, explain results, highlight errors/warnings]",
-		"write": "[HINT: Confirm file created, explain contents, suggest verification steps]",
-		"edit":  "[HINT: Explain what changed, show before/after, suggest testing]",
-		"tree":  "[HINT: Explain directory structure, identify key directories]",
-		"diff":  "[HINT: Summarize changes, explain their significance]",
+		"read":        "[HINT: Explain what this file contains, key sections, and how it answers the user's question]",
+		"grep":        "[HINT: Summarize matches, group by purpose, explain patterns found]",
+		"glob":        "[HINT: Categorize files found, highlight important ones, suggest which to read]",
+		"bash":        "[HINT: Summarize command output, explain results, highlight errors/warnings]",
+		"write":       "[HINT: Confirm file created, explain contents, suggest verification steps]",
+		"edit":        "[HINT: Explain what changed, show before/after, suggest testing]",
+		"apply_patch": "[HINT: Summarize the changes per file, note any fuzzy matches, suggest testing]",
+		"lsp":         "[HINT: Explain what the symbol is and where it is used; for diagnostics, explain each problem and how to fix it]",
+		"lsp_rename":  "[HINT: Summarize the renamed files, then check diagnostics or run the tests]",
+		"go_symbols":  "[HINT: Point out the declarations relevant to the task and where they are]",
+		"go_edit":     "[HINT: Explain what changed in the declaration, then build or run the tests]",
+		"tree":        "[HINT: Explain directory structure, identify key directories]",
+		"diff":        "[HINT: Summarize changes, explain their significance]",
 	}
 	if hint, ok := hints[toolName]; ok {
 		return hint
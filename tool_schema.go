@@ -0,0 +1,119 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// ValidateToolArgs checks args against a tool's function declaration schema.
+// It verifies required fields, value types and enum constraints, and rejects
+// arguments that the schema does not declare.
+func ValidateToolArgs(decl *genai.FunctionDeclaration, args map[string]any) error {
+	if decl == nil || decl.Parameters == nil {
+		return nil
+	}
+	return validateObject("", decl.Parameters, args)
+}
+
+func validateObject(prefix string, schema *genai.Schema, obj map[string]any) error {
+	for _, name := range schema.Required {
+		if v, ok := obj[name]; !ok || v == nil {
+			return &ValidationError{Field: joinField(prefix, name), Message: "required field is missing"}
+		}
+	}
+
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+
+	// Iterate in a stable order so the reported error is deterministic.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prop, ok := schema.Properties[k]
+		if !ok {
+			return &ValidationError{Field: joinField(prefix, k), Message: "unknown field"}
+		}
+		if err := validateValue(joinField(prefix, k), prop, obj[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValue(field string, schema *genai.Schema, v any) error {
+	if schema == nil || v == nil {
+		return nil
+	}
+
+	switch schema.Type {
+	case genai.TypeString:
+		s, ok := v.(string)
+		if !ok {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("expected string, got %T", v)}
+		}
+		if len(schema.Enum) > 0 && !containsString(schema.Enum, s) {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be one of [%s]", strings.Join(schema.Enum, ", "))}
+		}
+	case genai.TypeInteger:
+		switch n := v.(type) {
+		case int, int64:
+		case float64:
+			if n != float64(int64(n)) {
+				return &ValidationError{Field: field, Message: "expected integer, got fractional number"}
+			}
+		default:
+			return &ValidationError{Field: field, Message: fmt.Sprintf("expected integer, got %T", v)}
+		}
+	case genai.TypeNumber:
+		switch v.(type) {
+		case int, int64, float64:
+		default:
+			return &ValidationError{Field: field, Message: fmt.Sprintf("expected number, got %T", v)}
+		}
+	case genai.TypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("expected boolean, got %T", v)}
+		}
+	case genai.TypeArray:
+		items, ok := v.([]any)
+		if !ok {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("expected array, got %T", v)}
+		}
+		for i, item := range items {
+			if err := validateValue(fmt.Sprintf("%s[%d]", field, i), schema.Items, item); err != nil {
+				return err
+			}
+		}
+	case genai.TypeObject:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("expected object, got %T", v)}
+		}
+		return validateObject(field, schema, obj)
+	}
+	return nil
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,906 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sdk "github.com/ginkida/gokin-sdk"
+
+	"google.golang.org/genai"
+)
+
+// PatchResult is the structured result of an apply_patch call, set as
+// ToolResult.Data.
+type PatchResult struct {
+	DryRun  bool              `json:"dry_run,omitempty"`
+	Applied bool              `json:"applied"`
+	Files   []PatchFileResult `json:"files"`
+}
+
+// PatchFileResult describes the changes to one file.
+type PatchFileResult struct {
+	Path   string            `json:"path"`
+	Action string            `json:"action"` // "modify", "create" or "delete"
+	Hunks  []PatchHunkReport `json:"hunks"`
+	Diff   string            `json:"diff,omitempty"`
+}
+
+// PatchHunkReport describes how one hunk or search/replace block applied.
+type PatchHunkReport struct {
+	Hunk    int    `json:"hunk"` // 1-based within the file
+	Applied bool   `json:"applied"`
+	Line    int    `json:"line,omitempty"`   // first line it applied at
+	Offset  int    `json:"offset,omitempty"` // lines away from the position in the hunk header
+	Fuzz    string `json:"fuzz,omitempty"`   // "trailing_whitespace" or "whitespace"
+	Error   string `json:"error,omitempty"`
+}
+
+// ApplyPatchTool applies unified diffs and search/replace blocks.
+type ApplyPatchTool struct {
+	workDir string
+	review  changeReview
+}
+
+// NewApplyPatch creates a new ApplyPatchTool. Relative paths in patches are
+// resolved against workDir.
+func NewApplyPatch(workDir string) *ApplyPatchTool {
+	return &ApplyPatchTool{workDir: workDir}
+}
+
+// SetApprover sets a callback that must accept each patch, shown as the
+// diff of every file it changes, before any file is written. Without one,
+// the approver carried by the run context is used (see sdk.WithReviewMode).
+func (t *ApplyPatchTool) SetApprover(approver sdk.ChangeApprover) {
+	t.review.approver = approver
+}
+
+func (t *ApplyPatchTool) Name() string { return "apply_patch" }
+
+func (t *ApplyPatchTool) Description() string {
+	return "Applies a unified diff or search/replace blocks to one or more files. " +
+		"Context is matched tolerantly (shifted lines, whitespace differences). " +
+		"Either every hunk applies or no file is changed; failures are reported per hunk."
+}
+
+func (t *ApplyPatchTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"patch": {
+					Type: genai.TypeString,
+					Description: "A unified diff ('--- a/file', '+++ b/file', '@@' hunks; use /dev/null to create or delete files), " +
+						"or search/replace blocks, each preceded by the file path on its own line:\n" +
+						"path/to/file\n<<<<<<< SEARCH\nold lines\n=======\nnew lines\n>>>>>>> REPLACE\n" +
+						"An empty SEARCH creates the file, or appends to it if it exists.",
+				},
+				"file_path": {
+					Type:        genai.TypeString,
+					Description: "File to patch when the patch does not name one",
+				},
+				"dry_run": {
+					Type:        genai.TypeBoolean,
+					Description: "If true, check the patch and return the resulting diff without changing files",
+				},
+			},
+			Required: []string{"patch"},
+		},
+	}
+}
+
+func (t *ApplyPatchTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	patch, ok := sdk.GetString(args, "patch")
+	if !ok || strings.TrimSpace(patch) == "" {
+		return sdk.NewErrorResult("patch is required"), nil
+	}
+	defaultPath := sdk.GetStringDefault(args, "file_path", "")
+	dryRun := sdk.GetBoolDefault(args, "dry_run", false)
+
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	var files []*filePatch
+	var err error
+	if searchMarker.MatchString(patch) {
+		files, err = parseSearchReplace(patch, defaultPath)
+	} else {
+		files, err = parseUnifiedDiff(patch, defaultPath)
+	}
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("invalid patch: %s", err)), nil
+	}
+	if len(files) == 0 {
+		return sdk.NewErrorResult("invalid patch: no files or hunks found"), nil
+	}
+
+	result := &PatchResult{DryRun: dryRun}
+	var outputs []*patchOutput
+	failed := 0
+	for _, fp := range files {
+		fp.path = t.resolve(fp.path)
+		out := applyFilePatch(fp)
+		out.result.Path = t.relPath(fp.path)
+		if out.failed() {
+			failed++
+		} else if out.result.Action == "create" {
+			out.result.Diff = UnifiedDiff("/dev/null", "b/"+out.result.Path, "", out.newText, 3)
+		} else if out.result.Action == "delete" {
+			out.result.Diff = UnifiedDiff("a/"+out.result.Path, "/dev/null", out.oldText, "", 3)
+		} else {
+			out.result.Diff = UnifiedDiff("a/"+out.result.Path, "b/"+out.result.Path, out.oldText, out.newText, 3)
+		}
+		result.Files = append(result.Files, out.result)
+		outputs = append(outputs, out)
+	}
+
+	if failed > 0 {
+		return &sdk.ToolResult{
+			Content: formatPatchFailure(result),
+			Data:    result,
+			Error:   fmt.Sprintf("patch not applied: %s failed; no files were changed", countFailedHunks(result)),
+			Success: false,
+		}, nil
+	}
+
+	summary := &sdk.ExecutionSummary{
+		ToolName:    t.Name(),
+		DisplayName: t.Name(),
+		Action:      "patch",
+		RiskLevel:   sdk.SafetyLevelCaution,
+		UserVisible: true,
+	}
+	var paths []string
+	var diff strings.Builder
+	for _, f := range result.Files {
+		paths = append(paths, f.Path)
+		diff.WriteString(f.Diff)
+	}
+	summary.Target = strings.Join(paths, ", ")
+	summary.Diff = diff.String()
+
+	if !dryRun {
+		if err := t.review.approve(ctx, summary); err != nil {
+			return &sdk.ToolResult{
+				Content:          formatPatchSummary(result),
+				Data:             result,
+				Error:            fmt.Sprintf("patch not applied: %s", err),
+				Success:          false,
+				ExecutionSummary: summary,
+			}, nil
+		}
+		if out, err := commitPatch(outputs); err != nil {
+			return &sdk.ToolResult{
+				Content:          formatPatchSummary(result),
+				Data:             result,
+				Error:            fmt.Sprintf("error writing %s: %s; no files were changed", out.result.Path, err),
+				Success:          false,
+				ExecutionSummary: summary,
+			}, nil
+		}
+		result.Applied = true
+	}
+
+	return &sdk.ToolResult{
+		Content:          formatPatchSummary(result),
+		Data:             result,
+		Success:          true,
+		ExecutionSummary: summary,
+	}, nil
+}
+
+func (t *ApplyPatchTool) resolve(path string) string {
+	if filepath.IsAbs(path) || t.workDir == "" {
+		return path
+	}
+	return filepath.Join(t.workDir, path)
+}
+
+func (t *ApplyPatchTool) relPath(path string) string {
+	if t.workDir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(t.workDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// --- Parsing ---
+
+// filePatch is the set of hunks for one file.
+type filePatch struct {
+	path   string
+	create bool // old side is /dev/null
+	delete bool // new side is /dev/null
+	hunks  []*patchHunk
+}
+
+// patchHunk is one diff hunk or search/replace block.
+type patchHunk struct {
+	oldStart int  // 1-based line from the hunk header
+	hasPos   bool // oldStart is known; otherwise the match must be unique
+	ops      []patchOp
+	noEOL    bool // "\ No newline at end of file" after the new side
+
+	// Lines still expected from the header counts; a "--- " or "+++ "
+	// line is hunk content while any are left.
+	oldLeft, newLeft int
+}
+
+type patchOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+func (h *patchHunk) oldLines() []string {
+	var lines []string
+	for _, op := range h.ops {
+		if op.kind != '+' {
+			lines = append(lines, op.text)
+		}
+	}
+	return lines
+}
+
+// addOp appends an op and counts it against the header's line counts.
+func (h *patchHunk) addOp(kind byte, text string) {
+	h.ops = append(h.ops, patchOp{kind, text})
+	if kind != '+' {
+		h.oldLeft--
+	}
+	if kind != '-' {
+		h.newLeft--
+	}
+}
+
+// pending reports whether the header promises more lines.
+func (h *patchHunk) pending() bool {
+	return h.oldLeft > 0 || h.newLeft > 0
+}
+
+// trimTrailingContext drops empty context lines left by blank lines after
+// a hunk.
+func (h *patchHunk) trimTrailingContext() {
+	for len(h.ops) > 0 {
+		last := h.ops[len(h.ops)-1]
+		if last.kind != ' ' || last.text != "" {
+			break
+		}
+		h.ops = h.ops[:len(h.ops)-1]
+	}
+}
+
+var (
+	hunkHeader    = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	searchMarker  = regexp.MustCompile(`(?m)^<{5,9} SEARCH\s*$`)
+	dividerMarker = regexp.MustCompile(`^={5,9}\s*$`)
+	replaceMarker = regexp.MustCompile(`^>{5,9} REPLACE\s*$`)
+)
+
+// parseUnifiedDiff parses a unified diff, tolerating wrong or missing line
+// counts in hunk headers.
+func parseUnifiedDiff(patch, defaultPath string) ([]*filePatch, error) {
+	lines := strings.Split(patch, "\n")
+	var files []*filePatch
+	var current *filePatch
+	var hunk *patchHunk
+
+	endHunk := func() {
+		if hunk != nil {
+			hunk.trimTrailingContext()
+			hunk = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") &&
+			(hunk == nil || !hunk.pending()):
+			endHunk()
+			oldPath := diffPath(line[4:])
+			newPath := diffPath(lines[i+1][4:])
+			i++
+			// Strip git's a/ and b/ prefixes
+			if (strings.HasPrefix(oldPath, "a/") || oldPath == "/dev/null") &&
+				(strings.HasPrefix(newPath, "b/") || newPath == "/dev/null") {
+				oldPath = strings.TrimPrefix(oldPath, "a/")
+				newPath = strings.TrimPrefix(newPath, "b/")
+			}
+			current = &filePatch{path: newPath}
+			switch {
+			case oldPath == "/dev/null" && newPath == "/dev/null":
+				return nil, fmt.Errorf("line %d: both sides are /dev/null", i)
+			case oldPath == "/dev/null":
+				current.create = true
+			case newPath == "/dev/null":
+				current.delete = true
+				current.path = oldPath
+			}
+			files = append(files, current)
+
+		case strings.HasPrefix(line, "@@"):
+			endHunk()
+			if current == nil {
+				if defaultPath == "" {
+					return nil, fmt.Errorf("line %d: hunk without a file header; pass file_path", i+1)
+				}
+				current = &filePatch{path: defaultPath}
+				files = append(files, current)
+			}
+			hunk = &patchHunk{}
+			if m := hunkHeader.FindStringSubmatch(line); m != nil {
+				hunk.oldStart, _ = strconv.Atoi(m[1])
+				hunk.hasPos = true
+				hunk.oldLeft, hunk.newLeft = hunkCount(m[2]), hunkCount(m[4])
+				// "-N,0" inserts after line N
+				if m[2] == "0" {
+					hunk.oldStart++
+				}
+			}
+			current.hunks = append(current.hunks, hunk)
+
+		case hunk != nil && line == "":
+			hunk.addOp(' ', "")
+		case hunk != nil && (line[0] == ' ' || line[0] == '-' || line[0] == '+'):
+			hunk.addOp(line[0], line[1:])
+		case hunk != nil && line[0] == '\\':
+			// "\ No newline at end of file" after the new side's last line
+			if n := len(hunk.ops); n > 0 && hunk.ops[n-1].kind != '-' {
+				hunk.noEOL = true
+			}
+
+		default:
+			// "diff --git", "index", mode lines and commentary end a hunk
+			endHunk()
+		}
+	}
+	endHunk()
+
+	// Drop headers without hunks, such as pure mode changes
+	var kept []*filePatch
+	for _, fp := range files {
+		if len(fp.hunks) > 0 || fp.delete {
+			kept = append(kept, fp)
+		}
+	}
+	return mergeFilePatches(kept), nil
+}
+
+// hunkCount parses a hunk header line count, which defaults to 1.
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// diffPath extracts the path from a ---/+++ header, dropping timestamps.
+func diffPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// parseSearchReplace parses search/replace blocks. Each block applies to
+// the path on the line before it, the previous block's file, or
+// defaultPath.
+func parseSearchReplace(patch, defaultPath string) ([]*filePatch, error) {
+	lines := strings.Split(patch, "\n")
+	var files []*filePatch
+	path := defaultPath
+	prev := ""
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if !searchMarker.MatchString(line) {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "```") {
+				prev = trimmed
+			}
+			continue
+		}
+
+		// A path is a lone token; prose before the block is ignored
+		if p := strings.Trim(prev, "`*: "); p != "" && !strings.ContainsAny(p, " \t") {
+			path = p
+		}
+		prev = ""
+		if path == "" {
+			return nil, fmt.Errorf("line %d: no file path before SEARCH block; pass file_path", i+1)
+		}
+
+		hunk := &patchHunk{}
+		section := byte('-')
+		closed := false
+		for i++; i < len(lines); i++ {
+			switch {
+			case section == '-' && dividerMarker.MatchString(lines[i]):
+				section = '+'
+				continue
+			case section == '+' && replaceMarker.MatchString(lines[i]):
+				closed = true
+			}
+			if closed {
+				break
+			}
+			hunk.ops = append(hunk.ops, patchOp{section, lines[i]})
+		}
+		if !closed {
+			return nil, fmt.Errorf("unterminated SEARCH block for %s (expected ======= and >>>>>>> REPLACE)", path)
+		}
+		files = append(files, &filePatch{path: path, hunks: []*patchHunk{hunk}})
+	}
+	return mergeFilePatches(files), nil
+}
+
+// mergeFilePatches joins patches to the same file, keeping hunk order.
+func mergeFilePatches(files []*filePatch) []*filePatch {
+	byPath := make(map[string]*filePatch)
+	var merged []*filePatch
+	for _, fp := range files {
+		if existing, ok := byPath[fp.path]; ok {
+			existing.hunks = append(existing.hunks, fp.hunks...)
+			existing.delete = existing.delete || fp.delete
+			continue
+		}
+		byPath[fp.path] = fp
+		merged = append(merged, fp)
+	}
+	return merged
+}
+
+// --- Applying ---
+
+// patchOutput is the in-memory result of patching one file.
+type patchOutput struct {
+	path    string
+	result  PatchFileResult
+	oldText string // with "\n" line endings
+	newText string
+	crlf    bool
+	mode    os.FileMode
+}
+
+func (o *patchOutput) failed() bool {
+	for _, h := range o.result.Hunks {
+		if !h.Applied {
+			return true
+		}
+	}
+	return len(o.result.Hunks) == 0 && o.result.Action != "delete"
+}
+
+// stage writes the new content to a temp file next to the target.
+func (o *patchOutput) stage() (string, error) {
+	dir := filepath.Dir(o.path)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", err
+	}
+	content := o.newText
+	if o.crlf {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(o.path)+".patch-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), o.mode)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// patchCommit tracks one file while a patch is committed.
+type patchCommit struct {
+	out      *patchOutput
+	staged   string // temp file with the new content; "" for deletions
+	backup   string // the original file, moved aside; "" if it did not exist
+	replaced bool
+}
+
+// commitPatch writes every output or none. New contents are staged in
+// temp files first; then each original is moved aside and replaced by
+// rename. If any step fails, the originals are put back. It returns the
+// output that failed.
+func commitPatch(outputs []*patchOutput) (*patchOutput, error) {
+	commits := make([]*patchCommit, 0, len(outputs))
+	removeStaged := func() {
+		for _, c := range commits {
+			if c.staged != "" {
+				os.Remove(c.staged)
+			}
+		}
+	}
+
+	for _, out := range outputs {
+		// Replace the file a symlink points to, not the link
+		if resolved, err := filepath.EvalSymlinks(out.path); err == nil {
+			out.path = resolved
+		}
+		c := &patchCommit{out: out}
+		if out.result.Action != "delete" {
+			staged, err := out.stage()
+			if err != nil {
+				removeStaged()
+				return out, err
+			}
+			c.staged = staged
+		}
+		commits = append(commits, c)
+	}
+
+	for i, c := range commits {
+		if err := c.replace(); err != nil {
+			for j := i; j >= 0; j-- {
+				commits[j].restore()
+			}
+			removeStaged()
+			return c.out, err
+		}
+	}
+
+	for _, c := range commits {
+		if c.backup != "" {
+			os.Remove(c.backup)
+		}
+	}
+	return nil, nil
+}
+
+// replace moves the original aside and puts the staged content in place.
+func (c *patchCommit) replace() error {
+	if _, err := os.Lstat(c.out.path); err == nil {
+		f, err := os.CreateTemp(filepath.Dir(c.out.path), "."+filepath.Base(c.out.path)+".orig-*")
+		if err != nil {
+			return err
+		}
+		backup := f.Name()
+		f.Close()
+		if err := os.Rename(c.out.path, backup); err != nil {
+			os.Remove(backup)
+			return err
+		}
+		c.backup = backup
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if c.staged != "" {
+		if err := os.Rename(c.staged, c.out.path); err != nil {
+			return err
+		}
+		c.staged = ""
+	}
+	c.replaced = true
+	return nil
+}
+
+// restore undoes a full or partial replace. An original that cannot be
+// moved back is left in its backup file.
+func (c *patchCommit) restore() {
+	if c.backup != "" {
+		if err := os.Rename(c.backup, c.out.path); err == nil {
+			c.backup = ""
+		}
+	} else if c.replaced {
+		os.Remove(c.out.path)
+	}
+	c.replaced = false
+}
+
+// Fuzz levels, from strictest to loosest.
+var patchFuzz = []struct {
+	name string
+	norm func(string) string
+}{
+	{"", func(s string) string { return s }},
+	{"trailing_whitespace", func(s string) string { return strings.TrimRight(s, " \t") }},
+	{"whitespace", func(s string) string { return strings.Join(strings.Fields(s), " ") }},
+}
+
+func applyFilePatch(fp *filePatch) *patchOutput {
+	out := &patchOutput{path: fp.path, mode: 0644}
+	out.result.Action = "modify"
+
+	data, err := os.ReadFile(fp.path)
+	exists := err == nil
+	switch {
+	case err != nil && !os.IsNotExist(err):
+		return out.failAll(fp, fmt.Sprintf("error reading file: %s", err))
+	case !exists && !fp.create && !onlyInserts(fp):
+		return out.failAll(fp, "file not found")
+	case !exists:
+		out.result.Action = "create"
+	case fp.create:
+		return out.failAll(fp, "file already exists")
+	}
+	if fp.delete {
+		out.result.Action = "delete"
+	}
+	if info, err := os.Stat(fp.path); err == nil {
+		out.mode = info.Mode().Perm()
+	}
+
+	content := string(data)
+	out.crlf = strings.Contains(content, "\r\n")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	out.oldText = content
+
+	trailingNL := strings.HasSuffix(content, "\n") || !exists
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	delta := 0
+	for i, h := range fp.hunks {
+		report := PatchHunkReport{Hunk: i + 1}
+		old := h.oldLines()
+		want := -1
+		if h.hasPos {
+			want = max(h.oldStart-1+delta, 0)
+		}
+
+		var pos int
+		if len(old) == 0 {
+			// Pure insertion at the header position, or at the end
+			pos = len(lines)
+			if h.hasPos {
+				pos = min(want, len(lines))
+			}
+		} else {
+			var fuzz string
+			var errMsg string
+			pos, fuzz, errMsg = locateHunk(lines, old, want)
+			if errMsg != "" {
+				report.Error = errMsg
+				out.result.Hunks = append(out.result.Hunks, report)
+				continue
+			}
+			report.Fuzz = fuzz
+		}
+
+		// Replace, keeping the file's own context lines
+		var replacement []string
+		k := pos
+		for _, op := range h.ops {
+			switch op.kind {
+			case ' ':
+				replacement = append(replacement, lines[k])
+				k++
+			case '-':
+				k++
+			case '+':
+				replacement = append(replacement, op.text)
+			}
+		}
+		lines = append(lines[:pos], append(replacement, lines[k:]...)...)
+		delta += len(replacement) - len(old)
+		if h.noEOL && pos+len(replacement) == len(lines) {
+			trailingNL = false
+		}
+
+		report.Applied = true
+		report.Line = pos + 1
+		if h.hasPos {
+			report.Offset = pos - want
+		}
+		out.result.Hunks = append(out.result.Hunks, report)
+	}
+
+	if fp.delete && len(lines) > 0 && !out.failed() {
+		if len(fp.hunks) > 0 {
+			out.result.Hunks = append(out.result.Hunks, PatchHunkReport{
+				Hunk:  len(fp.hunks),
+				Error: fmt.Sprintf("file deletion leaves %d line(s); the hunks must remove the whole file", len(lines)),
+			})
+		} else {
+			lines = nil
+		}
+	}
+
+	out.newText = strings.Join(lines, "\n")
+	if len(lines) > 0 && trailingNL {
+		out.newText += "\n"
+	}
+	return out
+}
+
+func (o *patchOutput) failAll(fp *filePatch, msg string) *patchOutput {
+	for i := range fp.hunks {
+		o.result.Hunks = append(o.result.Hunks, PatchHunkReport{Hunk: i + 1, Error: msg})
+	}
+	if len(fp.hunks) == 0 {
+		o.result.Hunks = append(o.result.Hunks, PatchHunkReport{Hunk: 1, Error: msg})
+	}
+	return o
+}
+
+// onlyInserts reports whether every hunk adds lines without context, so
+// the patch can create the file.
+func onlyInserts(fp *filePatch) bool {
+	for _, h := range fp.hunks {
+		if len(h.oldLines()) > 0 {
+			return false
+		}
+	}
+	return len(fp.hunks) > 0
+}
+
+// locateHunk finds old in lines at the strictest fuzz level that matches,
+// preferring the position nearest want. Without a position (want < 0) the
+// match must be unique.
+func locateHunk(lines, old []string, want int) (pos int, fuzz, errMsg string) {
+	for _, level := range patchFuzz {
+		normOld := make([]string, len(old))
+		for i, l := range old {
+			normOld[i] = level.norm(l)
+		}
+
+		var found []int
+		for p := 0; p+len(old) <= len(lines); p++ {
+			match := true
+			for j := range old {
+				if level.norm(lines[p+j]) != normOld[j] {
+					match = false
+					break
+				}
+			}
+			if match {
+				found = append(found, p)
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		if want < 0 {
+			if len(found) > 1 {
+				at := make([]string, 0, len(found))
+				for _, p := range found {
+					at = append(at, strconv.Itoa(p+1))
+				}
+				return 0, "", fmt.Sprintf("search text matches %d places (lines %s); include more surrounding lines",
+					len(found), strings.Join(at, ", "))
+			}
+			return found[0], level.name, ""
+		}
+
+		best := found[0]
+		for _, p := range found[1:] {
+			if abs(p-want) < abs(best-want) {
+				best = p
+			}
+		}
+		return best, level.name, ""
+	}
+
+	msg := "context not found"
+	if want >= 0 {
+		msg += fmt.Sprintf(" (expected near line %d)", want+1)
+	}
+	return 0, "", msg + closestMismatch(lines, old)
+}
+
+// closestMismatch describes where old comes closest to matching lines.
+func closestMismatch(lines, old []string) string {
+	norm := patchFuzz[len(patchFuzz)-1].norm
+	bestPos, bestLen := -1, 0
+	for p := range lines {
+		n := 0
+		for n < len(old) && p+n < len(lines) && norm(lines[p+n]) == norm(old[n]) {
+			n++
+		}
+		if n > bestLen {
+			bestPos, bestLen = p, n
+		}
+	}
+	if bestPos < 0 {
+		return fmt.Sprintf("; no line matches %q", truncateLine(old[0]))
+	}
+	found := "end of file"
+	if bestPos+bestLen < len(lines) {
+		found = fmt.Sprintf("%q", truncateLine(lines[bestPos+bestLen]))
+	}
+	return fmt.Sprintf("; closest match at line %d differs at line %d: expected %q, found %s",
+		bestPos+1, bestPos+bestLen+1, truncateLine(old[bestLen]), found)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// --- Reporting ---
+
+func formatPatchSummary(r *PatchResult) string {
+	var sb strings.Builder
+	if r.DryRun {
+		fmt.Fprintf(&sb, "Dry run: patch applies cleanly to %d file(s). No files were changed.\n\n", len(r.Files))
+	} else {
+		fmt.Fprintf(&sb, "Applied patch to %d file(s):\n\n", len(r.Files))
+	}
+	for _, f := range r.Files {
+		fmt.Fprintf(&sb, "%s %s (%d hunk(s)", actionLetter(f.Action), f.Path, len(f.Hunks))
+		for _, h := range f.Hunks {
+			var notes []string
+			if h.Offset != 0 {
+				notes = append(notes, fmt.Sprintf("offset %+d", h.Offset))
+			}
+			if h.Fuzz != "" {
+				notes = append(notes, "ignoring "+strings.ReplaceAll(h.Fuzz, "_", " "))
+			}
+			if len(notes) > 0 {
+				fmt.Fprintf(&sb, "; hunk %d at line %d, %s", h.Hunk, h.Line, strings.Join(notes, ", "))
+			}
+		}
+		sb.WriteString(")\n")
+	}
+	if r.DryRun {
+		for _, f := range r.Files {
+			if f.Diff != "" {
+				sb.WriteString("\n")
+				sb.WriteString(f.Diff)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func formatPatchFailure(r *PatchResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Patch not applied: %s failed. No files were changed.\n\n", countFailedHunks(r))
+	for _, f := range r.Files {
+		for _, h := range f.Hunks {
+			if h.Applied {
+				fmt.Fprintf(&sb, "%s hunk %d: ok (line %d)\n", f.Path, h.Hunk, h.Line)
+			} else {
+				fmt.Fprintf(&sb, "%s hunk %d: FAILED: %s\n", f.Path, h.Hunk, h.Error)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func countFailedHunks(r *PatchResult) string {
+	failed, total := 0, 0
+	for _, f := range r.Files {
+		for _, h := range f.Hunks {
+			total++
+			if !h.Applied {
+				failed++
+			}
+		}
+	}
+	return fmt.Sprintf("%d of %d hunk(s)", failed, total)
+}
+
+func actionLetter(action string) string {
+	switch action {
+	case "create":
+		return "A"
+	case "delete":
+		return "D"
+	default:
+		return "M"
+	}
+}
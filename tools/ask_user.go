@@ -13,6 +13,17 @@ import (
 // QuestionHandler handles user questions and returns their response.
 type QuestionHandler func(ctx context.Context, question string, options []string, defaultOption string) (string, error)
 
+// InteractionQuestionHandler adapts an sdk.Interaction to a QuestionHandler.
+// Questions with options become choices.
+func InteractionQuestionHandler(i sdk.Interaction) QuestionHandler {
+	return func(ctx context.Context, question string, options []string, defaultOption string) (string, error) {
+		if len(options) > 0 {
+			return i.Choose(ctx, question, options, defaultOption)
+		}
+		return i.Ask(ctx, question, defaultOption)
+	}
+}
+
 // AskUserTool allows the agent to ask the user a question.
 type AskUserTool struct {
 	handler QuestionHandler
@@ -23,7 +34,8 @@ func NewAskUser() *AskUserTool {
 	return &AskUserTool{}
 }
 
-// SetHandler sets the question handler.
+// SetHandler sets the question handler. Without one, questions go to the
+// sdk.Interaction of the running agent, if any.
 func (t *AskUserTool) SetHandler(handler QuestionHandler) {
 	t.handler = handler
 }
@@ -57,7 +69,13 @@ func (t *AskUserTool) Declaration() *genai.FunctionDeclaration {
 }
 
 func (t *AskUserTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
-	if t.handler == nil {
+	handler := t.handler
+	if handler == nil {
+		if i := sdk.InteractionFromContext(ctx); i != nil {
+			handler = InteractionQuestionHandler(i)
+		}
+	}
+	if handler == nil {
 		return sdk.NewErrorResult("ask_user: no question handler configured"), nil
 	}
 
@@ -77,7 +95,7 @@ func (t *AskUserTool) Execute(ctx context.Context, args map[string]any) (*sdk.To
 
 	defaultOpt := sdk.GetStringDefault(args, "default", "")
 
-	response, err := t.handler(ctx, question, options, defaultOpt)
+	response, err := handler(ctx, question, options, defaultOpt)
 	if err != nil {
 		return sdk.NewErrorResult(fmt.Sprintf("failed to get user response: %s", err)), nil
 	}
@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
 	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/security"
+	"github.com/ginkida/gokin-sdk/tasks"
 
 	"google.golang.org/genai"
 )
@@ -19,6 +22,31 @@ import (
 type BashTool struct {
 	workDir string
 	timeout time.Duration
+	policy  BashPolicy
+	tasks   *tasks.Manager
+}
+
+// BashPolicy controls how BashTool vets and runs commands.
+type BashPolicy struct {
+	// Validator checks each command before it runs. Blocked commands are
+	// not run; the reason is returned to the model. Nil disables checks.
+	Validator *security.CommandValidator
+
+	// BlockCaution also rejects commands the validator rates "caution",
+	// such as command substitution.
+	BlockCaution bool
+
+	// Sandbox runs commands through security.SandboxedCommand with
+	// security.SafeEnvironment when enabled. Note that the sandboxed
+	// environment sets HOME to the working directory. The protections a
+	// command ran with are reported in sdk.CommandResult.Sandbox.
+	Sandbox security.SandboxConfig
+}
+
+// DefaultBashPolicy validates commands with security.DefaultCommandValidator
+// and runs them unsandboxed with a scrubbed environment.
+func DefaultBashPolicy() BashPolicy {
+	return BashPolicy{Validator: security.DefaultCommandValidator}
 }
 
 // NewBash creates a new BashTool with the given working directory.
@@ -26,6 +54,7 @@ func NewBash(workDir string) *BashTool {
 	return &BashTool{
 		workDir: workDir,
 		timeout: 30 * time.Second,
+		policy:  DefaultBashPolicy(),
 	}
 }
 
@@ -34,13 +63,34 @@ func NewBashWithTimeout(workDir string, timeout time.Duration) *BashTool {
 	return &BashTool{
 		workDir: workDir,
 		timeout: timeout,
+		policy:  DefaultBashPolicy(),
 	}
 }
 
+// NewBashWithPolicy creates a new BashTool with a custom security policy.
+func NewBashWithPolicy(workDir string, policy BashPolicy) *BashTool {
+	t := NewBash(workDir)
+	t.policy = policy
+	return t
+}
+
+// SetPolicy replaces the security policy.
+func (t *BashTool) SetPolicy(policy BashPolicy) {
+	t.policy = policy
+}
+
+// SetTaskManager enables run_in_background. Such commands run as tasks of
+// m, in m's working directory, and are read, fed input and stopped with
+// task_output and task_stop when those tools share m.
+func (t *BashTool) SetTaskManager(m *tasks.Manager) {
+	t.tasks = m
+}
+
 func (t *BashTool) Name() string { return "bash" }
 
 func (t *BashTool) Description() string {
-	return "Executes a bash command and returns the output. Use for system operations, running tests, builds, etc."
+	return "Executes a bash command and returns the output. Use for system operations, running tests, builds, etc. " +
+		"Long-running commands such as dev servers can run in the background, optionally waiting until they are ready."
 }
 
 func (t *BashTool) Declaration() *genai.FunctionDeclaration {
@@ -54,6 +104,26 @@ func (t *BashTool) Declaration() *genai.FunctionDeclaration {
 					Type:        genai.TypeString,
 					Description: "The bash command to execute",
 				},
+				"run_in_background": {
+					Type:        genai.TypeBoolean,
+					Description: "Start the command as a background task and return its task ID; read it with task_output and stop it with task_stop",
+				},
+				"stdin": {
+					Type:        genai.TypeBoolean,
+					Description: "Background only: keep stdin open so task_output can send input (default: false)",
+				},
+				"ready_pattern": {
+					Type:        genai.TypeString,
+					Description: "Background only: wait until the output matches this regular expression, e.g. 'listening on'",
+				},
+				"ready_port": {
+					Type:        genai.TypeInteger,
+					Description: "Background only: wait until this TCP port on localhost accepts connections; it must be free when the command starts",
+				},
+				"ready_timeout": {
+					Type:        genai.TypeInteger,
+					Description: "Seconds to wait for ready_pattern or ready_port (default: 60)",
+				},
 			},
 			Required: []string{"command"},
 		},
@@ -66,6 +136,31 @@ func (t *BashTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		return sdk.NewErrorResult("command is required"), nil
 	}
 
+	level := sdk.SafetyLevelSafe
+	if t.policy.Validator != nil {
+		validation, rating := t.policy.Validator.ValidateWithLevel(command)
+		switch {
+		case !validation.Valid:
+			return &sdk.ToolResult{
+				Error:       fmt.Sprintf("command blocked by security policy: %s", validation.Reason),
+				SafetyLevel: sdk.SafetyLevelCritical,
+			}, nil
+		case rating == "caution" && t.policy.BlockCaution:
+			return &sdk.ToolResult{
+				Error:       fmt.Sprintf("command blocked by security policy: %s", validation.Reason),
+				SafetyLevel: sdk.SafetyLevelCaution,
+			}, nil
+		case rating == "caution":
+			level = sdk.SafetyLevelCaution
+		}
+	}
+
+	if sdk.GetBoolDefault(args, "run_in_background", false) {
+		result := t.startBackground(ctx, command, args)
+		result.SafetyLevel = level
+		return result, nil
+	}
+
 	execCtx := ctx
 	if t.timeout > 0 {
 		var cancel context.CancelFunc
@@ -73,7 +168,83 @@ func (t *BashTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		defer cancel()
 	}
 
-	cmd := exec.CommandContext(execCtx, "bash", "-c", command)
+	var result *sdk.ToolResult
+	if t.policy.Sandbox.Enabled {
+		result = t.runSandboxed(execCtx, command)
+	} else {
+		result = t.run(execCtx, command)
+	}
+	result.SafetyLevel = level
+	return result, nil
+}
+
+// startBackground starts command as a task and waits for it to become
+// ready when a readiness probe is given.
+func (t *BashTool) startBackground(ctx context.Context, command string, args map[string]any) *sdk.ToolResult {
+	if t.tasks == nil {
+		return sdk.NewErrorResult("run_in_background is not available: no task manager configured")
+	}
+	if t.policy.Sandbox.Enabled {
+		return sdk.NewErrorResult("run_in_background is not available when commands are sandboxed")
+	}
+
+	pattern := sdk.GetStringDefault(args, "ready_pattern", "")
+	port := sdk.GetIntDefault(args, "ready_port", 0)
+	timeout := time.Duration(sdk.GetIntDefault(args, "ready_timeout", 60)) * time.Second
+	if pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("invalid ready_pattern: %s", err))
+		}
+	}
+	// Otherwise the process already on the port would look like our server
+	if port > 0 && tasks.PortInUse(fmt.Sprintf("localhost:%d", port)) {
+		return sdk.NewErrorResult(fmt.Sprintf("ready_port %d is already in use by another process; stop it or use another port", port))
+	}
+
+	// The task outlives this call; the manager stops it on shutdown
+	id, err := t.tasks.StartWithOptions(context.WithoutCancel(ctx), tasks.StartOptions{
+		Program: "bash",
+		Args:    []string{"-c", command},
+		Stdin:   sdk.GetBoolDefault(args, "stdin", false),
+	})
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("failed to start background task: %s", err))
+	}
+	task, _ := t.tasks.Get(id)
+	data := map[string]string{"task_id": id}
+
+	if pattern == "" && port == 0 {
+		return &sdk.ToolResult{
+			Content: fmt.Sprintf("Started background task %s.\nUse task_output to read its output and task_stop to stop it.", id),
+			Data:    data,
+			Success: true,
+		}
+	}
+
+	start := time.Now()
+	ready, err := waitTaskReady(ctx, task, pattern, port, timeout)
+	tail := task.Output.Tail(20)
+	if err != nil {
+		content := fmt.Sprintf("Background task %s is not ready: %s", id, err)
+		if task.IsRunning() {
+			content += "\nIt is still running; use task_output to inspect it or task_stop to stop it."
+		}
+		if tail != "" {
+			content += "\n\nLast output:\n" + tail
+		}
+		return &sdk.ToolResult{Content: content, Error: err.Error(), Data: data, Success: false}
+	}
+
+	content := fmt.Sprintf("Started background task %s; ready after %.1fs (%s).", id, time.Since(start).Seconds(), ready)
+	if tail != "" {
+		content += "\n\nLast output:\n" + tail
+	}
+	return &sdk.ToolResult{Content: content, Data: data, Success: true}
+}
+
+// run executes command directly with a scrubbed environment.
+func (t *BashTool) run(ctx context.Context, command string) *sdk.ToolResult {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
 	cmd.Dir = t.workDir
 
 	// Use safe environment
@@ -85,48 +256,83 @@ func (t *BashTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 
 	err := cmd.Run()
 
-	if execCtx.Err() == context.DeadlineExceeded {
-		return sdk.NewErrorResult(fmt.Sprintf("command timed out after %v", t.timeout)), nil
+	if ctx.Err() == context.DeadlineExceeded {
+		return sdk.NewErrorResult(fmt.Sprintf("command timed out after %v", t.timeout))
 	}
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			output := stdout.String()
-			if stderr.Len() > 0 {
-				output += "\nSTDERR:\n" + stderr.String()
-			}
-			return &sdk.ToolResult{
-				Content: output,
-				Error:   fmt.Sprintf("command exited with code %d", exitErr.ExitCode()),
-				Success: false,
-			}, nil
+			return commandResult(stdout.String(), stderr.String(), exitErr.ExitCode())
 		}
-		return sdk.NewErrorResult(fmt.Sprintf("command failed: %s", err)), nil
+		return sdk.NewErrorResult(fmt.Sprintf("command failed: %s", err))
 	}
+	return commandResult(stdout.String(), stderr.String(), 0)
+}
 
-	var output strings.Builder
-	if stdout.Len() > 0 {
-		output.WriteString(stdout.String())
+// runSandboxed executes command through security.SandboxedCommand.
+func (t *BashTool) runSandboxed(ctx context.Context, command string) *sdk.ToolResult {
+	workDir := t.workDir
+	if workDir == "" {
+		workDir = "."
+	}
+	sc, err := security.NewSandboxedCommand(ctx, workDir, command, t.policy.Sandbox)
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("sandbox setup failed: %s", err))
+	}
+
+	res := sc.Run(t.timeout)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return sdk.NewErrorResult(fmt.Sprintf("command timed out after %v", t.timeout))
+	}
+	if res.Error != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("command failed: %s", res.Error))
 	}
-	if stderr.Len() > 0 {
+	result := commandResult(string(res.Stdout), string(res.Stderr), res.ExitCode)
+	result.Data.(*sdk.CommandResult).Sandbox = &res.Report
+	return result
+}
+
+// commandResult formats the output of a finished command.
+func commandResult(stdout, stderr string, exitCode int) *sdk.ToolResult {
+	if exitCode != 0 {
+		output := stdout
+		if stderr != "" {
+			output += "\nSTDERR:\n" + stderr
+		}
+		return &sdk.ToolResult{
+			Content: output,
+			Data:    &sdk.CommandResult{ExitCode: exitCode},
+			Error:   fmt.Sprintf("command exited with code %d", exitCode),
+			Success: false,
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString(stdout)
+	if stderr != "" {
 		if output.Len() > 0 {
 			output.WriteString("\n")
 		}
 		output.WriteString("STDERR:\n")
-		output.WriteString(stderr.String())
+		output.WriteString(stderr)
 	}
 
 	result := output.String()
 	const maxLen = 30000
 	if len(result) > maxLen {
-		result = result[:maxLen] + fmt.Sprintf("\n... (output truncated: showing %d of %d characters)", maxLen, len(output.String()))
+		result = result[:maxLen] + fmt.Sprintf("\n... (output truncated: showing %d of %d characters)", maxLen, output.Len())
 	}
 
 	if result == "" {
 		result = "(no output)"
 	}
 
-	return sdk.NewSuccessResult(result), nil
+	return &sdk.ToolResult{
+		Content: result,
+		Data:    &sdk.CommandResult{ExitCode: 0},
+		Success: true,
+	}
 }
 
 // buildSafeEnv creates a sanitized environment for command execution.
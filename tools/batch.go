@@ -22,6 +22,7 @@ type BatchTool struct {
 	workDir          string
 	progressCallback BatchProgressCallback
 	failureThreshold float64 // Stop if failure rate exceeds this (0.0 to 1.0, 0 = disabled)
+	review           changeReview
 }
 
 // NewBatch creates a new BatchTool instance.
@@ -49,6 +50,20 @@ func (t *BatchTool) SetFailureThreshold(threshold float64) {
 	t.failureThreshold = threshold
 }
 
+// SetApprover sets a callback that must accept each batch operation before
+// any file is changed. Replacements are shown as one diff across all files.
+// Without one, the approver carried by the run context is used (see
+// sdk.WithReviewMode).
+func (t *BatchTool) SetApprover(approver sdk.ChangeApprover) {
+	t.review.approver = approver
+}
+
+// SetDiffPreview controls whether replace results carry the combined diff in
+// ExecutionSummary (default: true, as in config.DiffPreviewConfig).
+func (t *BatchTool) SetDiffPreview(enabled bool) {
+	t.review.noPreview = !enabled
+}
+
 func (t *BatchTool) Name() string {
 	return "batch"
 }
@@ -141,8 +156,16 @@ func (t *BatchTool) Execute(ctx context.Context, args map[string]any) (*sdk.Tool
 		return sdk.NewErrorResult("no files matched the pattern or list"), nil
 	}
 
-	// Execute operation
-	var result batchResult
+	// Prepare operation
+	summary := &sdk.ExecutionSummary{
+		ToolName:    t.Name(),
+		DisplayName: t.Name(),
+		Action:      op,
+		Target:      t.describeFiles(files),
+		RiskLevel:   sdk.SafetyLevelCaution,
+		UserVisible: true,
+	}
+	var execute func() batchResult
 	switch op {
 	case "replace":
 		search, _ := sdk.GetString(args, "search")
@@ -150,7 +173,12 @@ func (t *BatchTool) Execute(ctx context.Context, args map[string]any) (*sdk.Tool
 			return sdk.NewErrorResult("search is required for replace operation"), nil
 		}
 		replacement := sdk.GetStringDefault(args, "replacement", "")
-		result = t.executeReplace(ctx, files, search, replacement, dryRun, parallel)
+		if t.review.wantsDiff(ctx) {
+			summary.Diff = t.replaceDiff(files, search, replacement)
+		}
+		execute = func() batchResult {
+			return t.executeReplace(ctx, files, search, replacement, dryRun, parallel)
+		}
 
 	case "rename":
 		from, _ := sdk.GetString(args, "rename_from")
@@ -158,16 +186,122 @@ func (t *BatchTool) Execute(ctx context.Context, args map[string]any) (*sdk.Tool
 		if from == "" || to == "" {
 			return sdk.NewErrorResult("rename_from and rename_to are required for rename operation"), nil
 		}
-		result = t.executeRename(ctx, files, from, to, dryRun)
+		renames := t.renamePairs(files, from, to)
+		summary.Target = describeNames(renames)
+		if t.review.wantsDiff(ctx) {
+			summary.Diff = renameDiff(renames)
+		}
+		execute = func() batchResult {
+			return t.executeRename(ctx, files, from, to, dryRun)
+		}
 
 	case "delete":
-		result = t.executeDelete(ctx, files, dryRun)
+		summary.RiskLevel = sdk.SafetyLevelDangerous
+		if t.review.wantsDiff(ctx) {
+			summary.Diff = t.deleteDiff(files)
+		}
+		execute = func() batchResult {
+			return t.executeDelete(ctx, files, dryRun)
+		}
 
 	default:
 		return sdk.NewErrorResult(fmt.Sprintf("unknown operation: %s", op)), nil
 	}
 
-	return t.formatResult(op, result, dryRun), nil
+	// Wait for approval, then execute operation
+	if !dryRun {
+		if err := t.review.approve(ctx, summary); err != nil {
+			return rejectedResult(summary, err), nil
+		}
+	}
+	result := t.formatResult(op, execute(), dryRun)
+	if dryRun && summary.Diff != "" {
+		result.Content += "\n" + summary.Diff
+	}
+	result.ExecutionSummary = summary
+	return result, nil
+}
+
+// describeFiles names the target files of an operation, relative to the
+// working directory, abbreviating long lists.
+func (t *BatchTool) describeFiles(files []string) string {
+	names := make([]string, len(files))
+	for i, path := range files {
+		names[i] = t.relPath(path)
+	}
+	return describeNames(names)
+}
+
+// describeNames joins names, abbreviating long lists.
+func describeNames(names []string) string {
+	const maxNamed = 10
+	if len(names) > maxNamed {
+		names = append(names[:maxNamed:maxNamed], fmt.Sprintf("and %d more", len(names)-maxNamed))
+	}
+	return strings.Join(names, ", ")
+}
+
+// renamePairs lists the renames of a rename operation as "old -> new",
+// relative to the working directory.
+func (t *BatchTool) renamePairs(files []string, from, to string) []string {
+	var pairs []string
+	for _, path := range files {
+		base := filepath.Base(path)
+		if !strings.Contains(base, from) {
+			continue
+		}
+		newPath := filepath.Join(filepath.Dir(path), strings.ReplaceAll(base, from, to))
+		pairs = append(pairs, t.relPath(path)+" -> "+t.relPath(newPath))
+	}
+	return pairs
+}
+
+// renameDiff returns git-style rename headers for rename pairs.
+func renameDiff(pairs []string) string {
+	var sb strings.Builder
+	for _, pair := range pairs {
+		oldRel, newRel, _ := strings.Cut(pair, " -> ")
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\nrename from %s\nrename to %s\n", oldRel, newRel, oldRel, newRel)
+	}
+	return sb.String()
+}
+
+// deleteDiff returns the combined diff of a delete operation.
+func (t *BatchTool) deleteDiff(files []string) string {
+	var sb strings.Builder
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		sb.WriteString(UnifiedDiff("a/"+t.relPath(path), "/dev/null", string(data), "", 3))
+	}
+	return sb.String()
+}
+
+// replaceDiff returns the combined diff of a replace operation.
+func (t *BatchTool) replaceDiff(files []string, search, replacement string) string {
+	var sb strings.Builder
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(data), search) {
+			continue
+		}
+		rel := t.relPath(path)
+		sb.WriteString(UnifiedDiff("a/"+rel, "b/"+rel, string(data), strings.ReplaceAll(string(data), search, replacement), 3))
+	}
+	return sb.String()
+}
+
+func (t *BatchTool) relPath(path string) string {
+	if t.workDir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(t.workDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
 }
 
 // batchResult holds the results of a batch operation.
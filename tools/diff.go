@@ -85,10 +85,7 @@ func (t *DiffTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		data2 = []byte(content)
 	}
 
-	lines1 := strings.Split(string(data1), "\n")
-	lines2 := strings.Split(string(data2), "\n")
-
-	diff := unifiedDiff(file1, label2, lines1, lines2, contextLines)
+	diff := unifiedDiff(file1, label2, diffLines(string(data1)), diffLines(string(data2)), contextLines)
 	if diff == "" {
 		return sdk.NewSuccessResult("Files are identical"), nil
 	}
@@ -96,22 +93,64 @@ func (t *DiffTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 	return sdk.NewSuccessResult(diff), nil
 }
 
+// UnifiedDiff returns a unified diff between two texts, or "" if they are
+// identical.
+func UnifiedDiff(oldLabel, newLabel, oldText, newText string, contextLines int) string {
+	return unifiedDiff(oldLabel, newLabel, diffLines(oldText), diffLines(newText), contextLines)
+}
+
+// noNewlineMarker follows the last line of a text that does not end in a
+// newline.
+const noNewlineMarker = `\ No newline at end of file`
+
+// diffLines splits text into lines. A missing final newline is kept as a
+// marker on the last line, so it is compared like content and printed on
+// its own line the way diff(1) and git print it.
+func diffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += "\n" + noNewlineMarker
+	}
+	return lines
+}
+
+// maxDiffCells bounds the LCS table of unifiedDiff. Larger changed regions
+// are shown as a single replacement.
+const maxDiffCells = 4 << 20
+
 // unifiedDiff produces a unified diff between two sets of lines.
 func unifiedDiff(label1, label2 string, a, b []string, contextLines int) string {
-	// Find longest common subsequence using dynamic programming
+	// Lines shared at both ends are never part of a change
 	m, n := len(a), len(b)
-	dp := make([][]int, m+1)
-	for i := range dp {
-		dp[i] = make([]int, n+1)
-	}
-	for i := m - 1; i >= 0; i-- {
-		for j := n - 1; j >= 0; j-- {
-			if a[i] == b[j] {
-				dp[i][j] = dp[i+1][j+1] + 1
-			} else if dp[i+1][j] > dp[i][j+1] {
-				dp[i][j] = dp[i+1][j]
-			} else {
-				dp[i][j] = dp[i][j+1]
+	prefix := 0
+	for prefix < m && prefix < n && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < m-prefix && suffix < n-prefix && a[m-1-suffix] == b[n-1-suffix] {
+		suffix++
+	}
+
+	// Find longest common subsequence of the rest using dynamic programming
+	midA, midB := a[prefix:m-suffix], b[prefix:n-suffix]
+	var dp [][]int
+	if len(midA)*len(midB) <= maxDiffCells {
+		dp = make([][]int, len(midA)+1)
+		for i := range dp {
+			dp[i] = make([]int, len(midB)+1)
+		}
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					dp[i][j] = dp[i+1][j+1] + 1
+				} else if dp[i+1][j] > dp[i][j+1] {
+					dp[i][j] = dp[i+1][j]
+				} else {
+					dp[i][j] = dp[i][j+1]
+				}
 			}
 		}
 	}
@@ -125,19 +164,54 @@ func unifiedDiff(label1, label2 string, a, b []string, contextLines int) string
 	}
 
 	var ops []editOp
+	for k := 0; k < prefix; k++ {
+		ops = append(ops, editOp{'=', a[k], k, k})
+	}
 	i, j := 0, 0
-	for i < m || j < n {
-		if i < m && j < n && a[i] == b[j] {
-			ops = append(ops, editOp{'=', a[i], i, j})
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case dp != nil && i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			ops = append(ops, editOp{'=', midA[i], prefix + i, prefix + j})
 			i++
 			j++
-		} else if j < n && (i >= m || dp[i][j+1] >= dp[i+1][j]) {
-			ops = append(ops, editOp{'+', b[j], i, j})
-			j++
-		} else if i < m {
-			ops = append(ops, editOp{'-', a[i], i, j})
+		case i < len(midA) && (j >= len(midB) || dp == nil || dp[i+1][j] > dp[i][j+1]):
+			ops = append(ops, editOp{'-', midA[i], prefix + i, prefix + j})
 			i++
+		default:
+			ops = append(ops, editOp{'+', midB[j], prefix + i, prefix + j})
+			j++
+		}
+	}
+	for k := suffix; k > 0; k-- {
+		ops = append(ops, editOp{'=', a[m-k], m - k, n - k})
+	}
+
+	// Within each run of changes, list deletions before additions, as git does
+	for start := 0; start < len(ops); {
+		if ops[start].kind == '=' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end].kind != '=' {
+			end++
+		}
+		aIdx, bIdx := ops[start].aIdx, ops[start].bIdx
+		run := make([]editOp, 0, end-start)
+		for _, op := range ops[start:end] {
+			if op.kind == '-' {
+				run = append(run, editOp{'-', op.line, aIdx, bIdx})
+				aIdx++
+			}
 		}
+		for _, op := range ops[start:end] {
+			if op.kind == '+' {
+				run = append(run, editOp{'+', op.line, aIdx, bIdx})
+				bIdx++
+			}
+		}
+		copy(ops[start:end], run)
+		start = end
 	}
 
 	// Check if there are any changes
@@ -178,7 +252,7 @@ func unifiedDiff(label1, label2 string, a, b []string, contextLines int) string
 					startCtx = 0
 				}
 				currentHunk = hunk{}
-				if len(contextBefore) > 0 {
+				if startCtx < len(contextBefore) {
 					for _, c := range contextBefore[startCtx:] {
 						currentHunk.lines = append(currentHunk.lines, " "+c.line)
 						currentHunk.countA++
@@ -204,6 +278,24 @@ func unifiedDiff(label1, label2 string, a, b []string, contextLines int) string
 			contextBefore = contextBefore[:0]
 		} else {
 			if inHunk {
+				// Check if next change is within context range
+				nextChangeIdx := -1
+				for k := idx + 1; k < len(ops); k++ {
+					if ops[k].kind != '=' {
+						nextChangeIdx = k
+						break
+					}
+				}
+				far := nextChangeIdx == -1 || nextChangeIdx-idx > 2*contextLines
+
+				// Without context lines, the hunk ends at the change
+				if contextLines == 0 && far {
+					hunks = append(hunks, currentHunk)
+					inHunk = false
+					contextBefore = append(contextBefore[:0], op)
+					continue
+				}
+
 				// Add context after
 				currentHunk.lines = append(currentHunk.lines, " "+op.line)
 				currentHunk.countA++
@@ -219,16 +311,7 @@ func unifiedDiff(label1, label2 string, a, b []string, contextLines int) string
 					}
 				}
 
-				// Check if next change is within context range
-				nextChangeIdx := -1
-				for k := idx + 1; k < len(ops); k++ {
-					if ops[k].kind != '=' {
-						nextChangeIdx = k
-						break
-					}
-				}
-
-				if trailingCtx >= contextLines && (nextChangeIdx == -1 || nextChangeIdx-idx > 2*contextLines) {
+				if trailingCtx >= contextLines && far {
 					hunks = append(hunks, currentHunk)
 					inHunk = false
 					contextBefore = contextBefore[:0]
@@ -244,6 +327,13 @@ func unifiedDiff(label1, label2 string, a, b []string, contextLines int) string
 	}
 
 	for _, h := range hunks {
+		// An empty range starts at the line before it
+		if h.countA == 0 {
+			h.startA--
+		}
+		if h.countB == 0 {
+			h.startB--
+		}
 		builder.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", h.startA, h.countA, h.startB, h.countB))
 		for _, line := range h.lines {
 			builder.WriteString(line)
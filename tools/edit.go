@@ -12,13 +12,28 @@ import (
 )
 
 // EditTool performs string replacement editing on files.
-type EditTool struct{}
+type EditTool struct {
+	review changeReview
+}
 
 // NewEdit creates a new EditTool.
 func NewEdit() *EditTool {
 	return &EditTool{}
 }
 
+// SetApprover sets a callback that must accept each edit, shown as a diff,
+// before the file is written. Without one, the approver carried by the run
+// context is used (see sdk.WithReviewMode).
+func (t *EditTool) SetApprover(approver sdk.ChangeApprover) {
+	t.review.approver = approver
+}
+
+// SetDiffPreview controls whether results carry the diff of the edit in
+// ExecutionSummary (default: true, as in config.DiffPreviewConfig).
+func (t *EditTool) SetDiffPreview(enabled bool) {
+	t.review.noPreview = !enabled
+}
+
 func (t *EditTool) Name() string { return "edit" }
 
 func (t *EditTool) Description() string {
@@ -114,10 +129,18 @@ func (t *EditTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		newContent = strings.Replace(content, oldString, newString, 1)
 	}
 
+	// Preview the change and wait for approval
+	summary := t.review.fileChangeSummary(ctx, t.Name(), "edit", filePath, content, newContent, false)
+	if err := t.review.approve(ctx, summary); err != nil {
+		return rejectedResult(summary, err), nil
+	}
+
 	// Write back
 	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
 		return sdk.NewErrorResult(fmt.Sprintf("error writing file: %s", err)), nil
 	}
 
-	return sdk.NewSuccessResult(fmt.Sprintf("Replaced %d occurrence(s) in %s", replacements, filePath)), nil
+	result := sdk.NewSuccessResult(fmt.Sprintf("Replaced %d occurrence(s) in %s", replacements, filePath))
+	result.ExecutionSummary = summary
+	return result, nil
 }
@@ -9,19 +9,29 @@ import (
 	"strings"
 
 	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/cache"
 
-	"github.com/bmatcuk/doublestar/v4"
 	"google.golang.org/genai"
 )
 
 // GlobTool finds files matching a glob pattern.
 type GlobTool struct {
 	workDir string
+	scope   *SearchScope
 }
 
-// NewGlob creates a new GlobTool with the given working directory.
+// NewGlob creates a new GlobTool with the given working directory. It skips
+// files ignored under DefaultSearchOptions.
 func NewGlob(workDir string) *GlobTool {
-	return &GlobTool{workDir: workDir}
+	return &GlobTool{
+		workDir: workDir,
+		scope:   NewSearchScope(workDir, DefaultSearchOptions()),
+	}
+}
+
+// SetScope sets the ignore rules and cache used for searches.
+func (t *GlobTool) SetScope(scope *SearchScope) {
+	t.scope = scope
 }
 
 func (t *GlobTool) Name() string { return "glob" }
@@ -69,10 +79,42 @@ func (t *GlobTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		return sdk.NewErrorResult(fmt.Sprintf("error accessing path: %s", err)), nil
 	}
 
-	fullPattern := filepath.Join(searchPath, pattern)
-	matches, err := doublestar.FilepathGlob(fullPattern)
+	key := cache.GlobKey(pattern, searchPath)
+	result, cached := t.scope.cachedGlob(key)
+	if !cached {
+		files, err := t.find(ctx, searchPath, pattern)
+		if err != nil {
+			if ctx.Err() != nil {
+				return sdk.NewErrorResult(fmt.Sprintf("search cancelled: %s", ctx.Err())), nil
+			}
+			return sdk.NewErrorResult(fmt.Sprintf("invalid pattern: %s", err)), nil
+		}
+		result = cache.GlobResult{Files: files}
+		t.scope.storeGlob(key, searchPath, result)
+	}
+
+	if len(result.Files) == 0 {
+		return sdk.NewSuccessResult("(no matches)"), nil
+	}
+
+	var builder strings.Builder
+	for _, path := range result.Files {
+		relPath, err := filepath.Rel(t.workDir, path)
+		if err != nil {
+			relPath = path
+		}
+		builder.WriteString(relPath)
+		builder.WriteString("\n")
+	}
+
+	return sdk.NewSuccessResult(builder.String()), nil
+}
+
+// find returns matching files, newest first.
+func (t *GlobTool) find(ctx context.Context, searchPath, pattern string) ([]string, error) {
+	matches, err := t.scope.glob(ctx, searchPath, pattern)
 	if err != nil {
-		return sdk.NewErrorResult(fmt.Sprintf("invalid pattern: %s", err)), nil
+		return nil, err
 	}
 
 	type fileInfo struct {
@@ -102,19 +144,9 @@ func (t *GlobTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		files = files[:maxResults]
 	}
 
-	if len(files) == 0 {
-		return sdk.NewSuccessResult("(no matches)"), nil
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
 	}
-
-	var builder strings.Builder
-	for _, f := range files {
-		relPath, err := filepath.Rel(t.workDir, f.path)
-		if err != nil {
-			relPath = f.path
-		}
-		builder.WriteString(relPath)
-		builder.WriteString("\n")
-	}
-
-	return sdk.NewSuccessResult(builder.String()), nil
+	return paths, nil
 }
@@ -0,0 +1,508 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/semantic"
+
+	"google.golang.org/genai"
+)
+
+// GoSymbol is a top-level Go declaration reported by go_symbols.
+type GoSymbol struct {
+	Path      string `json:"path"`
+	Name      string `json:"name"` // "Recv.Name" for methods
+	Kind      string `json:"kind"` // "func", "method", "type", "var" or "const"
+	Signature string `json:"signature"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"end_line"`
+	Exported  bool   `json:"exported"`
+	Source    string `json:"source,omitempty"` // set when reading one symbol
+}
+
+// GoSymbolsTool lists the declarations of Go files and packages and reads
+// single declarations by name.
+type GoSymbolsTool struct {
+	workDir string
+}
+
+// NewGoSymbols creates a new GoSymbolsTool.
+func NewGoSymbols(workDir string) *GoSymbolsTool {
+	return &GoSymbolsTool{workDir: workDir}
+}
+
+func (t *GoSymbolsTool) Name() string { return "go_symbols" }
+
+func (t *GoSymbolsTool) Description() string {
+	return "Lists the top-level declarations (functions, methods, types, vars, consts) of a Go file or package " +
+		"with their signatures and line ranges, or returns the full source of one declaration by name. " +
+		"Cheaper than reading whole files when navigating Go code."
+}
+
+func (t *GoSymbolsTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"path": {
+					Type:        genai.TypeString,
+					Description: "A Go file, or a package directory (default: working directory)",
+				},
+				"name": {
+					Type:        genai.TypeString,
+					Description: "Return the source of this declaration: 'Func', 'Type', 'Type.Method' or 'Method'",
+				},
+				"exported_only": {
+					Type:        genai.TypeBoolean,
+					Description: "List only exported declarations (default: false)",
+				},
+				"include_tests": {
+					Type:        genai.TypeBoolean,
+					Description: "Include _test.go files of a package (default: false)",
+				},
+			},
+		},
+	}
+}
+
+func (t *GoSymbolsTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	path := resolveGoPath(t.workDir, sdk.GetStringDefault(args, "path", ""))
+	name := sdk.GetStringDefault(args, "name", "")
+	exportedOnly := sdk.GetBoolDefault(args, "exported_only", false)
+	includeTests := sdk.GetBoolDefault(args, "include_tests", false)
+
+	files, err := goFiles(path, includeTests)
+	if err != nil {
+		return sdk.NewErrorResult(err.Error()), nil
+	}
+	if len(files) == 0 {
+		return sdk.NewErrorResult(fmt.Sprintf("no Go files in %s", path)), nil
+	}
+
+	var sb strings.Builder
+	var symbols []GoSymbol
+	var matches []GoSymbol
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return sdk.NewErrorResult(err.Error()), nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s: %s\n", t.relPath(file), err)
+			continue
+		}
+		gf, err := semantic.ParseGoFile(file, string(data))
+		if err != nil {
+			fmt.Fprintf(&sb, "%s: parse error: %s\n", t.relPath(file), err)
+			continue
+		}
+
+		if name == "" {
+			fmt.Fprintf(&sb, "%s (package %s)\n", t.relPath(file), gf.AST.Name.Name)
+		}
+		for _, d := range gf.Decls() {
+			symbol := GoSymbol{
+				Path:      t.relPath(file),
+				Name:      d.Name,
+				Kind:      d.Kind,
+				Signature: d.Signature,
+				Line:      d.LineStart,
+				EndLine:   d.LineEnd,
+				Exported:  d.Exported,
+			}
+			if name != "" {
+				if matchesGoName(d, name) {
+					symbol.Source = d.Source(gf.Content)
+					matches = append(matches, symbol)
+				}
+				continue
+			}
+			if exportedOnly && !d.Exported {
+				continue
+			}
+			fmt.Fprintf(&sb, "  %d-%d  %s\n", d.LineStart, d.LineEnd, d.Signature)
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if name == "" {
+		return &sdk.ToolResult{Content: sb.String(), Data: symbols, Success: true}, nil
+	}
+
+	switch len(matches) {
+	case 0:
+		return sdk.NewErrorResult(fmt.Sprintf("no declaration named %s in %s", name, t.relPath(path))), nil
+	case 1:
+		m := matches[0]
+		content := fmt.Sprintf("%s:%d-%d (%s %s)\n\n%s\n", m.Path, m.Line, m.EndLine, m.Kind, m.Name, m.Source)
+		return &sdk.ToolResult{Content: content, Data: m, Success: true}, nil
+	default:
+		// The same method name on several types, or several files
+		var candidates []string
+		for _, m := range matches {
+			candidates = append(candidates, fmt.Sprintf("%s (%s:%d)", m.Name, m.Path, m.Line))
+		}
+		return sdk.NewErrorResult(fmt.Sprintf("%s is ambiguous; use one of: %s", name, strings.Join(candidates, ", "))), nil
+	}
+}
+
+func (t *GoSymbolsTool) relPath(path string) string {
+	return relGoPath(t.workDir, path)
+}
+
+// GoEditTool edits Go declarations through the AST: it replaces a
+// function's body or a whole declaration and adds imports, then formats
+// the file with go/format.
+type GoEditTool struct {
+	workDir string
+	review  changeReview
+}
+
+// NewGoEdit creates a new GoEditTool.
+func NewGoEdit(workDir string) *GoEditTool {
+	return &GoEditTool{workDir: workDir}
+}
+
+// SetApprover sets a callback that must accept each edit, shown as a diff,
+// before the file is written. Without one, the approver carried by the run
+// context is used (see sdk.WithReviewMode).
+func (t *GoEditTool) SetApprover(approver sdk.ChangeApprover) {
+	t.review.approver = approver
+}
+
+// SetDiffPreview controls whether results carry the diff of the edit in
+// ExecutionSummary (default: true, as in config.DiffPreviewConfig).
+func (t *GoEditTool) SetDiffPreview(enabled bool) {
+	t.review.noPreview = !enabled
+}
+
+func (t *GoEditTool) Name() string { return "go_edit" }
+
+func (t *GoEditTool) Description() string {
+	return "Edits a Go file by declaration instead of by text: replaces the body of a function or method, " +
+		"replaces a whole declaration, and adds imports. The result must parse and is formatted with gofmt; " +
+		"otherwise nothing is written."
+}
+
+func (t *GoEditTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"file_path": {
+					Type:        genai.TypeString,
+					Description: "The Go file to edit",
+				},
+				"name": {
+					Type:        genai.TypeString,
+					Description: "The declaration to change: 'Func', 'Type', 'Type.Method' or 'Method'",
+				},
+				"body": {
+					Type:        genai.TypeString,
+					Description: "New statements for the function's body, without the enclosing braces",
+				},
+				"code": {
+					Type: genai.TypeString,
+					Description: "New source for the whole declaration. Start it with a comment to replace the doc " +
+						"comment too; otherwise the existing doc comment is kept",
+				},
+				"imports": {
+					Type:        genai.TypeArray,
+					Description: "Import paths to add, optionally with a name: 'fmt', 'errs \"errors\"'",
+					Items:       &genai.Schema{Type: genai.TypeString},
+				},
+				"dry_run": {
+					Type:        genai.TypeBoolean,
+					Description: "Show the diff without changing the file (default: false)",
+				},
+			},
+			Required: []string{"file_path"},
+		},
+	}
+}
+
+func (t *GoEditTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	filePath := sdk.GetStringDefault(args, "file_path", "")
+	if filePath == "" {
+		return sdk.NewErrorResult("file_path is required"), nil
+	}
+	path := resolveGoPath(t.workDir, filePath)
+	name := sdk.GetStringDefault(args, "name", "")
+	body, hasBody := sdk.GetString(args, "body")
+	code, hasCode := sdk.GetString(args, "code")
+	dryRun := sdk.GetBoolDefault(args, "dry_run", false)
+
+	var imports []string
+	if list, ok := args["imports"].([]any); ok {
+		for _, item := range list {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				imports = append(imports, strings.TrimSpace(s))
+			}
+		}
+	}
+
+	if hasBody && hasCode {
+		return sdk.NewErrorResult("give either body or code, not both"), nil
+	}
+	if (hasBody || hasCode) && name == "" {
+		return sdk.NewErrorResult("name is required to replace a body or declaration"), nil
+	}
+	if !hasBody && !hasCode && len(imports) == 0 {
+		return sdk.NewErrorResult("nothing to do: give body, code or imports"), nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("error reading file: %s", err)), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("error reading file: %s", err)), nil
+	}
+	original := string(data)
+	content := original
+	var done []string
+
+	// Replace the declaration or its body
+	if hasBody || hasCode {
+		gf, err := semantic.ParseGoFile(path, content)
+		if err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("cannot parse %s: %s", t.relPath(path), err)), nil
+		}
+		var found []semantic.GoDecl
+		for _, d := range gf.Decls() {
+			if matchesGoName(d, name) {
+				found = append(found, d)
+			}
+		}
+		if len(found) == 0 {
+			return sdk.NewErrorResult(fmt.Sprintf("no declaration named %s in %s", name, t.relPath(path))), nil
+		}
+		if len(found) > 1 {
+			var names []string
+			for _, d := range found {
+				names = append(names, d.Name)
+			}
+			return sdk.NewErrorResult(fmt.Sprintf("%s is ambiguous; use one of: %s", name, strings.Join(names, ", "))), nil
+		}
+		d := found[0]
+
+		if hasBody {
+			if d.BodyStart < 0 {
+				return sdk.NewErrorResult(fmt.Sprintf("%s is a %s without a body; use code to replace it", d.Name, d.Kind)), nil
+			}
+			body = strings.TrimSpace(body)
+			if strings.HasPrefix(body, "{") && strings.HasSuffix(body, "}") {
+				body = strings.TrimSpace(body[1 : len(body)-1])
+			}
+			content = content[:d.BodyStart] + "{\n" + body + "\n}" + content[d.BodyEnd:]
+			done = append(done, "replaced body of "+d.Name)
+		} else {
+			start := d.Start
+			trimmed := strings.TrimSpace(code)
+			if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") {
+				start = d.DocStart
+			}
+			content = content[:start] + trimmed + content[d.End:]
+			done = append(done, "replaced "+d.Kind+" "+d.Name)
+		}
+	}
+
+	// Add imports
+	if len(imports) > 0 {
+		updated, added, err := addGoImports(path, content, imports)
+		if err != nil {
+			return sdk.NewErrorResult(err.Error()), nil
+		}
+		content = updated
+		if len(added) > 0 {
+			done = append(done, "added import(s) "+strings.Join(added, ", "))
+		}
+	}
+
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("edit would not produce valid Go (%s); nothing was written", err)), nil
+	}
+	content = string(formatted)
+	if content == original {
+		return sdk.NewSuccessResult(fmt.Sprintf("No changes to %s", t.relPath(path))), nil
+	}
+
+	rel := t.relPath(path)
+	review := t.review
+	if dryRun {
+		// A dry run always shows its diff
+		review.noPreview = false
+	}
+	summary := review.fileChangeSummary(ctx, t.Name(), "edit", rel, original, content, false)
+	if dryRun {
+		result := sdk.NewSuccessResult(fmt.Sprintf("Dry run: %s in %s. No files were changed.\n\n%s", strings.Join(done, "; "), rel, summary.Diff))
+		result.ExecutionSummary = summary
+		return result, nil
+	}
+	if err := t.review.approve(ctx, summary); err != nil {
+		return rejectedResult(summary, err), nil
+	}
+
+	if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("error writing file: %s", err)), nil
+	}
+
+	result := sdk.NewSuccessResult(fmt.Sprintf("Updated %s: %s", rel, strings.Join(done, "; ")))
+	result.ExecutionSummary = summary
+	return result, nil
+}
+
+func (t *GoEditTool) relPath(path string) string {
+	return relGoPath(t.workDir, path)
+}
+
+// addGoImports adds import specs to Go source, skipping ones already
+// present. It returns the new source and the specs it added.
+func addGoImports(path, content string, imports []string) (string, []string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot parse %s: %s", filepath.Base(path), err)
+	}
+
+	existing := make(map[string]string) // path → name
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		existing[p] = ""
+		if spec.Name != nil {
+			existing[p] = spec.Name.Name
+		}
+	}
+
+	var specs, added []string
+	for _, imp := range imports {
+		name, importPath := "", imp
+		if fields := strings.Fields(imp); len(fields) == 2 {
+			name, importPath = fields[0], fields[1]
+		} else if len(fields) != 1 {
+			return "", nil, fmt.Errorf("invalid import %q", imp)
+		}
+		if unquoted, err := strconv.Unquote(importPath); err == nil {
+			importPath = unquoted
+		}
+		if have, ok := existing[importPath]; ok && have == name {
+			continue
+		}
+		existing[importPath] = name
+		spec := strconv.Quote(importPath)
+		if name != "" {
+			spec = name + " " + spec
+		}
+		specs = append(specs, spec)
+		added = append(added, spec)
+	}
+	if len(specs) == 0 {
+		return content, nil, nil
+	}
+	block := "\t" + strings.Join(specs, "\n\t") + "\n"
+
+	// Extend the last import declaration, or add one after the package clause
+	var last *ast.GenDecl
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			last = gen
+		}
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	switch {
+	case last == nil:
+		at := offset(f.Name.End())
+		return content[:at] + "\n\nimport (\n" + block + ")" + content[at:], added, nil
+	case last.Lparen.IsValid():
+		at := offset(last.Rparen)
+		if !strings.HasSuffix(content[:at], "\n") {
+			block = "\n" + block
+		}
+		return content[:at] + block + content[at:], added, nil
+	default:
+		spec := last.Specs[0]
+		current := content[offset(spec.Pos()):offset(spec.End())]
+		start, end := offset(last.Pos()), offset(last.End())
+		return content[:start] + "import (\n\t" + current + "\n" + block + ")" + content[end:], added, nil
+	}
+}
+
+// matchesGoName reports whether a declaration answers to name: its full
+// name, or a method's name without the receiver type.
+func matchesGoName(d semantic.GoDecl, name string) bool {
+	if d.Name == name {
+		return true
+	}
+	return d.Kind == "method" && strings.HasSuffix(d.Name, "."+name)
+}
+
+// goFiles returns the Go file at path, or the Go files of the package
+// directory at path.
+func goFiles(path string, includeTests bool) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if filepath.Ext(path) != ".go" {
+			return nil, fmt.Errorf("%s is not a Go file", path)
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".go" {
+			continue
+		}
+		if !includeTests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(path, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func resolveGoPath(workDir, path string) string {
+	if path == "" {
+		return workDir
+	}
+	if !filepath.IsAbs(path) && workDir != "" {
+		return filepath.Join(workDir, path)
+	}
+	return path
+}
+
+func relGoPath(workDir, path string) string {
+	if workDir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -10,27 +10,99 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/cache"
+	"github.com/ginkida/gokin-sdk/semantic"
 
-	"github.com/bmatcuk/doublestar/v4"
 	"google.golang.org/genai"
 )
 
+// Grep output modes.
+const (
+	GrepModeContent = "content"
+	GrepModeFiles   = "files_with_matches"
+	GrepModeCount   = "count"
+)
+
+const (
+	// defaultGrepLimit is the default page size.
+	defaultGrepLimit = 500
+
+	// maxGrepCollect caps the matches collected by one search.
+	maxGrepCollect = 10000
+
+	// maxGrepContext caps context lines on either side of a match.
+	maxGrepContext = 20
+
+	// maxGrepLineLen truncates long lines in results.
+	maxGrepLineLen = 500
+)
+
+// grepTypeAliases maps common short names to semantic.DetectLanguage names.
+var grepTypeAliases = map[string]string{
+	"py": "python", "js": "javascript", "ts": "typescript",
+	"rs": "rust", "rb": "ruby", "c++": "cpp",
+}
+
+// grepTypes lists the languages semantic.DetectLanguage recognizes.
+var grepTypes = []string{"go", "python", "javascript", "typescript", "java", "c", "cpp", "rust", "ruby", "php"}
+
+// GrepOutput is the structured result of a grep call, set as ToolResult.Data.
+type GrepOutput struct {
+	Mode       string      `json:"mode"`
+	Total      int         `json:"total"` // matches, or files in files_with_matches mode
+	Files      int         `json:"file_count"`
+	Offset     int         `json:"offset"`
+	NextOffset int         `json:"next_offset,omitempty"` // set when more results follow
+	Truncated  bool        `json:"truncated,omitempty"`   // search stopped at its match limit
+	Matches    []GrepHit   `json:"matches,omitempty"`
+	FileList   []string    `json:"files,omitempty"`
+	Counts     []GrepCount `json:"counts,omitempty"`
+}
+
+// GrepHit is one match with its surrounding lines.
+type GrepHit struct {
+	File    string   `json:"file"` // relative to the working directory
+	Line    int      `json:"line"`
+	EndLine int      `json:"end_line,omitempty"` // last line of a multiline match
+	Text    string   `json:"text"`
+	Before  []string `json:"before,omitempty"`
+	After   []string `json:"after,omitempty"`
+}
+
+// GrepCount is the number of matches in one file.
+type GrepCount struct {
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
 // GrepTool searches for patterns in files.
 type GrepTool struct {
 	workDir string
+	scope   *SearchScope
 }
 
-// NewGrep creates a new GrepTool with the given working directory.
+// NewGrep creates a new GrepTool with the given working directory. It skips
+// files ignored under DefaultSearchOptions.
 func NewGrep(workDir string) *GrepTool {
-	return &GrepTool{workDir: workDir}
+	return &GrepTool{
+		workDir: workDir,
+		scope:   NewSearchScope(workDir, DefaultSearchOptions()),
+	}
+}
+
+// SetScope sets the ignore rules and cache used for searches.
+func (t *GrepTool) SetScope(scope *SearchScope) {
+	t.scope = scope
 }
 
 func (t *GrepTool) Name() string { return "grep" }
 
 func (t *GrepTool) Description() string {
-	return "Searches for a regex pattern in files. Returns matching lines with file paths and line numbers."
+	return "Searches for a regex pattern in files. Returns matching lines with file paths and line numbers, " +
+		"optionally with context lines, or only matching files or per-file counts. Large results are paged."
 }
 
 func (t *GrepTool) Declaration() *genai.FunctionDeclaration {
@@ -52,10 +124,47 @@ func (t *GrepTool) Declaration() *genai.FunctionDeclaration {
 					Type:        genai.TypeString,
 					Description: "Glob pattern to filter files (e.g., '*.go', '**/*.ts')",
 				},
+				"type": {
+					Type:        genai.TypeString,
+					Description: "Only search files of these languages, comma-separated: " + strings.Join(grepTypes, ", "),
+				},
 				"case_insensitive": {
 					Type:        genai.TypeBoolean,
 					Description: "If true, search is case-insensitive",
 				},
+				"literal": {
+					Type:        genai.TypeBoolean,
+					Description: "If true, treat pattern as a plain string rather than a regex",
+				},
+				"multiline": {
+					Type:        genai.TypeBoolean,
+					Description: "If true, the pattern may span lines (use \\n or [\\s\\S] to match line breaks)",
+				},
+				"output_mode": {
+					Type:        genai.TypeString,
+					Description: "'content' (matching lines, default), 'files_with_matches' (file paths only) or 'count' (matches per file)",
+					Enum:        []string{GrepModeContent, GrepModeFiles, GrepModeCount},
+				},
+				"context": {
+					Type:        genai.TypeInteger,
+					Description: "Lines of context before and after each match (content mode)",
+				},
+				"before_context": {
+					Type:        genai.TypeInteger,
+					Description: "Lines of context before each match; overrides context",
+				},
+				"after_context": {
+					Type:        genai.TypeInteger,
+					Description: "Lines of context after each match; overrides context",
+				},
+				"max_results": {
+					Type:        genai.TypeInteger,
+					Description: fmt.Sprintf("Maximum matches (or files) to return (default: %d)", defaultGrepLimit),
+				},
+				"offset": {
+					Type:        genai.TypeInteger,
+					Description: "Skip this many matches (or files), to page through large results",
+				},
 			},
 			Required: []string{"pattern"},
 		},
@@ -71,14 +180,46 @@ func (t *GrepTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 	searchPath := sdk.GetStringDefault(args, "path", t.workDir)
 	globPattern := sdk.GetStringDefault(args, "glob", "")
 	caseInsensitive := sdk.GetBoolDefault(args, "case_insensitive", false)
+	literal := sdk.GetBoolDefault(args, "literal", false)
+	multiline := sdk.GetBoolDefault(args, "multiline", false)
+	mode := sdk.GetStringDefault(args, "output_mode", GrepModeContent)
+	contextLines := clampInt(sdk.GetIntDefault(args, "context", 0), 0, maxGrepContext)
+	before := clampInt(sdk.GetIntDefault(args, "before_context", contextLines), 0, maxGrepContext)
+	after := clampInt(sdk.GetIntDefault(args, "after_context", contextLines), 0, maxGrepContext)
+	limit := sdk.GetIntDefault(args, "max_results", defaultGrepLimit)
+	offset := sdk.GetIntDefault(args, "offset", 0)
+
+	switch mode {
+	case GrepModeContent, GrepModeFiles, GrepModeCount:
+	default:
+		return sdk.NewErrorResult(fmt.Sprintf("invalid output_mode: %s (use %s, %s or %s)",
+			mode, GrepModeContent, GrepModeFiles, GrepModeCount)), nil
+	}
+	if limit <= 0 {
+		limit = defaultGrepLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	types, err := parseGrepTypes(sdk.GetStringDefault(args, "type", ""))
+	if err != nil {
+		return sdk.NewErrorResult(err.Error()), nil
+	}
 
 	if !filepath.IsAbs(searchPath) {
 		searchPath = filepath.Join(t.workDir, searchPath)
 	}
 
 	regexPattern := pattern
+	if literal {
+		regexPattern = regexp.QuoteMeta(pattern)
+	}
+	if multiline {
+		regexPattern = "(?m)" + regexPattern
+	}
 	if caseInsensitive {
-		regexPattern = "(?i)" + pattern
+		regexPattern = "(?i)" + regexPattern
 	}
 
 	re, err := regexp.Compile(regexPattern)
@@ -86,52 +227,216 @@ func (t *GrepTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 		return sdk.NewErrorResult(fmt.Sprintf("invalid regex: %s", err)), nil
 	}
 
-	files, err := getSearchFiles(searchPath, globPattern)
-	if err != nil {
-		return sdk.NewErrorResult(err.Error()), nil
+	key := cache.GrepQueryKey(re.String(), searchPath, globPattern, strings.Join(types, ","), multiline)
+	result, cached := t.scope.cachedGrep(key)
+	if !cached {
+		files, err := getSearchFiles(ctx, t.scope, searchPath, globPattern)
+		if err != nil {
+			return sdk.NewErrorResult(err.Error()), nil
+		}
+		files = filterByType(files, types)
+
+		result = collectMatches(searchParallel(ctx, files, re, multiline), maxGrepCollect)
+		if ctx.Err() != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("search cancelled: %s", ctx.Err())), nil
+		}
+		t.scope.storeGrep(key, searchPath, result)
 	}
 
-	const maxMatches = 500
-	fileMatches := searchParallel(ctx, files, re)
+	if len(result.Matches) == 0 {
+		return &sdk.ToolResult{
+			Content: "No matches found.",
+			Data:    &GrepOutput{Mode: mode},
+			Success: true,
+		}, nil
+	}
 
-	var results strings.Builder
-	matchCount := 0
-	fileCount := 0
+	out := t.page(result, mode, offset, limit, before, after)
+	return &sdk.ToolResult{
+		Content: t.format(out),
+		Data:    out,
+		Success: true,
+	}, nil
+}
 
-	for _, fm := range fileMatches {
-		if matchCount >= maxMatches {
-			break
+// page selects one page of results in the requested mode.
+func (t *GrepTool) page(result cache.GrepResult, mode string, offset, limit, before, after int) *GrepOutput {
+	out := &GrepOutput{
+		Mode:      mode,
+		Files:     result.FileCount,
+		Offset:    offset,
+		Truncated: result.Truncated,
+	}
+
+	// Per-file counts, in path order
+	var counts []GrepCount
+	for _, m := range result.Matches {
+		rel := t.relPath(m.FilePath)
+		if n := len(counts); n > 0 && counts[n-1].File == rel {
+			counts[n-1].Count++
+		} else {
+			counts = append(counts, GrepCount{File: rel, Count: 1})
 		}
+	}
 
-		fileCount++
-		relPath, _ := filepath.Rel(t.workDir, fm.path)
-		if relPath == "" {
-			relPath = fm.path
+	out.Total = len(result.Matches)
+	items := len(counts) // what is paged over
+	switch mode {
+	case GrepModeFiles:
+		out.Total = len(counts)
+		for _, c := range pageOf(counts, offset, limit) {
+			out.FileList = append(out.FileList, c.File)
 		}
+	case GrepModeCount:
+		out.Counts = pageOf(counts, offset, limit)
+	default:
+		items = len(result.Matches)
+		lines := make(map[string][]string) // file contents for context
+		for _, m := range pageOf(result.Matches, offset, limit) {
+			hit := GrepHit{File: t.relPath(m.FilePath), Line: m.LineNum, Text: m.Line}
+			if n := strings.Count(m.Line, "\n"); n > 0 {
+				hit.EndLine = m.LineNum + n
+			}
+			if before > 0 || after > 0 {
+				fileLines, ok := lines[m.FilePath]
+				if !ok {
+					fileLines = readLines(m.FilePath)
+					lines[m.FilePath] = fileLines
+				}
+				hit.Before, hit.After = contextAround(fileLines, hit.Line, max(hit.Line, hit.EndLine), before, after)
+			}
+			out.Matches = append(out.Matches, hit)
+		}
+	}
 
-		for _, match := range fm.matches {
-			if matchCount >= maxMatches {
-				break
+	if end := offset + limit; end < items {
+		out.NextOffset = end
+	}
+	return out
+}
+
+// format renders a page as text for the model.
+func (t *GrepTool) format(out *GrepOutput) string {
+	var sb strings.Builder
+	shown := len(out.Matches) + len(out.FileList) + len(out.Counts)
+
+	switch out.Mode {
+	case GrepModeFiles:
+		fmt.Fprintf(&sb, "Found %d file(s) with matches:\n\n", out.Total)
+		for _, f := range out.FileList {
+			sb.WriteString(f + "\n")
+		}
+	case GrepModeCount:
+		fmt.Fprintf(&sb, "Found %d match(es) in %d file(s):\n\n", out.Total, out.Files)
+		for _, c := range out.Counts {
+			fmt.Fprintf(&sb, "%s: %d\n", c.File, c.Count)
+		}
+	default:
+		fmt.Fprintf(&sb, "Found %d match(es) in %d file(s):\n\n", out.Total, out.Files)
+		writeHits(&sb, out.Matches)
+	}
+
+	if out.NextOffset > 0 {
+		fmt.Fprintf(&sb, "\n(showing %d-%d of %d; use offset=%d for more)\n",
+			out.Offset+1, out.Offset+shown, out.Total, out.NextOffset)
+	}
+	if out.Truncated {
+		fmt.Fprintf(&sb, "(search stopped after %d matches; narrow the pattern or path)\n", maxGrepCollect)
+	}
+	return sb.String()
+}
+
+// writeHits prints matches as "file:line: text" and context lines as
+// "file-line- text", merging overlapping context and separating
+// non-adjacent groups with "--".
+func writeHits(sb *strings.Builder, hits []GrepHit) {
+	type outLine struct {
+		text  string
+		match bool
+	}
+	withContext := false
+	for _, hit := range hits {
+		withContext = withContext || len(hit.Before) > 0 || len(hit.After) > 0
+	}
+
+	prevFile, prevLine := "", 0
+	for i := 0; i < len(hits); {
+		// Gather the lines of consecutive hits in one file
+		file := hits[i].File
+		lines := make(map[int]outLine)
+		for ; i < len(hits) && hits[i].File == file; i++ {
+			hit := hits[i]
+			for j, text := range hit.Before {
+				n := hit.Line - len(hit.Before) + j
+				if _, ok := lines[n]; !ok {
+					lines[n] = outLine{text: text}
+				}
+			}
+			for j, text := range strings.Split(hit.Text, "\n") {
+				lines[hit.Line+j] = outLine{text: text, match: true}
+			}
+			end := max(hit.Line, hit.EndLine)
+			for j, text := range hit.After {
+				if _, ok := lines[end+1+j]; !ok {
+					lines[end+1+j] = outLine{text: text}
+				}
 			}
-			results.WriteString(fmt.Sprintf("%s:%d: %s\n", relPath, match.lineNum, match.line))
-			matchCount++
+		}
+
+		nums := make([]int, 0, len(lines))
+		for n := range lines {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+		for _, n := range nums {
+			if withContext && prevFile != "" && (file != prevFile || n > prevLine+1) {
+				sb.WriteString("--\n")
+			}
+			if l := lines[n]; l.match {
+				fmt.Fprintf(sb, "%s:%d: %s\n", file, n, l.text)
+			} else {
+				fmt.Fprintf(sb, "%s-%d- %s\n", file, n, l.text)
+			}
+			prevFile, prevLine = file, n
 		}
 	}
+}
 
-	if matchCount == 0 {
-		return sdk.NewSuccessResult("No matches found."), nil
+func (t *GrepTool) relPath(path string) string {
+	rel, err := filepath.Rel(t.workDir, path)
+	if err != nil {
+		return path
 	}
+	return rel
+}
 
-	summary := fmt.Sprintf("Found %d match(es) in %d file(s):\n\n", matchCount, fileCount)
-	if matchCount >= maxMatches {
-		summary = fmt.Sprintf("Found %d+ match(es) in %d file(s) (capped at %d):\n\n", matchCount, fileCount, maxMatches)
+// collectMatches flattens per-file matches, keeping at most maxMatches.
+func collectMatches(fileMatches []fileMatch, maxMatches int) cache.GrepResult {
+	var result cache.GrepResult
+	for _, fm := range fileMatches {
+		if len(result.Matches) >= maxMatches {
+			result.Truncated = true
+			break
+		}
+		result.FileCount++
+		for _, match := range fm.matches {
+			if len(result.Matches) >= maxMatches {
+				result.Truncated = true
+				break
+			}
+			result.Matches = append(result.Matches, cache.GrepMatch{
+				FilePath: fm.path,
+				LineNum:  match.lineNum,
+				Line:     match.line,
+			})
+		}
 	}
-	return sdk.NewSuccessResult(summary + results.String()), nil
+	return result
 }
 
 type grepMatch struct {
 	lineNum int
-	line    string
+	line    string // all lines of a multiline match, joined by "\n"
 }
 
 type fileMatch struct {
@@ -139,7 +444,7 @@ type fileMatch struct {
 	matches []grepMatch
 }
 
-func getSearchFiles(searchPath, globPattern string) ([]string, error) {
+func getSearchFiles(ctx context.Context, scope *SearchScope, searchPath, globPattern string) ([]string, error) {
 	info, err := os.Stat(searchPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -155,10 +460,11 @@ func getSearchFiles(searchPath, globPattern string) ([]string, error) {
 	if globPattern == "" {
 		globPattern = "**/*"
 	}
-	fullPattern := filepath.Join(searchPath, globPattern)
-
-	matches, err := doublestar.FilepathGlob(fullPattern)
+	matches, err := scope.glob(ctx, searchPath, globPattern)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("search cancelled: %w", err)
+		}
 		return nil, fmt.Errorf("invalid glob pattern: %w", err)
 	}
 
@@ -173,17 +479,59 @@ func getSearchFiles(searchPath, globPattern string) ([]string, error) {
 	return files, nil
 }
 
-func searchParallel(ctx context.Context, files []string, re *regexp.Regexp) []fileMatch {
+// parseGrepTypes parses a comma-separated list of languages.
+func parseGrepTypes(s string) ([]string, error) {
+	var types []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if alias, ok := grepTypeAliases[name]; ok {
+			name = alias
+		}
+		known := false
+		for _, t := range grepTypes {
+			known = known || t == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown type: %s (supported: %s)", name, strings.Join(grepTypes, ", "))
+		}
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+// filterByType keeps files whose language is one of types.
+func filterByType(files, types []string) []string {
+	if len(types) == 0 {
+		return files
+	}
+	var kept []string
+	for _, f := range files {
+		lang := semantic.DetectLanguage(f)
+		for _, t := range types {
+			if lang == t {
+				kept = append(kept, f)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+func searchParallel(ctx context.Context, files []string, re *regexp.Regexp, multiline bool) []fileMatch {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	var found atomic.Int64
 	results := make([]fileMatch, 0)
 	semaphore := make(chan struct{}, 10)
 
 	for _, file := range files {
-		select {
-		case <-ctx.Done():
+		// Stop early once enough matches were found to be truncated anyway
+		if ctx.Err() != nil || found.Load() > maxGrepCollect {
 			break
-		default:
 		}
 
 		wg.Add(1)
@@ -193,8 +541,14 @@ func searchParallel(ctx context.Context, files []string, re *regexp.Regexp) []fi
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			matches := searchFile(f, re)
+			var matches []grepMatch
+			if multiline {
+				matches = searchFileMultiline(f, re)
+			} else {
+				matches = searchFile(f, re)
+			}
 			if len(matches) > 0 {
+				found.Add(int64(len(matches)))
 				mu.Lock()
 				results = append(results, fileMatch{path: f, matches: matches})
 				mu.Unlock()
@@ -227,16 +581,111 @@ func searchFile(filePath string, re *regexp.Regexp) []grepMatch {
 		lineNum++
 		line := scanner.Text()
 		if re.MatchString(line) {
-			if len(line) > 500 {
-				line = line[:500] + "..."
-			}
-			matches = append(matches, grepMatch{lineNum: lineNum, line: line})
+			matches = append(matches, grepMatch{lineNum: lineNum, line: truncateLine(line)})
 		}
 	}
 
 	return matches
 }
 
+// searchFileMultiline matches re against the whole file, reporting each
+// match with the full lines it spans. Matches starting on a line already
+// covered by the previous match are merged into it.
+func searchFileMultiline(filePath string, re *regexp.Regexp) []grepMatch {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+
+	// lineStarts[i] is the offset of line i+1
+	lineStarts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset })
+	}
+
+	var matches []grepMatch
+	lastLine := 0
+	for _, loc := range re.FindAllStringIndex(content, -1) {
+		start := lineOf(loc[0])
+		if start <= lastLine {
+			continue
+		}
+		end := start
+		if loc[1] > loc[0] {
+			end = lineOf(loc[1] - 1)
+		}
+
+		from := lineStarts[start-1]
+		to := len(content)
+		if end < len(lineStarts) {
+			to = lineStarts[end] - 1
+		}
+		lines := strings.Split(content[from:to], "\n")
+		for i, line := range lines {
+			lines[i] = truncateLine(strings.TrimSuffix(line, "\r"))
+		}
+		matches = append(matches, grepMatch{lineNum: start, line: strings.Join(lines, "\n")})
+		lastLine = end
+	}
+	return matches
+}
+
+// readLines returns the lines of a file, or nil if it cannot be read.
+func readLines(filePath string) []string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, truncateLine(scanner.Text()))
+	}
+	return lines
+}
+
+// contextAround returns up to before lines preceding line start and up to
+// after lines following line end (both 1-based).
+func contextAround(lines []string, start, end, before, after int) (pre, post []string) {
+	if start < 1 || start > len(lines) {
+		return nil, nil
+	}
+	from := max(start-1-before, 0)
+	pre = lines[from : start-1]
+	if end <= len(lines) {
+		post = lines[end:min(end+after, len(lines))]
+	}
+	return pre, post
+}
+
+func truncateLine(line string) string {
+	if len(line) > maxGrepLineLen {
+		return line[:maxGrepLineLen] + "..."
+	}
+	return line
+}
+
+// pageOf returns items[offset:offset+limit], clamped to the slice.
+func pageOf[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
+
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
 func isBinaryFile(path string) bool {
 	binaryExts := map[string]bool{
 		".exe": true, ".dll": true, ".so": true, ".dylib": true,
@@ -15,11 +15,21 @@ import (
 // ListDirTool lists directory contents.
 type ListDirTool struct {
 	workDir string
+	scope   *SearchScope
 }
 
-// NewListDir creates a new ListDirTool.
+// NewListDir creates a new ListDirTool. It hides entries ignored under
+// DefaultSearchOptions.
 func NewListDir(workDir string) *ListDirTool {
-	return &ListDirTool{workDir: workDir}
+	return &ListDirTool{
+		workDir: workDir,
+		scope:   NewSearchScope(workDir, DefaultSearchOptions()),
+	}
+}
+
+// SetScope sets the ignore rules used to hide entries.
+func (t *ListDirTool) SetScope(scope *SearchScope) {
+	t.scope = scope
 }
 
 func (t *ListDirTool) Name() string { return "list_dir" }
@@ -65,11 +75,22 @@ func (t *ListDirTool) Execute(ctx context.Context, args map[string]any) (*sdk.To
 		return sdk.NewSuccessResult("(empty)"), nil
 	}
 
+	// Show everything inside a directory that is itself ignored
+	scope := t.scope
+	if scope.ignored(dirPath, true) {
+		scope = nil
+	}
+
 	const maxEntries = 2000
 	var builder strings.Builder
 	count := 0
+	hidden := 0
 
 	for _, entry := range entries {
+		if scope.ignored(filepath.Join(dirPath, entry.Name()), entry.IsDir()) {
+			hidden++
+			continue
+		}
 		if count >= maxEntries {
 			builder.WriteString(fmt.Sprintf("... (output truncated: showing %d entries)\n", maxEntries))
 			break
@@ -83,5 +104,8 @@ func (t *ListDirTool) Execute(ctx context.Context, args map[string]any) (*sdk.To
 		count++
 	}
 
+	if hidden > 0 {
+		builder.WriteString(fmt.Sprintf("(%d ignored entries hidden)\n", hidden))
+	}
 	return sdk.NewSuccessResult(builder.String()), nil
 }
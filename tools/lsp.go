@@ -0,0 +1,687 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/lsp"
+
+	"google.golang.org/genai"
+)
+
+// lspDiagnosticsWait is how long the diagnostics action waits for a server
+// to analyze a changed file.
+const lspDiagnosticsWait = 5 * time.Second
+
+// LSPLocation is a source position reported by the lsp tools. Line and
+// Column are 1-based; Column counts characters.
+type LSPLocation struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text,omitempty"` // the source line
+}
+
+// LSPSymbol is a symbol found by the symbols action.
+type LSPSymbol struct {
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	Container string      `json:"container,omitempty"`
+	Location  LSPLocation `json:"location"`
+}
+
+// LSPDiagnostic is a problem reported by the diagnostics action.
+type LSPDiagnostic struct {
+	Location LSPLocation `json:"location"`
+	Severity string      `json:"severity"`
+	Source   string      `json:"source,omitempty"`
+	Message  string      `json:"message"`
+}
+
+// LSPTool answers semantic code questions through language servers:
+// definitions, references, hover information, workspace symbols and
+// diagnostics.
+type LSPTool struct {
+	servers *lsp.Manager
+}
+
+// NewLSP creates a new LSPTool. Share servers with NewLSPRename so both
+// tools talk to the same server processes.
+func NewLSP(servers *lsp.Manager) *LSPTool {
+	return &LSPTool{servers: servers}
+}
+
+func (t *LSPTool) Name() string { return "lsp" }
+
+func (t *LSPTool) Description() string {
+	return "Semantic code navigation through a language server (gopls, pyright, typescript-language-server...). " +
+		"Finds definitions, references and hover docs for the symbol at a position, searches workspace symbols, " +
+		"and reports compiler diagnostics for a file (run after edits). More precise than grep for code symbols."
+}
+
+func (t *LSPTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"action": {
+					Type:        genai.TypeString,
+					Description: "What to look up",
+					Enum:        []string{"definition", "references", "hover", "symbols", "diagnostics"},
+				},
+				"file_path": {
+					Type:        genai.TypeString,
+					Description: "The file containing the symbol (required except for symbols)",
+				},
+				"line": {
+					Type:        genai.TypeInteger,
+					Description: "1-based line of the symbol (definition, references, hover)",
+				},
+				"symbol": {
+					Type:        genai.TypeString,
+					Description: "The symbol's name as written on that line; locates the column",
+				},
+				"column": {
+					Type:        genai.TypeInteger,
+					Description: "1-based column of the symbol, if symbol is not given",
+				},
+				"query": {
+					Type:        genai.TypeString,
+					Description: "Symbol name or prefix to search for (symbols)",
+				},
+				"language": {
+					Type:        genai.TypeString,
+					Description: "Language to search when no file_path is given (symbols), e.g. 'go', 'python'",
+				},
+				"include_declaration": {
+					Type:        genai.TypeBoolean,
+					Description: "Include the declaration among references (default: true)",
+				},
+				"max_results": {
+					Type:        genai.TypeInteger,
+					Description: "Maximum number of locations or symbols to return (default: 100)",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+func (t *LSPTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	action, _ := sdk.GetString(args, "action")
+	if action == "" {
+		return sdk.NewErrorResult("action is required"), nil
+	}
+	maxResults := sdk.GetIntDefault(args, "max_results", 100)
+	if maxResults <= 0 {
+		maxResults = 100
+	}
+
+	switch action {
+	case "definition", "references", "hover":
+		return t.positionAction(ctx, action, args, maxResults)
+	case "symbols":
+		return t.symbols(ctx, args, maxResults)
+	case "diagnostics":
+		return t.diagnostics(ctx, args)
+	default:
+		return sdk.NewErrorResult(fmt.Sprintf("unknown action: %s", action)), nil
+	}
+}
+
+func (t *LSPTool) positionAction(ctx context.Context, action string, args map[string]any, maxResults int) (*sdk.ToolResult, error) {
+	target, errResult := lspTarget(ctx, t.servers, args)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	if action == "hover" {
+		text, err := target.client.Hover(ctx, target.path, target.pos)
+		if err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("hover failed: %s", err)), nil
+		}
+		if text == "" {
+			return sdk.NewSuccessResult(fmt.Sprintf("No hover information at %s", target.describe())), nil
+		}
+		return sdk.NewSuccessResult(text), nil
+	}
+
+	var locations []lsp.Location
+	var err error
+	if action == "definition" {
+		locations, err = target.client.Definition(ctx, target.path, target.pos)
+	} else {
+		includeDecl := sdk.GetBoolDefault(args, "include_declaration", true)
+		locations, err = target.client.References(ctx, target.path, target.pos, includeDecl)
+	}
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("%s lookup failed: %s", action, err)), nil
+	}
+
+	results := newLSPLocator(t.servers.RootDir()).locations(locations)
+	if len(results) == 0 {
+		return sdk.NewSuccessResult(fmt.Sprintf("No %s found for %s", action, target.describe())), nil
+	}
+
+	var sb strings.Builder
+	noun := "Definition"
+	if action == "references" {
+		noun = "Reference"
+	}
+	fmt.Fprintf(&sb, "%s(s) of %s: %d\n\n", noun, target.describe(), len(results))
+	truncated := len(results) > maxResults
+	if truncated {
+		results = results[:maxResults]
+	}
+	for _, loc := range results {
+		fmt.Fprintf(&sb, "%s:%d:%d: %s\n", loc.Path, loc.Line, loc.Column, loc.Text)
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\n(showing first %d; raise max_results for more)\n", maxResults)
+	}
+
+	return &sdk.ToolResult{Content: sb.String(), Data: results, Success: true}, nil
+}
+
+func (t *LSPTool) symbols(ctx context.Context, args map[string]any, maxResults int) (*sdk.ToolResult, error) {
+	query := sdk.GetStringDefault(args, "query", "")
+	if query == "" {
+		return sdk.NewErrorResult("query is required for symbols"), nil
+	}
+
+	// Pick servers: by file, by language, or every running one
+	var clients []*lsp.Client
+	filePath := sdk.GetStringDefault(args, "file_path", "")
+	language := sdk.GetStringDefault(args, "language", "")
+	switch {
+	case filePath != "":
+		client, err := t.servers.ClientFor(ctx, resolveLSPPath(t.servers, filePath))
+		if err != nil {
+			return sdk.NewErrorResult(err.Error()), nil
+		}
+		clients = append(clients, client)
+	case language != "":
+		client, err := t.servers.ClientForLanguage(ctx, language)
+		if err != nil {
+			return sdk.NewErrorResult(err.Error()), nil
+		}
+		clients = append(clients, client)
+	default:
+		clients = t.servers.Running()
+		if len(clients) == 0 {
+			return sdk.NewErrorResult("no language server is running; pass language or file_path"), nil
+		}
+	}
+
+	locator := newLSPLocator(t.servers.RootDir())
+	var results []LSPSymbol
+	for _, client := range clients {
+		symbols, err := client.WorkspaceSymbols(ctx, query)
+		if err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("symbol search failed: %s", err)), nil
+		}
+		for _, s := range symbols {
+			results = append(results, LSPSymbol{
+				Name:      s.Name,
+				Kind:      s.Kind.String(),
+				Container: s.ContainerName,
+				Location:  locator.location(s.Location),
+			})
+		}
+	}
+	if len(results) == 0 {
+		return sdk.NewSuccessResult(fmt.Sprintf("No symbols matching %q", query)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Symbols matching %q: %d\n\n", query, len(results))
+	truncated := len(results) > maxResults
+	if truncated {
+		results = results[:maxResults]
+	}
+	for _, s := range results {
+		name := s.Name
+		if s.Container != "" {
+			name = s.Container + "." + s.Name
+		}
+		fmt.Fprintf(&sb, "%s %s  %s:%d\n", s.Kind, name, s.Location.Path, s.Location.Line)
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\n(showing first %d; refine the query or raise max_results)\n", maxResults)
+	}
+
+	return &sdk.ToolResult{Content: sb.String(), Data: results, Success: true}, nil
+}
+
+func (t *LSPTool) diagnostics(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	filePath := sdk.GetStringDefault(args, "file_path", "")
+	if filePath == "" {
+		return sdk.NewErrorResult("file_path is required for diagnostics"), nil
+	}
+	path := resolveLSPPath(t.servers, filePath)
+	client, err := t.servers.ClientFor(ctx, path)
+	if err != nil {
+		return sdk.NewErrorResult(err.Error()), nil
+	}
+
+	diagnostics, err := client.Diagnostics(ctx, path, lspDiagnosticsWait)
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("diagnostics failed: %s", err)), nil
+	}
+
+	locator := newLSPLocator(t.servers.RootDir())
+	rel := locator.relPath(path)
+	if len(diagnostics) == 0 {
+		return sdk.NewSuccessResult(fmt.Sprintf("No diagnostics for %s", rel)), nil
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Severity != diagnostics[j].Severity {
+			return diagnostics[i].Severity < diagnostics[j].Severity
+		}
+		return diagnostics[i].Range.Start.Line < diagnostics[j].Range.Start.Line
+	})
+
+	results := make([]LSPDiagnostic, 0, len(diagnostics))
+	counts := make(map[string]int)
+	var sb strings.Builder
+	for _, d := range diagnostics {
+		loc := locator.location(lsp.Location{URI: lsp.PathToURI(path), Range: d.Range})
+		severity := d.Severity.String()
+		if d.Severity == 0 {
+			severity = lsp.SeverityError.String() // unspecified severity defaults to error
+		}
+		counts[severity]++
+		results = append(results, LSPDiagnostic{Location: loc, Severity: severity, Source: d.Source, Message: d.Message})
+
+		fmt.Fprintf(&sb, "%s:%d:%d: %s: %s", loc.Path, loc.Line, loc.Column, severity, d.Message)
+		if d.Source != "" {
+			fmt.Fprintf(&sb, " (%s)", d.Source)
+		}
+		sb.WriteString("\n")
+	}
+
+	var summary []string
+	for _, severity := range []string{"error", "warning", "info", "hint"} {
+		if counts[severity] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s(s)", counts[severity], severity))
+		}
+	}
+	content := fmt.Sprintf("Diagnostics for %s: %s\n\n%s", rel, strings.Join(summary, ", "), sb.String())
+	return &sdk.ToolResult{Content: content, Data: results, Success: true}, nil
+}
+
+// LSPRenameTool renames a symbol across the workspace with the edits
+// computed by a language server.
+type LSPRenameTool struct {
+	servers *lsp.Manager
+	review  changeReview
+}
+
+// LSPRenameResult is the structured result of an lsp_rename call, set as
+// ToolResult.Data.
+type LSPRenameResult struct {
+	NewName string          `json:"new_name"`
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Applied bool            `json:"applied"`
+	Files   []LSPRenameFile `json:"files"`
+}
+
+// LSPRenameFile describes the rename edits in one file.
+type LSPRenameFile struct {
+	Path  string `json:"path"`
+	Edits int    `json:"edits"`
+	Diff  string `json:"diff,omitempty"`
+}
+
+// NewLSPRename creates a new LSPRenameTool. Share servers with NewLSP so
+// both tools talk to the same server processes.
+func NewLSPRename(servers *lsp.Manager) *LSPRenameTool {
+	return &LSPRenameTool{servers: servers}
+}
+
+// SetApprover sets a callback that must accept each rename, shown as the
+// diff of every file it changes, before any file is written. Without one,
+// the approver carried by the run context is used (see sdk.WithReviewMode).
+func (t *LSPRenameTool) SetApprover(approver sdk.ChangeApprover) {
+	t.review.approver = approver
+}
+
+// SetDiffPreview controls whether results carry the diff of the rename in
+// ExecutionSummary (default: true). Dry runs always show it.
+func (t *LSPRenameTool) SetDiffPreview(enabled bool) {
+	t.review.noPreview = !enabled
+}
+
+func (t *LSPRenameTool) Name() string { return "lsp_rename" }
+
+func (t *LSPRenameTool) Description() string {
+	return "Renames a symbol (variable, function, type, method...) everywhere it is used, with the edits computed " +
+		"by a language server. Safer than search-and-replace: only real references are changed. " +
+		"Use dry_run to preview the diff."
+}
+
+func (t *LSPRenameTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"file_path": {
+					Type:        genai.TypeString,
+					Description: "A file containing the symbol",
+				},
+				"line": {
+					Type:        genai.TypeInteger,
+					Description: "1-based line where the symbol appears",
+				},
+				"symbol": {
+					Type:        genai.TypeString,
+					Description: "The symbol's current name as written on that line",
+				},
+				"column": {
+					Type:        genai.TypeInteger,
+					Description: "1-based column of the symbol, if symbol is not given",
+				},
+				"new_name": {
+					Type:        genai.TypeString,
+					Description: "The new name",
+				},
+				"dry_run": {
+					Type:        genai.TypeBoolean,
+					Description: "Show the diff without changing files (default: false)",
+				},
+			},
+			Required: []string{"file_path", "line", "new_name"},
+		},
+	}
+}
+
+func (t *LSPRenameTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	newName := strings.TrimSpace(sdk.GetStringDefault(args, "new_name", ""))
+	if newName == "" {
+		return sdk.NewErrorResult("new_name is required"), nil
+	}
+	dryRun := sdk.GetBoolDefault(args, "dry_run", false)
+
+	target, errResult := lspTarget(ctx, t.servers, args)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	edit, err := target.client.Rename(ctx, target.path, target.pos, newName)
+	if err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("rename failed: %s", err)), nil
+	}
+	if len(edit.Changes) == 0 {
+		return sdk.NewErrorResult(fmt.Sprintf("nothing to rename at %s", target.describe())), nil
+	}
+
+	// Compute every file's new content before touching any
+	locator := newLSPLocator(t.servers.RootDir())
+	wantsDiff := dryRun || t.review.wantsDiff(ctx)
+	var outputs []*patchOutput
+	var touched []string
+	result := &LSPRenameResult{NewName: newName, DryRun: dryRun}
+	for uri, edits := range edit.Changes {
+		path := lsp.URIToPath(uri)
+		touched = append(touched, path)
+		rel := locator.relPath(path)
+		if filepath.IsAbs(rel) {
+			return sdk.NewErrorResult(fmt.Sprintf("rename would edit %s, outside the workspace; nothing was changed", path)), nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("rename failed: %s", err)), nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("rename failed: %s", err)), nil
+		}
+		after, err := lsp.ApplyEdits(string(data), edits)
+		if err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("rename failed in %s: %s", locator.relPath(path), err)), nil
+		}
+		if after == string(data) {
+			continue
+		}
+		out := &patchOutput{path: path, oldText: string(data), newText: after, mode: info.Mode().Perm()}
+		out.result.Path = rel
+		out.result.Action = "modify"
+		outputs = append(outputs, out)
+		file := LSPRenameFile{Path: rel, Edits: len(edits)}
+		if wantsDiff {
+			file.Diff = UnifiedDiff("a/"+rel, "b/"+rel, string(data), after, 3)
+		}
+		result.Files = append(result.Files, file)
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Path < result.Files[j].Path })
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].path < outputs[j].path })
+
+	summary := &sdk.ExecutionSummary{
+		ToolName:    t.Name(),
+		DisplayName: t.Name(),
+		Action:      "rename to " + newName,
+		RiskLevel:   sdk.SafetyLevelCaution,
+		UserVisible: true,
+	}
+	var paths []string
+	var diff strings.Builder
+	for _, f := range result.Files {
+		paths = append(paths, f.Path)
+		diff.WriteString(f.Diff)
+	}
+	summary.Target = strings.Join(paths, ", ")
+	summary.Diff = diff.String()
+
+	if !dryRun && len(outputs) > 0 {
+		if err := t.review.approve(ctx, summary); err != nil {
+			return rejectedResult(summary, err), nil
+		}
+		// Written like apply_patch: every file or none
+		if out, err := commitPatch(outputs); err != nil {
+			return &sdk.ToolResult{
+				Content:          formatLSPRename(result),
+				Data:             result,
+				Error:            fmt.Sprintf("error writing %s: %s; no files were changed", out.result.Path, err),
+				Success:          false,
+				ExecutionSummary: summary,
+			}, nil
+		}
+		// Keep the server's view in step with every file the edit touched
+		sort.Strings(touched)
+		for _, path := range touched {
+			_, _ = target.client.Sync(ctx, path)
+		}
+		result.Applied = true
+	}
+
+	return &sdk.ToolResult{
+		Content:          formatLSPRename(result),
+		Data:             result,
+		Success:          true,
+		ExecutionSummary: summary,
+	}, nil
+}
+
+func formatLSPRename(r *LSPRenameResult) string {
+	var sb strings.Builder
+	edits := 0
+	for _, f := range r.Files {
+		edits += f.Edits
+	}
+	switch {
+	case len(r.Files) == 0:
+		fmt.Fprintf(&sb, "Renaming to %s changes nothing.\n", r.NewName)
+	case r.DryRun:
+		fmt.Fprintf(&sb, "Dry run: renaming to %s would make %d edit(s) in %d file(s). No files were changed.\n\n", r.NewName, edits, len(r.Files))
+	default:
+		fmt.Fprintf(&sb, "Renamed to %s: %d edit(s) in %d file(s).\n\n", r.NewName, edits, len(r.Files))
+	}
+	for _, f := range r.Files {
+		fmt.Fprintf(&sb, "M %s (%d edit(s))\n", f.Path, f.Edits)
+	}
+	if r.DryRun {
+		for _, f := range r.Files {
+			sb.WriteString("\n")
+			sb.WriteString(f.Diff)
+		}
+	}
+	return sb.String()
+}
+
+// lspPosition is a symbol position resolved from tool arguments.
+type lspPosition struct {
+	client *lsp.Client
+	path   string
+	pos    lsp.Position
+	line   int
+	symbol string
+}
+
+func (p *lspPosition) describe() string {
+	if p.symbol != "" {
+		return fmt.Sprintf("%s at %s:%d", p.symbol, filepath.Base(p.path), p.line)
+	}
+	return fmt.Sprintf("%s:%d:%d", filepath.Base(p.path), p.line, p.pos.Character+1)
+}
+
+// lspTarget resolves file_path, line and symbol or column to a position and
+// the client of the file's language. On failure it returns an error result.
+func lspTarget(ctx context.Context, servers *lsp.Manager, args map[string]any) (*lspPosition, *sdk.ToolResult) {
+	filePath := sdk.GetStringDefault(args, "file_path", "")
+	if filePath == "" {
+		return nil, sdk.NewErrorResult("file_path is required")
+	}
+	line := sdk.GetIntDefault(args, "line", 0)
+	if line < 1 {
+		return nil, sdk.NewErrorResult("line is required (1-based)")
+	}
+	symbol := sdk.GetStringDefault(args, "symbol", "")
+	column := sdk.GetIntDefault(args, "column", 0)
+	if symbol == "" && column < 1 {
+		return nil, sdk.NewErrorResult("symbol or column is required")
+	}
+
+	path := resolveLSPPath(servers, filePath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, sdk.NewErrorResult(fmt.Sprintf("error reading file: %s", err))
+	}
+	text := string(data)
+
+	if symbol != "" {
+		col, err := symbolColumn(text, line, symbol)
+		if err != nil {
+			return nil, sdk.NewErrorResult(err.Error())
+		}
+		column = col
+	}
+	pos, err := lsp.PositionAt(text, line, column)
+	if err != nil {
+		return nil, sdk.NewErrorResult(err.Error())
+	}
+
+	client, err := servers.ClientFor(ctx, path)
+	if err != nil {
+		return nil, sdk.NewErrorResult(err.Error())
+	}
+	return &lspPosition{client: client, path: path, pos: pos, line: line, symbol: symbol}, nil
+}
+
+// symbolColumn returns the 1-based column of symbol on a line, preferring
+// a whole-word match.
+func symbolColumn(text string, line int, symbol string) (int, error) {
+	lines := strings.Split(text, "\n")
+	if line > len(lines) {
+		return 0, fmt.Errorf("line %d out of range (1-%d)", line, len(lines))
+	}
+	lineText := lines[line-1]
+
+	idx := -1
+	if loc := regexp.MustCompile(`\b` + regexp.QuoteMeta(symbol) + `\b`).FindStringIndex(lineText); loc != nil {
+		idx = loc[0]
+	} else {
+		idx = strings.Index(lineText, symbol)
+	}
+	if idx < 0 {
+		return 0, fmt.Errorf("symbol %q not found on line %d: %s", symbol, line, strings.TrimSpace(lineText))
+	}
+	return utf8.RuneCountInString(lineText[:idx]) + 1, nil
+}
+
+// resolveLSPPath makes a path absolute against the workspace root, as
+// document URIs require.
+func resolveLSPPath(servers *lsp.Manager, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(servers.RootDir(), path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// lspLocator turns protocol locations into LSPLocations, reading each file
+// once.
+type lspLocator struct {
+	rootDir string
+	lines   map[string][]string
+}
+
+func newLSPLocator(rootDir string) *lspLocator {
+	if abs, err := filepath.Abs(rootDir); err == nil {
+		rootDir = abs
+	}
+	return &lspLocator{rootDir: rootDir, lines: make(map[string][]string)}
+}
+
+func (l *lspLocator) locations(locations []lsp.Location) []LSPLocation {
+	results := make([]LSPLocation, 0, len(locations))
+	for _, loc := range locations {
+		results = append(results, l.location(loc))
+	}
+	return results
+}
+
+func (l *lspLocator) location(loc lsp.Location) LSPLocation {
+	path := lsp.URIToPath(loc.URI)
+	lines, ok := l.lines[path]
+	if !ok {
+		if data, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		l.lines[path] = lines
+	}
+
+	result := LSPLocation{
+		Path:   l.relPath(path),
+		Line:   loc.Range.Start.Line + 1,
+		Column: loc.Range.Start.Character + 1,
+	}
+	if loc.Range.Start.Line < len(lines) {
+		lineText := strings.TrimRight(lines[loc.Range.Start.Line], "\r")
+		result.Column = lsp.Column(lineText, loc.Range.Start.Character)
+		result.Text = truncateLine(strings.TrimSpace(lineText))
+	}
+	return result
+}
+
+func (l *lspLocator) relPath(path string) string {
+	rel, err := filepath.Rel(l.rootDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+// changeReview holds the diff preview and approval settings of a tool that
+// modifies files.
+type changeReview struct {
+	noPreview bool
+	approver  sdk.ChangeApprover
+}
+
+// approverFor returns the tool's approver, or the one carried by ctx.
+func (r *changeReview) approverFor(ctx context.Context) sdk.ChangeApprover {
+	if r.approver != nil {
+		return r.approver
+	}
+	return sdk.ChangeApproverFromContext(ctx)
+}
+
+// wantsDiff reports whether a change's diff must be computed: previews are
+// on, or someone has to approve it.
+func (r *changeReview) wantsDiff(ctx context.Context) bool {
+	return !r.noPreview || r.approverFor(ctx) != nil
+}
+
+// approve asks the approver, if any, to accept the change described by
+// summary. It returns an error when the change is rejected.
+func (r *changeReview) approve(ctx context.Context, summary *sdk.ExecutionSummary) error {
+	approver := r.approverFor(ctx)
+	if approver == nil {
+		return nil
+	}
+	summary.RequiresApproval = true
+	ok, err := approver(ctx, summary)
+	if err != nil {
+		return fmt.Errorf("approval failed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("change to %s was rejected", summary.Target)
+	}
+	return nil
+}
+
+// fileChangeSummary describes a change to one file, with its diff when
+// wanted. oldText is ignored when created is true.
+func (r *changeReview) fileChangeSummary(ctx context.Context, toolName, action, path, oldText, newText string, created bool) *sdk.ExecutionSummary {
+	summary := &sdk.ExecutionSummary{
+		ToolName:    toolName,
+		DisplayName: toolName,
+		Action:      action,
+		Target:      path,
+		RiskLevel:   sdk.SafetyLevelCaution,
+		UserVisible: true,
+	}
+	if r.wantsDiff(ctx) {
+		oldLabel, newLabel := "a/"+path, "b/"+path
+		if filepath.IsAbs(path) {
+			oldLabel, newLabel = path, path
+		}
+		if created {
+			oldLabel, oldText = "/dev/null", ""
+		}
+		summary.Diff = UnifiedDiff(oldLabel, newLabel, oldText, newText, 3)
+	}
+	return summary
+}
+
+// rejectedResult reports a change that was not applied.
+func rejectedResult(summary *sdk.ExecutionSummary, err error) *sdk.ToolResult {
+	result := sdk.NewErrorResult(err.Error())
+	result.ExecutionSummary = summary
+	return result
+}
@@ -1,14 +1,14 @@
 package tools
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	sdk "github.com/ginkida/gokin-sdk"
@@ -16,20 +16,34 @@ import (
 	"google.golang.org/genai"
 )
 
+// maxTestRetries caps the re-runs of failed tests per call.
+const maxTestRetries = 5
+
 // RunTestsTool runs project tests and parses results.
+//
+// Results are returned as text and, in ToolResult.Data, as a
+// *sdk.TestReport with one entry per test.
 type RunTestsTool struct {
 	workDir string
+
+	mu         sync.Mutex
+	lastFailed map[string][]sdk.TestCase // "framework:dir" → tests that failed in the last run
 }
 
 // NewRunTests creates a new RunTestsTool instance.
 func NewRunTests(workDir string) *RunTestsTool {
-	return &RunTestsTool{workDir: workDir}
+	return &RunTestsTool{
+		workDir:    workDir,
+		lastFailed: make(map[string][]sdk.TestCase),
+	}
 }
 
 func (t *RunTestsTool) Name() string { return "run_tests" }
 
 func (t *RunTestsTool) Description() string {
-	return "Runs project tests with automatic framework detection (Go, Python, Node, Rust). Parses output, reports failures with context, and provides coverage summary."
+	return "Runs project tests with automatic framework detection (Go, Python, Node, Rust). Reports each failing test " +
+		"with its message and file:line, can re-run failures to detect flaky tests or re-run only the tests that failed " +
+		"last time, and summarizes coverage per file."
 }
 
 func (t *RunTestsTool) Declaration() *genai.FunctionDeclaration {
@@ -60,17 +74,48 @@ func (t *RunTestsTool) Declaration() *genai.FunctionDeclaration {
 					Description: "Force specific framework: 'go', 'pytest', 'jest', 'cargo', 'auto' (default: auto-detect)",
 					Enum:        []string{"auto", "go", "pytest", "jest", "cargo"},
 				},
+				"retries": {
+					Type:        genai.TypeInteger,
+					Description: "Re-run failed tests up to this many times; tests that then pass are reported as flaky (default: 0, max: 5)",
+				},
+				"failed_only": {
+					Type:        genai.TypeBoolean,
+					Description: "Run only the tests that failed in the previous run (default: false)",
+				},
 			},
 		},
 	}
 }
 
+// testOptions configures one test command.
+type testOptions struct {
+	filter   string
+	verbose  bool
+	coverage bool
+	only     []sdk.TestCase // run just these tests
+	textOnly bool           // cargo: libtest JSON needs a nightly toolchain
+
+	// Where the command writes its machine-readable reports
+	reportFile string
+	coverFile  string
+	coverDir   string
+}
+
+// testRun is the outcome of one test command.
+type testRun struct {
+	report *sdk.TestReport
+	output string // human-readable output
+	err    error
+}
+
 func (t *RunTestsTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
 	testPath := sdk.GetStringDefault(args, "path", "")
 	filter := sdk.GetStringDefault(args, "filter", "")
 	verbose := sdk.GetBoolDefault(args, "verbose", false)
 	coverage := sdk.GetBoolDefault(args, "coverage", false)
 	framework := sdk.GetStringDefault(args, "framework", "auto")
+	retries := sdk.GetIntDefault(args, "retries", 0)
+	failedOnly := sdk.GetBoolDefault(args, "failed_only", false)
 
 	workDir := t.workDir
 	if testPath != "" {
@@ -88,27 +133,80 @@ func (t *RunTestsTool) Execute(ctx context.Context, args map[string]any) (*sdk.T
 			return sdk.NewErrorResult("could not detect test framework. Specify 'framework' parameter."), nil
 		}
 	}
+	if retries > maxTestRetries {
+		retries = maxTestRetries
+	}
+
+	opts := testOptions{filter: filter, verbose: verbose, coverage: coverage}
+	key := framework + ":" + workDir
+	if failedOnly {
+		t.mu.Lock()
+		opts.only = t.lastFailed[key]
+		t.mu.Unlock()
+		if len(opts.only) == 0 {
+			return sdk.NewErrorResult("no failed tests recorded from a previous run; run the tests first"), nil
+		}
+	}
+
+	start := time.Now()
+	run := t.run(ctx, framework, workDir, opts)
+	report := run.report
+
+	// Re-run failures to tell flaky tests from broken ones
+	for i := 0; i < retries && report.Failed > 0 && ctx.Err() == nil; i++ {
+		rerun := t.run(ctx, framework, workDir, testOptions{verbose: verbose, only: report.Failures()})
+		mergeRerun(report, rerun.report)
+	}
+
+	report.Duration = time.Since(start)
+	report.Success = report.Failed == 0 && len(report.FailedPackages) == 0 &&
+		(len(report.Tests) > 0 || run.err == nil)
+
+	t.mu.Lock()
+	t.lastFailed[key] = report.Failures()
+	t.mu.Unlock()
+
+	return &sdk.ToolResult{
+		Content: formatTestReport(report, run.output, verbose),
+		Data:    report,
+		Success: true,
+	}, nil
+}
+
+// run runs the tests once and parses their reports.
+func (t *RunTestsTool) run(ctx context.Context, framework, dir string, opts testOptions) testRun {
+	tmp, err := os.MkdirTemp("", "run-tests-")
+	if err != nil {
+		return testRun{report: &sdk.TestReport{Framework: framework}, err: err}
+	}
+	defer os.RemoveAll(tmp)
+	opts.reportFile = filepath.Join(tmp, "report")
+	opts.coverFile = filepath.Join(tmp, "coverage")
+	opts.coverDir = tmp
+
+	run := execTests(ctx, framework, dir, opts)
+	if framework == "cargo" && !opts.textOnly && len(run.report.Tests) == 0 &&
+		strings.Contains(run.output, "only accepted on the nightly compiler") {
+		opts.textOnly = true
+		run = execTests(ctx, framework, dir, opts)
+	}
+	return run
+}
 
-	// Build command
-	cmdName, cmdArgs := buildTestCommand(framework, filter, verbose, coverage)
+func execTests(ctx context.Context, framework, dir string, opts testOptions) testRun {
+	cmdName, cmdArgs := buildTestCommand(framework, opts)
 
 	// Execute with timeout
 	testCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
 	cmd := exec.CommandContext(testCtx, cmdName, cmdArgs...)
-	cmd.Dir = workDir
-
-	start := time.Now()
+	cmd.Dir = dir
 	output, err := cmd.CombinedOutput()
-	duration := time.Since(start)
 
-	outStr := string(output)
-
-	// Parse results based on framework
-	result := parseTestResults(framework, outStr, err, duration)
-
-	return sdk.NewSuccessResult(result), nil
+	run := testRun{output: string(output), err: err}
+	run.report = parseTestReport(framework, dir, &run, opts)
+	return run
 }
 
 // detectTestFramework auto-detects the test framework from project files.
@@ -142,60 +240,93 @@ func detectTestFramework(dir string) string {
 }
 
 // buildTestCommand creates the test command for the given framework.
-func buildTestCommand(framework, filter string, verbose, coverage bool) (string, []string) {
+func buildTestCommand(framework string, opts testOptions) (string, []string) {
 	switch framework {
 	case "go":
 		args := []string{"test"}
-		if verbose {
+		if opts.verbose {
 			args = append(args, "-v")
 		}
-		if coverage {
-			args = append(args, "-coverprofile=coverage.out")
+		if opts.coverage {
+			args = append(args, "-coverprofile="+opts.coverFile)
 		}
 		args = append(args, "-json")
-		if filter != "" {
-			args = append(args, "-run", filter)
+		if len(opts.only) > 0 {
+			// Subtests are re-run through their top-level test
+			var names, pkgs []string
+			for _, c := range opts.only {
+				names = append(names, strings.SplitN(c.Name, "/", 2)[0])
+				pkgs = append(pkgs, c.Package)
+			}
+			args = append(args, "-run", anchoredPattern(names))
+			return "go", append(args, unique(pkgs)...)
+		}
+		if opts.filter != "" {
+			args = append(args, "-run", opts.filter)
 		}
 		args = append(args, "./...")
 		return "go", args
 
 	case "pytest":
 		args := []string{"-m", "pytest"}
-		if verbose {
+		if opts.verbose {
 			args = append(args, "-v")
 		}
-		if coverage {
-			args = append(args, "--cov", "--cov-report=term-missing")
+		if opts.coverage {
+			args = append(args, "--cov", "--cov-report=term-missing", "--cov-report=json:"+opts.coverFile)
+		}
+		if opts.filter != "" && len(opts.only) == 0 {
+			args = append(args, "-k", opts.filter)
 		}
-		if filter != "" {
-			args = append(args, "-k", filter)
+		args = append(args, "--tb=short", "--no-header", "-q",
+			"--junitxml="+opts.reportFile, "-o", "junit_family=xunit1")
+		for _, c := range opts.only {
+			args = append(args, c.Package+"::"+c.Name)
 		}
-		args = append(args, "--tb=short", "--no-header", "-q")
 		return "python3", args
 
 	case "jest":
 		args := []string{"jest"}
-		if verbose {
+		if opts.verbose {
 			args = append(args, "--verbose")
 		}
-		if coverage {
-			args = append(args, "--coverage")
+		if opts.coverage {
+			args = append(args, "--coverage", "--coverageReporters=json-summary", "--coverageReporters=text",
+				"--coverageDirectory="+opts.coverDir)
 		}
-		if filter != "" {
-			args = append(args, "--testNamePattern", filter)
+		var files []string
+		if len(opts.only) > 0 {
+			var names []string
+			for _, c := range opts.only {
+				names = append(names, c.Name)
+				files = append(files, regexp.QuoteMeta(c.Package))
+			}
+			args = append(args, "--testNamePattern", anchoredPattern(names))
+		} else if opts.filter != "" {
+			args = append(args, "--testNamePattern", opts.filter)
 		}
-		args = append(args, "--forceExit", "--no-color")
-		return "npx", args
+		args = append(args, "--json", "--outputFile="+opts.reportFile, "--testLocationInResults",
+			"--forceExit", "--no-color")
+		return "npx", append(args, unique(files)...)
 
 	case "cargo":
 		args := []string{"test"}
-		if !verbose {
-			args = append(args, "--quiet")
+		if opts.filter != "" && len(opts.only) == 0 {
+			args = append(args, opts.filter)
+		}
+		args = append(args, "--")
+		if len(opts.only) > 0 {
+			var names []string
+			for _, c := range opts.only {
+				names = append(names, c.Name)
+			}
+			args = append(append(args, unique(names)...), "--exact")
 		}
-		if filter != "" {
-			args = append(args, filter)
+		if opts.textOnly {
+			args = append(args, "--format", "pretty")
+		} else {
+			args = append(args, "-Z", "unstable-options", "--format", "json", "--report-time")
 		}
-		args = append(args, "--", "--format", "json")
 		return "cargo", args
 
 	default:
@@ -203,140 +334,204 @@ func buildTestCommand(framework, filter string, verbose, coverage bool) (string,
 	}
 }
 
-// goTestEvent represents a single Go test JSON event.
-type goTestEvent struct {
-	Time    string  `json:"Time"`
-	Action  string  `json:"Action"`
-	Package string  `json:"Package"`
-	Test    string  `json:"Test"`
-	Output  string  `json:"Output"`
-	Elapsed float64 `json:"Elapsed"`
-}
+// parseTestReport reads the results of a finished test command. For Go it
+// replaces run.output with the human-readable part of the JSON stream.
+func parseTestReport(framework, dir string, run *testRun, opts testOptions) *sdk.TestReport {
+	var report *sdk.TestReport
+	var err error
 
-// parseTestResults parses test output and generates a structured report.
-func parseTestResults(framework, output string, execErr error, duration time.Duration) string {
 	switch framework {
 	case "go":
-		return parseGoTestResults(output, execErr, duration)
-	default:
-		return parseGenericTestResults(output, execErr, duration)
+		report, run.output = parseGoTestJSON(run.output)
+		if opts.coverage {
+			report.Coverage, _ = parseGoCoverProfile(opts.coverFile)
+		}
+	case "pytest":
+		if report, err = parsePytestJUnit(opts.reportFile); err == nil && opts.coverage {
+			report.Coverage, _ = parsePytestCoverage(opts.coverFile)
+		}
+	case "jest":
+		if report, err = parseJestJSON(opts.reportFile, dir); err == nil && opts.coverage {
+			report.Coverage, _ = parseJestCoverage(filepath.Join(opts.coverDir, "coverage-summary.json"), dir)
+		}
+	case "cargo":
+		report = parseCargoTest(run.output)
+	}
+
+	if report == nil {
+		// No report was written, e.g. the tests could not be collected
+		report = &sdk.TestReport{Framework: framework}
 	}
+	return report
 }
 
-// parseGoTestResults parses Go's JSON test output.
-func parseGoTestResults(output string, execErr error, duration time.Duration) string {
-	var (
-		passed     int
-		failed     int
-		skipped    int
-		failures   []string
-		packages   = make(map[string]string)
-		failOutput = make(map[string][]string)
-	)
-
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		var event goTestEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			continue
+// mergeRerun marks failed tests that passed when re-run as flaky.
+func mergeRerun(report, rerun *sdk.TestReport) {
+	passed := make(map[string]bool)
+	rerunPkgs := make(map[string]bool)
+	for _, c := range rerun.Tests {
+		rerunPkgs[c.Package] = true
+		if c.Status == sdk.TestPassed {
+			passed[testKey(c.Package, c.Name)] = true
 		}
+	}
+	for i := range report.Tests {
+		c := &report.Tests[i]
+		if c.Status == sdk.TestFailed && passed[testKey(c.Package, c.Name)] {
+			c.Status = sdk.TestPassed
+			c.Flaky = true
+		}
+	}
 
-		switch event.Action {
-		case "pass":
-			if event.Test != "" {
-				passed++
-			} else {
-				packages[event.Package] = "pass"
-			}
-		case "fail":
-			if event.Test != "" {
-				failed++
-				key := event.Package + "/" + event.Test
-				failures = append(failures, key)
-			} else {
-				packages[event.Package] = "fail"
-			}
-		case "skip":
-			if event.Test != "" {
-				skipped++
-			}
-		case "output":
-			if event.Test != "" {
-				key := event.Package + "/" + event.Test
-				failOutput[key] = append(failOutput[key], strings.TrimRight(event.Output, "\n"))
-			}
+	// A package stays failed unless its re-run passed
+	stillFailed := make(map[string]bool)
+	for _, p := range rerun.FailedPackages {
+		stillFailed[p] = true
+	}
+	var pkgs []string
+	for _, p := range report.FailedPackages {
+		if stillFailed[p] || !rerunPkgs[p] {
+			pkgs = append(pkgs, p)
 		}
 	}
+	report.FailedPackages = pkgs
+	report.Reruns++
+	report.Count()
+}
 
-	// If JSON parsing failed, fall back to generic
-	if passed == 0 && failed == 0 && skipped == 0 {
-		return parseGenericTestResults(output, execErr, duration)
+// formatTestReport renders a report as the tool's text output.
+func formatTestReport(report *sdk.TestReport, output string, verbose bool) string {
+	if len(report.Tests) == 0 {
+		// Nothing was parsed: the build failed or no tests ran
+		return parseGenericTestResults(output, report.Success, report.Duration, report.FailedPackages)
 	}
 
 	var result strings.Builder
-	total := passed + failed + skipped
-
-	if failed > 0 {
-		result.WriteString(fmt.Sprintf("FAIL - %d/%d tests failed", failed, total))
-	} else {
-		result.WriteString(fmt.Sprintf("PASS - %d tests passed", passed))
+	total := len(report.Tests)
+	switch {
+	case report.Failed > 0:
+		result.WriteString(fmt.Sprintf("FAIL - %d/%d tests failed", report.Failed, total))
+	case !report.Success:
+		result.WriteString(fmt.Sprintf("FAIL - %d tests passed, %d packages failed", report.Passed, len(report.FailedPackages)))
+	default:
+		result.WriteString(fmt.Sprintf("PASS - %d tests passed", report.Passed))
+	}
+	if report.Skipped > 0 {
+		result.WriteString(fmt.Sprintf(", %d skipped", report.Skipped))
 	}
-	if skipped > 0 {
-		result.WriteString(fmt.Sprintf(", %d skipped", skipped))
+	flaky := report.FlakyTests()
+	if len(flaky) > 0 {
+		result.WriteString(fmt.Sprintf(", %d flaky", len(flaky)))
 	}
-	result.WriteString(fmt.Sprintf(" (%.1fs)\n", duration.Seconds()))
+	result.WriteString(fmt.Sprintf(" (%.1fs)\n", report.Duration.Seconds()))
 
-	if len(failures) > 0 {
+	if len(flaky) > 0 {
+		result.WriteString("\nFlaky tests (passed when re-run):\n")
+		for _, c := range flaky {
+			result.WriteString(fmt.Sprintf("  ~ %s\n", testDisplayName(report.Framework, c)))
+		}
+	}
+
+	if failures := report.Failures(); len(failures) > 0 {
 		result.WriteString("\nFailed tests:\n")
-		for _, f := range failures {
-			result.WriteString(fmt.Sprintf("  x %s\n", f))
-			if lines, ok := failOutput[f]; ok {
-				start := 0
+		for _, c := range failures {
+			result.WriteString(fmt.Sprintf("  x %s", testDisplayName(report.Framework, c)))
+			if loc := c.Location(); loc != "" {
+				result.WriteString(fmt.Sprintf(" (%s)", loc))
+			}
+			result.WriteString("\n")
+			if c.Message != "" {
+				lines := strings.Split(c.Message, "\n")
 				if len(lines) > 10 {
-					start = len(lines) - 10
+					lines = lines[len(lines)-10:]
 				}
-				for _, l := range lines[start:] {
-					l = strings.TrimSpace(l)
-					if l != "" && !strings.HasPrefix(l, "=== RUN") && !strings.HasPrefix(l, "--- FAIL") {
-						result.WriteString(fmt.Sprintf("    %s\n", l))
-					}
+				for _, l := range lines {
+					result.WriteString(fmt.Sprintf("    %s\n", l))
 				}
 			}
 		}
 	}
 
-	var failedPkgs []string
-	for pkg, status := range packages {
-		if status == "fail" {
-			failedPkgs = append(failedPkgs, pkg)
+	if len(report.FailedPackages) > 0 {
+		result.WriteString(fmt.Sprintf("\nFailed packages: %s\n", strings.Join(report.FailedPackages, ", ")))
+	}
+
+	if cov := report.Coverage; cov != nil {
+		result.WriteString(fmt.Sprintf("\nCoverage: %.1f%% of statements\n", cov.Percent))
+		// Least covered files first
+		for i, f := range cov.Files {
+			if i == 10 {
+				result.WriteString(fmt.Sprintf("  ... and %d more files\n", len(cov.Files)-i))
+				break
+			}
+			result.WriteString(fmt.Sprintf("  %5.1f%%  %s (%d/%d)\n", f.Percent, f.File, f.Covered, f.Statements))
 		}
 	}
-	if len(failedPkgs) > 0 {
-		result.WriteString(fmt.Sprintf("\nFailed packages: %s\n", strings.Join(failedPkgs, ", ")))
+
+	// Build errors and the like are only in the output
+	if (verbose || len(report.FailedPackages) > 0) && strings.TrimSpace(output) != "" {
+		result.WriteString("\nOutput:\n")
+		result.WriteString(truncateTestOutput(output))
 	}
 
 	return result.String()
 }
 
-// parseGenericTestResults handles non-JSON test output.
-func parseGenericTestResults(output string, execErr error, duration time.Duration) string {
+// parseGenericTestResults handles output without per-test results.
+func parseGenericTestResults(output string, success bool, duration time.Duration, failedPackages []string) string {
 	var result strings.Builder
 
-	if execErr != nil {
-		result.WriteString(fmt.Sprintf("FAIL (%.1fs)\n\n", duration.Seconds()))
-	} else {
+	if success {
 		result.WriteString(fmt.Sprintf("PASS (%.1fs)\n\n", duration.Seconds()))
+	} else {
+		result.WriteString(fmt.Sprintf("FAIL (%.1fs)\n\n", duration.Seconds()))
+	}
+	if len(failedPackages) > 0 {
+		result.WriteString(fmt.Sprintf("Failed packages: %s\n\n", strings.Join(failedPackages, ", ")))
 	}
 
-	// Truncate long output
+	result.WriteString(truncateTestOutput(output))
+	return result.String()
+}
+
+// truncateTestOutput keeps the head and tail of long output.
+func truncateTestOutput(output string) string {
 	if len(output) > 5000 {
-		result.WriteString(output[:2000])
-		result.WriteString("\n\n... (output truncated) ...\n\n")
-		result.WriteString(output[len(output)-2000:])
-	} else {
-		result.WriteString(output)
+		return output[:2000] + "\n\n... (output truncated) ...\n\n" + output[len(output)-2000:]
 	}
+	return output
+}
 
-	return result.String()
+func testDisplayName(framework string, c sdk.TestCase) string {
+	switch {
+	case c.Package == "" || framework == "cargo":
+		return c.Name
+	case framework == "pytest":
+		return c.Package + "::" + c.Name
+	case framework == "jest":
+		return c.Package + ": " + c.Name
+	default:
+		return c.Package + "/" + c.Name
+	}
+}
+
+// anchoredPattern builds a regular expression matching exactly the names.
+func anchoredPattern(names []string) string {
+	quoted := unique(names)
+	for i, n := range quoted {
+		quoted[i] = regexp.QuoteMeta(n)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+func unique(items []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, item := range items {
+		if item != "" && !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
 }
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ginkida/gokin-sdk/cache"
+	"github.com/ginkida/gokin-sdk/git"
+	"github.com/ginkida/gokin-sdk/watcher"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// SearchOptions controls which files the search tools (grep, glob, tree
+// and list_dir) see and whether grep and glob results are cached.
+type SearchOptions struct {
+	// GitIgnore skips files matched by .gitignore files, .git/info/exclude
+	// and the global excludes file.
+	GitIgnore bool
+
+	// Ignore lists extra patterns in .gitignore syntax, such as
+	// "node_modules/" or "*.min.js". They apply even without GitIgnore.
+	Ignore []string
+
+	// CacheTTL keeps grep and glob results for this long; zero disables
+	// caching. Cached results only notice file changes through
+	// HandleFileChange, so enable it together with a watcher.Watcher.
+	CacheTTL time.Duration
+
+	// CacheSize bounds the number of cached searches of each kind.
+	CacheSize int
+}
+
+// DefaultSearchIgnore lists directories skipped even when not gitignored.
+var DefaultSearchIgnore = []string{".git/", "node_modules/", "__pycache__/", ".venv/"}
+
+// DefaultSearchOptions respects .gitignore and DefaultSearchIgnore and does
+// not cache.
+func DefaultSearchOptions() SearchOptions {
+	return SearchOptions{
+		GitIgnore: true,
+		Ignore:    DefaultSearchIgnore,
+		CacheSize: 100,
+	}
+}
+
+// SearchScope applies SearchOptions to a working directory. Share one scope
+// between the search tools so ignore files are parsed once and the cache is
+// shared, and feed it watcher events to keep the cache fresh:
+//
+//	scope := tools.NewSearchScope(workDir, opts)
+//	w, _ := watcher.NewWatcher(workDir, nil, watcher.Config{Enabled: true})
+//	w.SetOnFileChange(scope.HandleFileChange)
+//	w.Start()
+//	grep := tools.NewGrep(workDir)
+//	grep.SetScope(scope)
+//
+// Tools must use the same workDir as the scope. A nil scope applies no
+// filtering and no caching.
+type SearchScope struct {
+	workDir string
+	opts    SearchOptions
+	ignore  *git.GitIgnore
+	cache   *cache.SearchCache
+
+	mu     sync.Mutex
+	loaded bool // ignore files parsed
+}
+
+// NewSearchScope creates a search scope for workDir. Ignore files are parsed
+// on first use.
+func NewSearchScope(workDir string, opts SearchOptions) *SearchScope {
+	s := &SearchScope{
+		workDir: workDir,
+		opts:    opts,
+		ignore:  git.NewGitIgnore(workDir),
+	}
+	for _, p := range opts.Ignore {
+		s.ignore.AddPattern(p)
+	}
+	if opts.CacheTTL > 0 {
+		size := opts.CacheSize
+		if size <= 0 {
+			size = 100
+		}
+		s.cache = cache.NewSearchCache(size, opts.CacheTTL)
+	}
+	return s
+}
+
+// HandleFileChange invalidates cached results affected by a change to path.
+// A changed .gitignore reloads the ignore rules and clears the cache. It
+// has the signature of watcher.FileChangeHandler.
+func (s *SearchScope) HandleFileChange(path string, op watcher.Operation) {
+	if filepath.Base(path) == ".gitignore" {
+		s.mu.Lock()
+		s.loaded = false
+		s.mu.Unlock()
+		if s.cache != nil {
+			s.cache.Clear()
+		}
+		return
+	}
+	if s.cache == nil {
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	s.cache.InvalidateByRoot(path)
+}
+
+// ClearCache drops all cached results.
+func (s *SearchScope) ClearCache() {
+	if s != nil && s.cache != nil {
+		s.cache.Clear()
+	}
+}
+
+// Close stops the cache's background cleanup.
+func (s *SearchScope) Close() {
+	if s != nil && s.cache != nil {
+		s.cache.StopCleanup()
+	}
+}
+
+// ignorer returns the ignore matcher, parsing ignore files if needed.
+func (s *SearchScope) ignorer() *git.GitIgnore {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.GitIgnore && !s.loaded {
+		s.loaded = true
+		_ = s.ignore.Reload() // unreadable ignore files are skipped
+	}
+	return s.ignore
+}
+
+// ignored reports whether a single path is hidden from the tools.
+func (s *SearchScope) ignored(path string, isDir bool) bool {
+	ig := s.ignorer()
+	return ig != nil && ig.Match(path, isDir)
+}
+
+// walk calls fn for root and every entry below it that is not ignored,
+// without descending into ignored directories. When root itself is
+// ignored nothing is filtered, so explicit searches of such paths work.
+func (s *SearchScope) walk(ctx context.Context, root string, fn func(path string, d fs.DirEntry) error) error {
+	ig := s.ignorer()
+	filter := ig != nil && !ig.Match(root, true)
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if filter && path != root && ig.Match(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// glob returns the files below root matching pattern. Only the directories
+// the pattern can reach are walked.
+func (s *SearchScope) glob(ctx context.Context, root, pattern string) ([]string, error) {
+	base, pattern := doublestar.SplitPattern(filepath.ToSlash(filepath.Join(root, pattern)))
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, doublestar.ErrBadPattern
+	}
+	base = filepath.FromSlash(base)
+
+	// Without "**" a pattern only reaches a fixed depth
+	maxDepth := -1
+	if !strings.Contains(pattern, "**") {
+		maxDepth = strings.Count(pattern, "/")
+	}
+
+	var files []string
+	err := s.walk(ctx, base, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if maxDepth >= 0 && path != base && strings.Count(rel, "/") >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (s *SearchScope) cachedGrep(key string) (cache.GrepResult, bool) {
+	if s == nil || s.cache == nil {
+		return cache.GrepResult{}, false
+	}
+	return s.cache.GetGrep(key)
+}
+
+func (s *SearchScope) storeGrep(key, root string, result cache.GrepResult) {
+	if s == nil || s.cache == nil {
+		return
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	result.Root = root
+	s.cache.SetGrep(key, result)
+}
+
+func (s *SearchScope) cachedGlob(key string) (cache.GlobResult, bool) {
+	if s == nil || s.cache == nil {
+		return cache.GlobResult{}, false
+	}
+	return s.cache.GetGlob(key)
+}
+
+func (s *SearchScope) storeGlob(key, root string, result cache.GlobResult) {
+	if s == nil || s.cache == nil {
+		return
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	result.Root = root
+	s.cache.SetGlob(key, result)
+}
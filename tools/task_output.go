@@ -3,9 +3,13 @@ package tools
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/tasks"
 
 	"google.golang.org/genai"
 )
@@ -17,14 +21,35 @@ type AgentLister interface {
 	Wait(ctx context.Context, agentID string) (*sdk.AgentResult, error)
 }
 
-// TaskOutputTool retrieves results from background agents.
+// maxTaskOutputChunk caps the command output returned by one get.
+const maxTaskOutputChunk = 30000
+
+// TaskOutput is the structured result of getting a background command's
+// output, returned in ToolResult.Data.
+type TaskOutput struct {
+	TaskID   string `json:"task_id"`
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+	Offset   int64  `json:"offset"`            // offset of Output in the task's output
+	Next     int64  `json:"next"`              // offset to read from next
+	Dropped  int64  `json:"dropped,omitempty"` // bytes skipped because the buffer no longer kept them
+}
+
+// TaskOutputTool retrieves results from background agents and background
+// commands.
 type TaskOutputTool struct {
 	lister AgentLister
+	tasks  *tasks.Manager
+
+	// cursors remembers where the last get of each command stopped
+	cursors map[string]int64
+	mu      sync.Mutex
 }
 
 // NewTaskOutput creates a new TaskOutputTool.
 func NewTaskOutput() *TaskOutputTool {
-	return &TaskOutputTool{}
+	return &TaskOutputTool{cursors: make(map[string]int64)}
 }
 
 // SetLister sets the agent lister.
@@ -32,8 +57,17 @@ func (t *TaskOutputTool) SetLister(lister AgentLister) {
 	t.lister = lister
 }
 
-func (t *TaskOutputTool) Name() string        { return "task_output" }
-func (t *TaskOutputTool) Description() string { return "Get the output from a running or completed background task." }
+// SetTaskManager sets the manager of background commands, such as those
+// started by bash with run_in_background.
+func (t *TaskOutputTool) SetTaskManager(m *tasks.Manager) {
+	t.tasks = m
+}
+
+func (t *TaskOutputTool) Name() string { return "task_output" }
+func (t *TaskOutputTool) Description() string {
+	return "Get the output from a running or completed background task. " +
+		"For background commands, get returns the output since the last read, and input and wait send input or wait until the command is ready."
+}
 
 func (t *TaskOutputTool) Declaration() *genai.FunctionDeclaration {
 	return &genai.FunctionDeclaration{
@@ -44,16 +78,40 @@ func (t *TaskOutputTool) Declaration() *genai.FunctionDeclaration {
 			Properties: map[string]*genai.Schema{
 				"action": {
 					Type:        genai.TypeString,
-					Description: "Action to perform: get (get result for a task), list (list all tasks)",
-					Enum:        []string{"get", "list"},
+					Description: "Action to perform: get (get result or new output for a task), list (list all tasks), input (send a line to a command's stdin), wait (wait until a command's output matches or a port opens)",
+					Enum:        []string{"get", "list", "input", "wait"},
 				},
 				"task_id": {
 					Type:        genai.TypeString,
-					Description: "The agent/task ID to get output from (required for 'get' action)",
+					Description: "The agent/task ID (required for 'get', 'input' and 'wait' actions)",
 				},
 				"block": {
 					Type:        genai.TypeBoolean,
-					Description: "If true, wait for the task to complete before returning (default: true)",
+					Description: "If true, wait for the task to complete before returning (default: true for agents, false for commands)",
+				},
+				"offset": {
+					Type:        genai.TypeInteger,
+					Description: "Command output offset to read from (default: where the last get stopped)",
+				},
+				"tail": {
+					Type:        genai.TypeInteger,
+					Description: "Return only the last N lines of command output",
+				},
+				"input": {
+					Type:        genai.TypeString,
+					Description: "Text to send for the 'input' action; a trailing newline is added if missing",
+				},
+				"pattern": {
+					Type:        genai.TypeString,
+					Description: "Regular expression the command output must match for the 'wait' action",
+				},
+				"port": {
+					Type:        genai.TypeInteger,
+					Description: "TCP port on localhost that must accept connections for the 'wait' action",
+				},
+				"timeout": {
+					Type:        genai.TypeInteger,
+					Description: "Seconds to wait for the 'wait' action (default: 60)",
 				},
 			},
 			Required: []string{"action"},
@@ -62,7 +120,7 @@ func (t *TaskOutputTool) Declaration() *genai.FunctionDeclaration {
 }
 
 func (t *TaskOutputTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
-	if t.lister == nil {
+	if t.lister == nil && t.tasks == nil {
 		return sdk.NewErrorResult("task_output: no agent lister configured"), nil
 	}
 
@@ -76,9 +134,29 @@ func (t *TaskOutputTool) Execute(ctx context.Context, args map[string]any) (*sdk
 		return t.executeGet(ctx, args)
 	case "list":
 		return t.executeList()
+	case "input":
+		return t.executeInput(args)
+	case "wait":
+		return t.executeWait(ctx, args)
 	default:
-		return sdk.NewErrorResult(fmt.Sprintf("unknown action: %s (use get, list)", action)), nil
+		return sdk.NewErrorResult(fmt.Sprintf("unknown action: %s (use get, list, input, wait)", action)), nil
+	}
+}
+
+// command returns the background command with the task_id in args.
+func (t *TaskOutputTool) command(args map[string]any, action string) (*tasks.Task, *sdk.ToolResult) {
+	taskID, ok := sdk.GetString(args, "task_id")
+	if !ok || taskID == "" {
+		return nil, sdk.NewErrorResult(fmt.Sprintf("task_id is required for %s action", action))
+	}
+	if t.tasks == nil {
+		return nil, sdk.NewErrorResult(fmt.Sprintf("%s action requires a task manager", action))
 	}
+	task, ok := t.tasks.Get(taskID)
+	if !ok {
+		return nil, sdk.NewErrorResult(fmt.Sprintf("no background command %s", taskID))
+	}
+	return task, nil
 }
 
 func (t *TaskOutputTool) executeGet(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
@@ -87,6 +165,15 @@ func (t *TaskOutputTool) executeGet(ctx context.Context, args map[string]any) (*
 		return sdk.NewErrorResult("task_id is required for get action"), nil
 	}
 
+	if t.tasks != nil {
+		if task, ok := t.tasks.Get(taskID); ok {
+			return t.commandOutput(ctx, task, args), nil
+		}
+	}
+	if t.lister == nil {
+		return sdk.NewErrorResult(fmt.Sprintf("no background command %s", taskID)), nil
+	}
+
 	block := sdk.GetBoolDefault(args, "block", true)
 
 	// Try non-blocking first
@@ -108,20 +195,169 @@ func (t *TaskOutputTool) executeGet(ctx context.Context, args map[string]any) (*
 	return formatAgentResult(taskID, result), nil
 }
 
+// commandOutput returns a background command's output since the last
+// read, or from an explicit offset.
+func (t *TaskOutputTool) commandOutput(ctx context.Context, task *tasks.Task, args map[string]any) *sdk.ToolResult {
+	if sdk.GetBoolDefault(args, "block", false) {
+		if err := task.Wait(ctx); err != nil {
+			return sdk.NewErrorResult(fmt.Sprintf("failed to wait for task %s: %s", task.ID, err))
+		}
+	}
+
+	t.mu.Lock()
+	start := t.cursors[task.ID]
+	if _, ok := args["offset"]; ok {
+		start = int64(sdk.GetIntDefault(args, "offset", 0))
+	}
+	var chunk tasks.OutputChunk
+	if n := sdk.GetIntDefault(args, "tail", 0); n > 0 {
+		total := task.Output.Written()
+		chunk = tasks.OutputChunk{Data: task.Output.Tail(n), Offset: total, Next: total}
+	} else {
+		chunk = task.ReadOutput(start, maxTaskOutputChunk)
+	}
+	t.cursors[task.ID] = chunk.Next
+	t.mu.Unlock()
+
+	info := task.GetInfo()
+	data := TaskOutput{
+		TaskID:   task.ID,
+		Status:   info.Status,
+		ExitCode: info.ExitCode,
+		Output:   chunk.Data,
+		Offset:   chunk.Offset,
+		Next:     chunk.Next,
+		Dropped:  chunk.Dropped,
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Task %s is %s after %s", task.ID, info.Status, info.Duration.Round(time.Millisecond))
+	if task.IsComplete() && info.Status != tasks.StatusCancelled.String() {
+		fmt.Fprintf(&sb, " (exit code %d)", info.ExitCode)
+	}
+	sb.WriteString(".\n")
+	if chunk.Dropped > 0 {
+		fmt.Fprintf(&sb, "%d bytes of earlier output were dropped.\n", chunk.Dropped)
+	}
+	if chunk.Data == "" {
+		sb.WriteString("No new output.")
+	} else {
+		sb.WriteString("\n")
+		sb.WriteString(chunk.Data)
+	}
+	if total := task.Output.Written(); chunk.Next < total {
+		fmt.Fprintf(&sb, "\n\n%d more bytes available; call get again to continue from offset %d.", total-chunk.Next, chunk.Next)
+	}
+
+	return &sdk.ToolResult{
+		Content: sb.String(),
+		Data:    data,
+		Success: info.Status != tasks.StatusFailed.String(),
+	}
+}
+
+func (t *TaskOutputTool) executeInput(args map[string]any) (*sdk.ToolResult, error) {
+	task, errResult := t.command(args, "input")
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	input, ok := sdk.GetString(args, "input")
+	if !ok {
+		return sdk.NewErrorResult("input is required for input action"), nil
+	}
+	if !strings.HasSuffix(input, "\n") {
+		input += "\n"
+	}
+	if err := task.WriteInput(input); err != nil {
+		return sdk.NewErrorResult(fmt.Sprintf("failed to send input to task %s: %s", task.ID, err)), nil
+	}
+	return sdk.NewSuccessResult(fmt.Sprintf("Sent %d bytes to task %s.", len(input), task.ID)), nil
+}
+
+func (t *TaskOutputTool) executeWait(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
+	task, errResult := t.command(args, "wait")
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	pattern := sdk.GetStringDefault(args, "pattern", "")
+	port := sdk.GetIntDefault(args, "port", 0)
+	if pattern == "" && port == 0 {
+		return sdk.NewErrorResult("pattern or port is required for wait action"), nil
+	}
+	timeout := time.Duration(sdk.GetIntDefault(args, "timeout", 60)) * time.Second
+
+	start := time.Now()
+	ready, err := waitTaskReady(ctx, task, pattern, port, timeout)
+	if err != nil {
+		content := fmt.Sprintf("Task %s is not ready: %s", task.ID, err)
+		if tail := task.Output.Tail(20); tail != "" {
+			content += "\n\nLast output:\n" + tail
+		}
+		return &sdk.ToolResult{Content: content, Error: err.Error(), Success: false}, nil
+	}
+	return sdk.NewSuccessResult(fmt.Sprintf("Task %s is ready after %.1fs (%s).",
+		task.ID, time.Since(start).Seconds(), ready)), nil
+}
+
 func (t *TaskOutputTool) executeList() (*sdk.ToolResult, error) {
-	running := t.lister.ListRunning()
-	if len(running) == 0 {
+	var running []string
+	if t.lister != nil {
+		running = t.lister.ListRunning()
+	}
+	var commands []tasks.Info
+	if t.tasks != nil {
+		commands = t.tasks.List()
+	}
+	if len(running) == 0 && len(commands) == 0 {
 		return sdk.NewSuccessResult("No running tasks."), nil
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Running tasks (%d):\n", len(running)))
-	for _, id := range running {
-		sb.WriteString(fmt.Sprintf("- %s\n", id))
+	if len(running) > 0 {
+		sb.WriteString(fmt.Sprintf("Running tasks (%d):\n", len(running)))
+		for _, id := range running {
+			sb.WriteString(fmt.Sprintf("- %s\n", id))
+		}
+	}
+	if len(commands) > 0 {
+		sb.WriteString(fmt.Sprintf("Background commands (%d):\n", len(commands)))
+		for _, info := range commands {
+			sb.WriteString(fmt.Sprintf("- %s [%s] %s\n", info.ID, info.Status, info.Command))
+		}
 	}
 	return sdk.NewSuccessResult(sb.String()), nil
 }
 
+// waitTaskReady waits until the task's output matches pattern and port on
+// localhost accepts connections, for whichever are given, for up to
+// timeout. It describes what it saw on success.
+func waitTaskReady(ctx context.Context, task *tasks.Task, pattern string, port int, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var seen []string
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+		match, err := task.WaitForOutput(ctx, re)
+		if err != nil {
+			return "", err
+		}
+		seen = append(seen, fmt.Sprintf("output matched %q", match))
+	}
+	if port > 0 {
+		if err := task.WaitForPort(ctx, fmt.Sprintf("localhost:%d", port)); err != nil {
+			return "", err
+		}
+		seen = append(seen, fmt.Sprintf("port %d is open", port))
+	}
+	return strings.Join(seen, ", "), nil
+}
+
 func formatAgentResult(taskID string, result *sdk.AgentResult) *sdk.ToolResult {
 	if result == nil {
 		return sdk.NewErrorResult(fmt.Sprintf("no result for task %s", taskID))
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/tasks"
 
 	"google.golang.org/genai"
 )
@@ -14,9 +15,10 @@ type AgentCanceller interface {
 	Cancel(agentID string) error
 }
 
-// TaskStopTool stops a running background task.
+// TaskStopTool stops a running background agent or command.
 type TaskStopTool struct {
 	canceller AgentCanceller
+	tasks     *tasks.Manager
 }
 
 // NewTaskStop creates a new TaskStopTool.
@@ -29,6 +31,12 @@ func (t *TaskStopTool) SetCanceller(canceller AgentCanceller) {
 	t.canceller = canceller
 }
 
+// SetTaskManager sets the manager of background commands, such as those
+// started by bash with run_in_background.
+func (t *TaskStopTool) SetTaskManager(m *tasks.Manager) {
+	t.tasks = m
+}
+
 func (t *TaskStopTool) Name() string        { return "task_stop" }
 func (t *TaskStopTool) Description() string { return "Stop a running background task by its ID." }
 
@@ -54,7 +62,7 @@ func (t *TaskStopTool) Declaration() *genai.FunctionDeclaration {
 }
 
 func (t *TaskStopTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolResult, error) {
-	if t.canceller == nil {
+	if t.canceller == nil && t.tasks == nil {
 		return sdk.NewErrorResult("task_stop: no canceller configured"), nil
 	}
 
@@ -65,7 +73,20 @@ func (t *TaskStopTool) Execute(ctx context.Context, args map[string]any) (*sdk.T
 
 	reason := sdk.GetStringDefault(args, "reason", "")
 
-	if err := t.canceller.Cancel(taskID); err != nil {
+	var canceller AgentCanceller = t.canceller
+	if t.tasks != nil {
+		if task, ok := t.tasks.Get(taskID); ok {
+			if task.IsComplete() {
+				return sdk.NewSuccessResult(fmt.Sprintf("Task %s already finished (%s).", taskID, task.GetStatus())), nil
+			}
+			canceller = t.tasks
+		}
+	}
+	if canceller == nil {
+		return sdk.NewErrorResult(fmt.Sprintf("no background command %s", taskID)), nil
+	}
+
+	if err := canceller.Cancel(taskID); err != nil {
 		return sdk.NewErrorResult(fmt.Sprintf("failed to stop task %s: %s", taskID, err)), nil
 	}
 
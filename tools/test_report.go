@@ -0,0 +1,615 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/ginkida/gokin-sdk"
+)
+
+// maxMessageLines caps the failure message kept per test.
+const maxMessageLines = 20
+
+var (
+	// "    foo_test.go:12: want 1, got 2" from t.Error and friends
+	goReportLine = regexp.MustCompile(`^\s*([\w./\\-]+\.go):(\d+): `)
+	// "    /src/pkg/foo_test.go:12 +0x1d" from panic traces
+	goTraceLine = regexp.MustCompile(`([\w./\\-]+_test\.go):(\d+)`)
+	pyFileLine  = regexp.MustCompile(`(?m)^([^\s:]+\.py):(\d+):`)
+	jsStackLine = regexp.MustCompile(`([^\s()]+\.(?:js|jsx|ts|tsx|mjs|cjs)):(\d+):\d+`)
+	rustPanic   = regexp.MustCompile(`panicked at (?:'.*', )?([^\s:']+):(\d+):\d+`)
+	rustResult  = regexp.MustCompile(`^test (\S+) \.\.\. (ok|FAILED|ignored)`)
+	rustRunning = regexp.MustCompile(`^\s*Running (?:unittests )?(\S+)`)
+	ansiEscape  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// goTestEvent represents a single Go test JSON event.
+type goTestEvent struct {
+	Time    string  `json:"Time"`
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Output  string  `json:"Output"`
+	Elapsed float64 `json:"Elapsed"`
+}
+
+// parseGoTestJSON parses `go test -json` output. It also returns the
+// output as plain text, for reporting failures that are not tests.
+func parseGoTestJSON(output string) (*sdk.TestReport, string) {
+	report := &sdk.TestReport{Framework: "go"}
+	index := make(map[string]int)
+	outputs := make(map[string][]string)
+	var plain strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var event goTestEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+			plain.WriteString(line + "\n")
+			continue
+		}
+
+		if event.Action == "build-output" {
+			plain.WriteString(event.Output)
+			continue
+		}
+		if event.Test == "" {
+			if event.Action == "output" {
+				plain.WriteString(event.Output)
+			}
+			if event.Action == "fail" {
+				report.FailedPackages = append(report.FailedPackages, event.Package)
+			}
+			continue
+		}
+
+		key := testKey(event.Package, event.Test)
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Tests)
+			index[key] = i
+			report.Tests = append(report.Tests, sdk.TestCase{Name: event.Test, Package: event.Package})
+		}
+		c := &report.Tests[i]
+		switch event.Action {
+		case "output":
+			outputs[key] = append(outputs[key], strings.TrimRight(event.Output, "\n"))
+		case "pass", "fail", "skip":
+			c.Status = sdk.TestStatus(event.Action)
+			c.Duration = seconds(event.Elapsed)
+		}
+	}
+
+	// Drop tests that never finished, such as ones interrupted by a timeout
+	tests := report.Tests[:0]
+	for _, c := range report.Tests {
+		if c.Status == "" {
+			continue
+		}
+		if c.Status == sdk.TestFailed {
+			lines := outputs[testKey(c.Package, c.Name)]
+			c.Message = goFailureMessage(lines)
+			c.File, c.Line = goFailureLocation(lines)
+		}
+		tests = append(tests, c)
+	}
+	report.Tests = tests
+	report.Count()
+	return report, plain.String()
+}
+
+func goFailureMessage(lines []string) string {
+	var kept []string
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if t == "" || strings.HasPrefix(t, "=== ") || strings.HasPrefix(t, "--- ") {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return lastLines(kept, maxMessageLines)
+}
+
+func goFailureLocation(lines []string) (string, int) {
+	for _, l := range lines {
+		if m := goReportLine.FindStringSubmatch(l); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			return m[1], n
+		}
+	}
+	for _, l := range lines {
+		if m := goTraceLine.FindStringSubmatch(l); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			return m[1], n
+		}
+	}
+	return "", 0
+}
+
+// junitSuite is a JUnit XML <testsuites> or <testsuite> element.
+type junitSuite struct {
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	File      string       `xml:"file,attr"`
+	Line      int          `xml:"line,attr"`
+	Time      float64      `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure"`
+	Error     *junitResult `xml:"error"`
+	Skipped   *junitResult `xml:"skipped"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parsePytestJUnit parses the JUnit XML report written by pytest with
+// junit_family=xunit1, which records each test's file and line.
+func parsePytestJUnit(path string) (*sdk.TestReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	report := &sdk.TestReport{Framework: "pytest"}
+	var walk func(s junitSuite)
+	walk = func(s junitSuite) {
+		for _, jc := range s.Cases {
+			report.Tests = append(report.Tests, pytestCase(jc))
+		}
+		for _, child := range s.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+	report.Count()
+	return report, nil
+}
+
+func pytestCase(jc junitCase) sdk.TestCase {
+	c := sdk.TestCase{
+		Name:     jc.Name,
+		Package:  jc.File,
+		Status:   sdk.TestPassed,
+		Duration: seconds(jc.Time),
+		File:     jc.File,
+	}
+
+	// Name tests by their node ID within the file: "TestClass::test_name"
+	if jc.File != "" {
+		module := strings.ReplaceAll(strings.TrimSuffix(filepath.ToSlash(jc.File), ".py"), "/", ".")
+		if class := strings.TrimPrefix(jc.ClassName, module+"."); class != jc.ClassName && class != "" {
+			c.Name = class + "::" + jc.Name
+		}
+	} else {
+		c.Package = jc.ClassName
+	}
+
+	result := jc.Failure
+	if result == nil {
+		result = jc.Error
+	}
+	switch {
+	case result != nil:
+		c.Status = sdk.TestFailed
+		c.Message = result.Message
+		if c.Message == "" {
+			c.Message = lastLines(strings.Split(strings.TrimSpace(result.Text), "\n"), maxMessageLines)
+		}
+		c.File, c.Line = pytestFailureLocation(result.Text, jc.File)
+	case jc.Skipped != nil:
+		c.Status = sdk.TestSkipped
+		c.Message = jc.Skipped.Message
+	}
+	if c.Line == 0 && jc.Line > 0 {
+		c.File, c.Line = jc.File, jc.Line+1 // xunit1 lines are 0-based
+	}
+	return c
+}
+
+// pytestFailureLocation finds the traceback entry in the test's own file,
+// or else the first one.
+func pytestFailureLocation(text, testFile string) (string, int) {
+	matches := pyFileLine.FindAllStringSubmatch(text, -1)
+	for _, m := range matches {
+		if testFile != "" && filepath.ToSlash(m[1]) == filepath.ToSlash(testFile) {
+			n, _ := strconv.Atoi(m[2])
+			return m[1], n
+		}
+	}
+	if len(matches) > 0 {
+		n, _ := strconv.Atoi(matches[0][2])
+		return matches[0][1], n
+	}
+	return testFile, 0
+}
+
+// jestReport is the output of `jest --json`.
+type jestReport struct {
+	TestResults []struct {
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Message          string `json:"message"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Status          string   `json:"status"`
+			Duration        *float64 `json:"duration"` // milliseconds
+			FailureMessages []string `json:"failureMessages"`
+			Location        *struct {
+				Line int `json:"line"`
+			} `json:"location"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+// parseJestJSON parses the report written by `jest --json --outputFile`.
+// Paths are made relative to workDir.
+func parseJestJSON(path, workDir string) (*sdk.TestReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jr jestReport
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return nil, err
+	}
+
+	report := &sdk.TestReport{Framework: "jest"}
+	for _, suite := range jr.TestResults {
+		file := relToDir(workDir, suite.Name)
+		if len(suite.AssertionResults) == 0 && suite.Status == "failed" {
+			// The file failed as a whole, e.g. it does not compile
+			report.FailedPackages = append(report.FailedPackages, file)
+			continue
+		}
+		for _, a := range suite.AssertionResults {
+			c := sdk.TestCase{Name: a.FullName, Package: file, File: file}
+			if a.Duration != nil {
+				c.Duration = time.Duration(*a.Duration * float64(time.Millisecond))
+			}
+			if a.Location != nil {
+				c.Line = a.Location.Line
+			}
+			switch a.Status {
+			case "passed":
+				c.Status = sdk.TestPassed
+			case "failed":
+				c.Status = sdk.TestFailed
+				message := ansiEscape.ReplaceAllString(strings.Join(a.FailureMessages, "\n"), "")
+				c.Message = firstLines(message, maxMessageLines)
+				if line := jestFailureLine(message, suite.Name); line > 0 {
+					c.Line = line
+				}
+			default: // pending, skipped, todo, disabled
+				c.Status = sdk.TestSkipped
+			}
+			report.Tests = append(report.Tests, c)
+		}
+	}
+	report.Count()
+	return report, nil
+}
+
+// jestFailureLine finds the line of the stack frame in the test file.
+func jestFailureLine(message, testFile string) int {
+	for _, m := range jsStackLine.FindAllStringSubmatch(message, -1) {
+		if m[1] == testFile || strings.HasSuffix(testFile, "/"+strings.TrimPrefix(m[1], "./")) {
+			n, _ := strconv.Atoi(m[2])
+			return n
+		}
+	}
+	return 0
+}
+
+// cargoTestEvent is a libtest JSON event.
+type cargoTestEvent struct {
+	Type     string  `json:"type"`
+	Event    string  `json:"event"`
+	Name     string  `json:"name"`
+	ExecTime float64 `json:"exec_time"`
+	Stdout   string  `json:"stdout"`
+}
+
+// parseCargoTest parses `cargo test` output, either libtest JSON events or
+// the default text format. Tests are grouped by the test target that ran
+// them.
+func parseCargoTest(output string) *sdk.TestReport {
+	report := &sdk.TestReport{Framework: "cargo"}
+	index := make(map[string]int)
+	target := ""
+	var section string // test whose captured output is being read
+	var sectionLines []string
+
+	add := func(name string) *sdk.TestCase {
+		key := testKey(target, name)
+		if i, ok := index[key]; ok {
+			return &report.Tests[i]
+		}
+		index[key] = len(report.Tests)
+		report.Tests = append(report.Tests, sdk.TestCase{Name: name, Package: target})
+		return &report.Tests[len(report.Tests)-1]
+	}
+	flush := func() {
+		if section != "" {
+			if i, ok := index[testKey(target, section)]; ok {
+				setCargoFailure(&report.Tests[i], strings.Join(sectionLines, "\n"))
+			}
+		}
+		section, sectionLines = "", nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		var event cargoTestEvent
+		if strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &event) == nil {
+			if event.Type != "test" || event.Name == "" {
+				continue
+			}
+			switch event.Event {
+			case "ok":
+				c := add(event.Name)
+				c.Status, c.Duration = sdk.TestPassed, seconds(event.ExecTime)
+			case "failed", "timeout":
+				c := add(event.Name)
+				c.Status, c.Duration = sdk.TestFailed, seconds(event.ExecTime)
+				setCargoFailure(c, event.Stdout)
+			case "ignored":
+				add(event.Name).Status = sdk.TestSkipped
+			}
+			continue
+		}
+
+		// Text format
+		switch {
+		case rustRunning.MatchString(line):
+			flush()
+			target = rustRunning.FindStringSubmatch(line)[1]
+		case rustResult.MatchString(line):
+			m := rustResult.FindStringSubmatch(line)
+			c := add(m[1])
+			switch m[2] {
+			case "ok":
+				c.Status = sdk.TestPassed
+			case "FAILED":
+				c.Status = sdk.TestFailed
+			default:
+				c.Status = sdk.TestSkipped
+			}
+		case strings.HasPrefix(line, "---- ") && strings.HasSuffix(line, " stdout ----"):
+			flush()
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "---- "), " stdout ----")
+		case section != "" && (line == "failures:" || strings.HasPrefix(line, "test result:")):
+			flush()
+		case section != "":
+			sectionLines = append(sectionLines, line)
+		}
+	}
+	flush()
+	report.Count()
+	return report
+}
+
+func setCargoFailure(c *sdk.TestCase, stdout string) {
+	stdout = strings.TrimSpace(stdout)
+	if stdout == "" {
+		return
+	}
+	c.Message = firstLines(stdout, maxMessageLines)
+	if m := rustPanic.FindStringSubmatch(stdout); m != nil {
+		c.File = m[1]
+		c.Line, _ = strconv.Atoi(m[2])
+	}
+}
+
+// parseGoCoverProfile parses a Go coverage profile into per-file
+// statement coverage.
+func parseGoCoverProfile(path string) (*sdk.CoverageReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type block struct {
+		statements int
+		covered    bool
+	}
+	files := make(map[string]map[string]*block)
+	for _, line := range strings.Split(string(data), "\n") {
+		// file.go:12.34,15.2 3 1
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		colon := strings.LastIndex(fields[0], ":")
+		if colon < 0 {
+			continue
+		}
+		file, pos := fields[0][:colon], fields[0][colon+1:]
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		// With -coverpkg the same block can be listed once per test binary
+		if files[file] == nil {
+			files[file] = make(map[string]*block)
+		}
+		b, ok := files[file][pos]
+		if !ok {
+			b = &block{statements: statements}
+			files[file][pos] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+
+	report := &sdk.CoverageReport{}
+	total, covered := 0, 0
+	for file, blocks := range files {
+		fc := sdk.FileCoverage{File: file}
+		for _, b := range blocks {
+			fc.Statements += b.statements
+			if b.covered {
+				fc.Covered += b.statements
+			}
+		}
+		fc.Percent = percent(fc.Covered, fc.Statements)
+		total += fc.Statements
+		covered += fc.Covered
+		report.Files = append(report.Files, fc)
+	}
+	report.Percent = percent(covered, total)
+	sortCoverage(report)
+	return report, nil
+}
+
+// parsePytestCoverage parses the JSON report of coverage.py
+// (--cov-report=json).
+func parsePytestCoverage(path string) (*sdk.CoverageReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type summary struct {
+		Statements int     `json:"num_statements"`
+		Covered    int     `json:"covered_lines"`
+		Percent    float64 `json:"percent_covered"`
+	}
+	var cov struct {
+		Files map[string]struct {
+			Summary summary `json:"summary"`
+		} `json:"files"`
+		Totals summary `json:"totals"`
+	}
+	if err := json.Unmarshal(data, &cov); err != nil {
+		return nil, err
+	}
+
+	report := &sdk.CoverageReport{Percent: round1(cov.Totals.Percent)}
+	for file, f := range cov.Files {
+		report.Files = append(report.Files, sdk.FileCoverage{
+			File:       file,
+			Percent:    round1(f.Summary.Percent),
+			Statements: f.Summary.Statements,
+			Covered:    f.Summary.Covered,
+		})
+	}
+	sortCoverage(report)
+	return report, nil
+}
+
+// parseJestCoverage parses the json-summary coverage report of jest.
+func parseJestCoverage(path, workDir string) (*sdk.CoverageReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type metrics struct {
+		Statements struct {
+			Total   int     `json:"total"`
+			Covered int     `json:"covered"`
+			Pct     float64 `json:"pct"`
+		} `json:"statements"`
+	}
+	var summary map[string]metrics
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	report := &sdk.CoverageReport{}
+	for file, m := range summary {
+		if file == "total" {
+			report.Percent = round1(m.Statements.Pct)
+			continue
+		}
+		report.Files = append(report.Files, sdk.FileCoverage{
+			File:       relToDir(workDir, file),
+			Percent:    round1(m.Statements.Pct),
+			Statements: m.Statements.Total,
+			Covered:    m.Statements.Covered,
+		})
+	}
+	sortCoverage(report)
+	return report, nil
+}
+
+// sortCoverage orders files from least to most covered.
+func sortCoverage(report *sdk.CoverageReport) {
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Percent != b.Percent {
+			return a.Percent < b.Percent
+		}
+		return a.File < b.File
+	})
+}
+
+func testKey(pkg, name string) string {
+	return pkg + "\x00" + name
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round1(float64(part) * 100 / float64(total))
+}
+
+func round1(f float64) float64 {
+	return float64(int(f*10+0.5)) / 10
+}
+
+func firstLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = append(lines[:n], "...")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lastLines(lines []string, n int) string {
+	if len(lines) > n {
+		lines = append([]string{"..."}, lines[len(lines)-n:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func relToDir(dir, path string) string {
+	if dir == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -17,11 +17,21 @@ import (
 // TreeTool displays a recursive directory tree.
 type TreeTool struct {
 	workDir string
+	scope   *SearchScope
 }
 
-// NewTree creates a new TreeTool.
+// NewTree creates a new TreeTool. It hides entries ignored under
+// DefaultSearchOptions.
 func NewTree(workDir string) *TreeTool {
-	return &TreeTool{workDir: workDir}
+	return &TreeTool{
+		workDir: workDir,
+		scope:   NewSearchScope(workDir, DefaultSearchOptions()),
+	}
+}
+
+// SetScope sets the ignore rules used to hide entries.
+func (t *TreeTool) SetScope(scope *SearchScope) {
+	t.scope = scope
 }
 
 func (t *TreeTool) Name() string { return "tree" }
@@ -90,15 +100,21 @@ func (t *TreeTool) Execute(ctx context.Context, args map[string]any) (*sdk.ToolR
 	var builder strings.Builder
 	builder.WriteString(rootPath + "\n")
 
+	// Show everything inside a directory that is itself ignored
+	scope := t.scope
+	if scope.ignored(rootPath, true) {
+		scope = nil
+	}
+
 	var dirCount, fileCount int
-	buildTree(ctx, rootPath, "", maxDepth, 0, pattern, showHidden, dirsOnly, &builder, &dirCount, &fileCount)
+	buildTree(ctx, scope, rootPath, "", maxDepth, 0, pattern, showHidden, dirsOnly, &builder, &dirCount, &fileCount)
 
 	builder.WriteString(fmt.Sprintf("\n%d directories, %d files\n", dirCount, fileCount))
 
 	return sdk.NewSuccessResult(builder.String()), nil
 }
 
-func buildTree(ctx context.Context, dir, prefix string, maxDepth, currentDepth int, pattern string, showHidden, dirsOnly bool, builder *strings.Builder, dirCount, fileCount *int) {
+func buildTree(ctx context.Context, scope *SearchScope, dir, prefix string, maxDepth, currentDepth int, pattern string, showHidden, dirsOnly bool, builder *strings.Builder, dirCount, fileCount *int) {
 	select {
 	case <-ctx.Done():
 		return
@@ -133,6 +149,9 @@ func buildTree(ctx context.Context, dir, prefix string, maxDepth, currentDepth i
 		if dirsOnly && !entry.IsDir() {
 			continue
 		}
+		if scope.ignored(filepath.Join(dir, name), entry.IsDir()) {
+			continue
+		}
 		if pattern != "" && !entry.IsDir() {
 			matched, _ := doublestar.Match(pattern, name)
 			if !matched {
@@ -155,7 +174,7 @@ func buildTree(ctx context.Context, dir, prefix string, maxDepth, currentDepth i
 		if entry.IsDir() {
 			builder.WriteString("/\n")
 			*dirCount++
-			buildTree(ctx, filepath.Join(dir, entry.Name()), childPrefix, maxDepth, currentDepth+1, pattern, showHidden, dirsOnly, builder, dirCount, fileCount)
+			buildTree(ctx, scope, filepath.Join(dir, entry.Name()), childPrefix, maxDepth, currentDepth+1, pattern, showHidden, dirsOnly, builder, dirCount, fileCount)
 		} else {
 			builder.WriteString("\n")
 			*fileCount++
@@ -12,13 +12,28 @@ import (
 )
 
 // WriteTool writes content to files.
-type WriteTool struct{}
+type WriteTool struct {
+	review changeReview
+}
 
 // NewWrite creates a new WriteTool.
 func NewWrite() *WriteTool {
 	return &WriteTool{}
 }
 
+// SetApprover sets a callback that must accept each write, shown as a diff,
+// before the file is written. Without one, the approver carried by the run
+// context is used (see sdk.WithReviewMode).
+func (t *WriteTool) SetApprover(approver sdk.ChangeApprover) {
+	t.review.approver = approver
+}
+
+// SetDiffPreview controls whether results carry the diff of the write in
+// ExecutionSummary (default: true, as in config.DiffPreviewConfig).
+func (t *WriteTool) SetDiffPreview(enabled bool) {
+	t.review.noPreview = !enabled
+}
+
 func (t *WriteTool) Name() string { return "write" }
 
 func (t *WriteTool) Description() string {
@@ -57,23 +72,40 @@ func (t *WriteTool) Execute(ctx context.Context, args map[string]any) (*sdk.Tool
 		return sdk.NewErrorResult("content is required"), nil
 	}
 
+	// Check if file exists
+	old, readErr := os.ReadFile(filePath)
+	isNew := os.IsNotExist(readErr)
+	if readErr != nil && !isNew {
+		return sdk.NewErrorResult(fmt.Sprintf("error reading file: %s", readErr)), nil
+	}
+
+	// Preview the change and wait for approval
+	action := "update"
+	if isNew {
+		action = "create"
+	}
+	summary := t.review.fileChangeSummary(ctx, t.Name(), action, filePath, string(old), content, isNew)
+	if err := t.review.approve(ctx, summary); err != nil {
+		return rejectedResult(summary, err), nil
+	}
+
 	// Create parent directories
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return sdk.NewErrorResult(fmt.Sprintf("error creating directories: %s", err)), nil
 	}
 
-	// Check if file exists
-	_, existErr := os.Stat(filePath)
-	isNew := os.IsNotExist(existErr)
-
 	// Write file
 	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
 		return sdk.NewErrorResult(fmt.Sprintf("error writing file: %s", err)), nil
 	}
 
+	var result *sdk.ToolResult
 	if isNew {
-		return sdk.NewSuccessResult(fmt.Sprintf("Created new file: %s (%d bytes)", filePath, len(content))), nil
+		result = sdk.NewSuccessResult(fmt.Sprintf("Created new file: %s (%d bytes)", filePath, len(content)))
+	} else {
+		result = sdk.NewSuccessResult(fmt.Sprintf("Updated file: %s (%d bytes)", filePath, len(content)))
 	}
-	return sdk.NewSuccessResult(fmt.Sprintf("Updated file: %s (%d bytes)", filePath, len(content))), nil
+	result.ExecutionSummary = summary
+	return result, nil
 }
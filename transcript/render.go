@@ -0,0 +1,335 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/ginkida/gokin-sdk/highlight"
+)
+
+// maxResultChars bounds tool output shown in transcripts.
+const maxResultChars = 4000
+
+// --- Markdown ---
+
+// Markdown renders the transcript as Markdown. Tool calls are collapsible
+// <details> blocks, which GitHub renders.
+func (t *Transcript) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s\n\n", t.title())
+	if meta := t.meta(); meta != "" {
+		fmt.Fprintf(&sb, "_%s_\n\n", meta)
+	}
+
+	for _, turn := range t.Turns {
+		fmt.Fprintf(&sb, "### %s\n\n", roleLabel(turn.Role))
+		if turn.Text != "" {
+			sb.WriteString(strings.TrimSpace(turn.Text))
+			sb.WriteString("\n\n")
+		}
+		for _, call := range turn.ToolCalls {
+			status := "✓"
+			if call.Error != "" {
+				status = "✗"
+			} else if call.Result == nil {
+				status = "…"
+			}
+			fmt.Fprintf(&sb, "<details>\n<summary>%s <code>%s</code> %s</summary>\n\n",
+				status, html.EscapeString(call.Name), html.EscapeString(callLabel(call)))
+			if args := argsJSON(call); args != "" {
+				sb.WriteString("**Arguments**\n\n")
+				writeFenced(&sb, "json", args)
+			}
+			if call.Diff != "" {
+				sb.WriteString("**Diff**\n\n")
+				writeFenced(&sb, "diff", call.Diff)
+			}
+			if call.Error != "" {
+				fmt.Fprintf(&sb, "**Error:** %s\n\n", call.Error)
+			}
+			if out := resultText(call); out != "" {
+				sb.WriteString("**Result**\n\n")
+				writeFenced(&sb, "", out)
+			}
+			sb.WriteString("</details>\n\n")
+		}
+	}
+
+	if t.Result != nil || t.Usage != nil {
+		sb.WriteString("---\n\n")
+		sb.WriteString("| | |\n|---|---|\n")
+		for _, row := range t.stats() {
+			fmt.Fprintf(&sb, "| %s | %s |\n", row[0], strings.ReplaceAll(row[1], "|", `\|`))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// writeFenced writes a code block whose fence is longer than any backtick
+// run in content.
+func writeFenced(sb *strings.Builder, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(sb, "%s%s\n%s\n%s\n\n", fence, lang, strings.TrimRight(content, "\n"), fence)
+}
+
+// --- HTML ---
+
+const htmlStyle = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;max-width:960px;margin:2em auto;padding:0 1em;color:#1f2328;line-height:1.5}
+h1{font-size:1.6em;border-bottom:1px solid #d0d7de;padding-bottom:.3em}
+.meta{color:#656d76;font-size:.9em}
+.turn{border:1px solid #d0d7de;border-radius:6px;margin:1em 0;padding:.5em 1em}
+.turn.user{background:#f6f8fa}
+.role{font-weight:600;color:#656d76;font-size:.85em;text-transform:uppercase}
+.text{white-space:pre-wrap}
+details{margin:.5em 0;border-left:3px solid #d0d7de;padding-left:.75em}
+details.error{border-left-color:#cf222e}
+summary{cursor:pointer;font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:.9em}
+pre{overflow-x:auto;padding:.75em;border-radius:6px;font-size:.85em}
+pre.plain{background:#f6f8fa}
+.label{font-weight:600;font-size:.85em;margin-top:.5em}
+.err{color:#cf222e}
+table{border-collapse:collapse;margin-top:1em}
+td{border:1px solid #d0d7de;padding:.25em .75em}
+`
+
+// HTML renders the transcript as a self-contained HTML page with inline
+// styles and syntax highlighting.
+func (t *Transcript) HTML() string {
+	h := highlight.NewHTML("github")
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n", html.EscapeString(t.title()), htmlStyle)
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n", html.EscapeString(t.title()))
+	if meta := t.meta(); meta != "" {
+		fmt.Fprintf(&sb, "<p class=\"meta\">%s</p>\n", html.EscapeString(meta))
+	}
+
+	for _, turn := range t.Turns {
+		fmt.Fprintf(&sb, "<div class=\"turn %s\">\n<div class=\"role\">%s</div>\n", html.EscapeString(turn.Role), roleLabel(turn.Role))
+		if turn.Text != "" {
+			fmt.Fprintf(&sb, "<div class=\"text\">%s</div>\n", html.EscapeString(strings.TrimSpace(turn.Text)))
+		}
+		for _, call := range turn.ToolCalls {
+			class := ""
+			if call.Error != "" {
+				class = ` class="error"`
+			}
+			fmt.Fprintf(&sb, "<details%s>\n<summary>%s %s</summary>\n", class,
+				html.EscapeString(call.Name), html.EscapeString(callLabel(call)))
+			if args := argsJSON(call); args != "" {
+				sb.WriteString("<div class=\"label\">Arguments</div>\n")
+				sb.WriteString(highlightHTML(h, args, "json"))
+			}
+			if call.Diff != "" {
+				sb.WriteString("<div class=\"label\">Diff</div>\n")
+				sb.WriteString(highlightHTML(h, call.Diff, "diff"))
+			}
+			if call.Error != "" {
+				fmt.Fprintf(&sb, "<div class=\"label err\">Error: %s</div>\n", html.EscapeString(call.Error))
+			}
+			if out := resultText(call); out != "" {
+				sb.WriteString("<div class=\"label\">Result</div>\n")
+				fmt.Fprintf(&sb, "<pre class=\"plain\">%s</pre>\n", html.EscapeString(out))
+			}
+			sb.WriteString("</details>\n")
+		}
+		sb.WriteString("</div>\n")
+	}
+
+	if t.Result != nil || t.Usage != nil {
+		sb.WriteString("<table>\n")
+		for _, row := range t.stats() {
+			fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(row[0]), html.EscapeString(row[1]))
+		}
+		sb.WriteString("</table>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+func highlightHTML(h *highlight.Highlighter, code, lang string) string {
+	out := h.Highlight(code, lang)
+	if out == code {
+		// Highlighting failed and returned the raw code
+		return fmt.Sprintf("<pre class=\"plain\">%s</pre>\n", html.EscapeString(code))
+	}
+	return out + "\n"
+}
+
+// --- JSONL ---
+
+// jsonlRecord is one line of a JSONL transcript.
+type jsonlRecord struct {
+	Type string `json:"type"` // "transcript", "turn", "usage" or "result"
+
+	Title     string     `json:"title,omitempty"`
+	SessionID string     `json:"session_id,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	*Turn
+	Usage  *Usage  `json:"usage,omitempty"`
+	Result *Result `json:"result,omitempty"`
+}
+
+// JSONL renders the transcript as JSON lines: a header, one line per turn,
+// then usage and result.
+func (t *Transcript) JSONL() ([]byte, error) {
+	records := []jsonlRecord{{Type: "transcript", Title: t.Title, SessionID: t.SessionID, CreatedAt: &t.CreatedAt}}
+	for _, turn := range t.Turns {
+		records = append(records, jsonlRecord{Type: "turn", Turn: turn})
+	}
+	if t.Usage != nil {
+		records = append(records, jsonlRecord{Type: "usage", Usage: t.Usage})
+	}
+	if t.Result != nil {
+		records = append(records, jsonlRecord{Type: "result", Result: t.Result})
+	}
+
+	var result []byte
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling transcript: %w", err)
+		}
+		result = append(result, line...)
+		result = append(result, '\n')
+	}
+	return result, nil
+}
+
+// --- helpers ---
+
+// title falls back to the first user message.
+func (t *Transcript) title() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	for _, turn := range t.Turns {
+		if turn.Role == "user" && turn.Text != "" {
+			title := strings.Join(strings.Fields(turn.Text), " ")
+			if runes := []rune(title); len(runes) > 80 {
+				title = string(runes[:77]) + "..."
+			}
+			return title
+		}
+	}
+	return "Transcript"
+}
+
+func (t *Transcript) meta() string {
+	var parts []string
+	if t.SessionID != "" {
+		parts = append(parts, "Session "+t.SessionID)
+	}
+	if !t.CreatedAt.IsZero() {
+		parts = append(parts, t.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// stats returns the summary rows shown after the conversation.
+func (t *Transcript) stats() [][2]string {
+	var rows [][2]string
+	if r := t.Result; r != nil {
+		rows = append(rows, [2]string{"Turns", fmt.Sprint(r.Turns)})
+		rows = append(rows, [2]string{"Duration", r.Duration.Round(time.Millisecond).String()})
+		if r.Error != "" {
+			rows = append(rows, [2]string{"Error", r.Error})
+		}
+	}
+	if u := t.Usage; u != nil {
+		if u.InputTokens > 0 || u.OutputTokens > 0 {
+			rows = append(rows, [2]string{"Input tokens", fmt.Sprint(u.InputTokens)})
+			rows = append(rows, [2]string{"Output tokens", fmt.Sprint(u.OutputTokens)})
+		}
+		rows = append(rows, [2]string{"Total tokens", fmt.Sprint(u.TotalTokens)})
+	}
+	return rows
+}
+
+func roleLabel(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "model":
+		return "Assistant"
+	default:
+		return html.EscapeString(role)
+	}
+}
+
+// callLabel picks the most telling argument of a call for its summary.
+func callLabel(c *ToolCall) string {
+	for _, key := range []string{"file_path", "path", "command", "pattern", "query", "url"} {
+		if v, ok := c.Args[key].(string); ok && v != "" {
+			if r := []rune(v); len(r) > 100 {
+				v = string(r[:97]) + "..."
+			}
+			return v
+		}
+	}
+	return ""
+}
+
+// argsJSON formats a call's arguments. Contents already shown as a diff
+// are left out.
+func argsJSON(c *ToolCall) string {
+	args := c.Args
+	if c.Diff != "" {
+		args = make(map[string]any, len(c.Args))
+		for k, v := range c.Args {
+			switch k {
+			case "old_string", "new_string", "content", "patch":
+				continue
+			}
+			args[k] = v
+		}
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return fmt.Sprint(args)
+	}
+	return string(data)
+}
+
+// resultText extracts the readable output of a call.
+func resultText(c *ToolCall) string {
+	if c.Result == nil {
+		return ""
+	}
+	var out string
+	if content, ok := c.Result["content"].(string); ok {
+		out = content
+	} else {
+		rest := make(map[string]any, len(c.Result))
+		for k, v := range c.Result {
+			if k != "success" && k != "error" {
+				rest[k] = v
+			}
+		}
+		if len(rest) == 0 {
+			return ""
+		}
+		data, err := json.MarshalIndent(rest, "", "  ")
+		if err != nil {
+			return fmt.Sprint(rest)
+		}
+		out = string(data)
+	}
+	if r := []rune(out); len(r) > maxResultChars {
+		out = string(r[:maxResultChars]) + fmt.Sprintf("\n... (truncated, %d characters total)", len(r))
+	}
+	return out
+}
@@ -0,0 +1,329 @@
+// Package transcript renders conversations as Markdown, self-contained
+// HTML or JSONL transcripts, for attaching to pull requests and incident
+// reviews.
+//
+//	t := transcript.FromSession(session)
+//	t.SetResult(result)
+//	html, err := t.Render(transcript.FormatHTML)
+package transcript
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	sdk "github.com/ginkida/gokin-sdk"
+	"github.com/ginkida/gokin-sdk/chat"
+	"github.com/ginkida/gokin-sdk/tools"
+)
+
+// Format identifies a transcript output format.
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatJSONL    Format = "jsonl"
+)
+
+// Transcript is a rendered-ready view of a conversation.
+type Transcript struct {
+	Title     string    `json:"title,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Turns     []*Turn   `json:"turns"`
+	Usage     *Usage    `json:"usage,omitempty"`
+	Result    *Result   `json:"result,omitempty"`
+}
+
+// Turn is one message of the conversation. Tool results are attached to
+// the calls that produced them rather than shown as separate turns.
+type Turn struct {
+	Role      string      `json:"role"` // "user" or "model"
+	Text      string      `json:"text,omitempty"`
+	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a tool invocation and its outcome.
+type ToolCall struct {
+	ID     string         `json:"id,omitempty"`
+	Name   string         `json:"name"`
+	Args   map[string]any `json:"args,omitempty"`
+	Result map[string]any `json:"result,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Diff   string         `json:"diff,omitempty"` // unified diff for file edits
+}
+
+// Usage is the token usage of the conversation.
+type Usage struct {
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// Result is the outcome of an agent run.
+type Result struct {
+	Text     string        `json:"text,omitempty"`
+	Turns    int           `json:"turns"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// FromHistory builds a transcript from raw conversation history.
+func FromHistory(history []*genai.Content) *Transcript {
+	t := &Transcript{CreatedAt: time.Now()}
+	calls := make(map[string]*ToolCall)
+	var unmatched []*ToolCall // calls without IDs, matched by name in order
+
+	for _, content := range history {
+		if content == nil {
+			continue
+		}
+		turn := &Turn{Role: content.Role}
+		if turn.Role == "" {
+			turn.Role = "model"
+		}
+		var texts []string
+		for _, part := range content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				call := newToolCall(part.FunctionCall.ID, part.FunctionCall.Name, part.FunctionCall.Args)
+				turn.ToolCalls = append(turn.ToolCalls, call)
+				if call.ID != "" {
+					calls[call.ID] = call
+				} else {
+					unmatched = append(unmatched, call)
+				}
+			case part.FunctionResponse != nil:
+				resp := part.FunctionResponse
+				call := calls[resp.ID]
+				if call == nil {
+					for i, c := range unmatched {
+						if c.Name == resp.Name {
+							call = c
+							unmatched = append(unmatched[:i], unmatched[i+1:]...)
+							break
+						}
+					}
+				}
+				if call != nil {
+					call.setResult(resp.Response)
+				}
+			case strings.TrimSpace(part.Text) != "":
+				texts = append(texts, part.Text)
+			}
+		}
+		turn.Text = strings.Join(texts, "\n")
+		if turn.Text != "" || len(turn.ToolCalls) > 0 {
+			t.Turns = append(t.Turns, turn)
+		}
+	}
+	return t
+}
+
+// FromSession builds a transcript from an SDK session.
+func FromSession(s *sdk.Session) *Transcript {
+	t := FromHistory(s.GetHistory())
+	t.SessionID = s.ID()
+	t.CreatedAt = s.CreatedAt()
+	t.Title = s.Title()
+	return t
+}
+
+// FromChatSession builds a transcript from a chat session.
+func FromChatSession(s *chat.Session) *Transcript {
+	t := FromHistory(s.GetHistory())
+	t.SessionID = s.ID
+	t.CreatedAt = s.StartTime
+	if tokens := s.GetTokenCount(); tokens > 0 {
+		t.Usage = &Usage{TotalTokens: tokens}
+	}
+	return t
+}
+
+// SetResult attaches the outcome of an agent run.
+func (t *Transcript) SetResult(r *sdk.AgentResult) {
+	if r == nil {
+		return
+	}
+	t.Result = &Result{Text: r.Text, Turns: r.Turns, Duration: r.Duration}
+	if r.Error != nil {
+		t.Result.Error = r.Error.Error()
+	}
+}
+
+// Render renders the transcript in the given format.
+func (t *Transcript) Render(format Format) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return []byte(t.Markdown()), nil
+	case FormatHTML:
+		return []byte(t.HTML()), nil
+	case FormatJSONL:
+		return t.JSONL()
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// Write renders the transcript to w.
+func (t *Transcript) Write(w io.Writer, format Format) error {
+	data, err := t.Render(format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func newToolCall(id, name string, args map[string]any) *ToolCall {
+	call := &ToolCall{ID: id, Name: name, Args: args}
+	call.Diff = editDiff(name, args)
+	return call
+}
+
+func (c *ToolCall) setResult(result map[string]any) {
+	c.Result = result
+	if success, ok := result["success"].(bool); ok && !success {
+		c.Error, _ = result["error"].(string)
+	}
+}
+
+// editDiff renders the change made by a write, edit or apply_patch call.
+func editDiff(name string, args map[string]any) string {
+	path, _ := args["file_path"].(string)
+	switch name {
+	case "edit":
+		oldText, _ := args["old_string"].(string)
+		newText, _ := args["new_string"].(string)
+		return tools.UnifiedDiff("a/"+path, "b/"+path, oldText, newText, 3)
+	case "write":
+		content, _ := args["content"].(string)
+		return tools.UnifiedDiff("/dev/null", "b/"+path, "", content, 0)
+	case "apply_patch":
+		patch, _ := args["patch"].(string)
+		return patch
+	}
+	return ""
+}
+
+// --- Recording runs ---
+
+// Recorder builds a transcript from the events of an agent run, including
+// token usage and the final result. Pass Handle to sdk.WithEventHandler:
+//
+//	rec := transcript.NewRecorder(prompt)
+//	agent, _ := sdk.NewAgent(name, client, registry, sdk.WithEventHandler(rec.Handle))
+//	agent.Run(ctx, prompt)
+//	t := rec.Transcript()
+type Recorder struct {
+	mu      sync.Mutex
+	t       *Transcript
+	current *Turn
+	calls   map[string]*ToolCall
+}
+
+// NewRecorder creates a recorder for a run started with prompt.
+func NewRecorder(prompt string) *Recorder {
+	r := &Recorder{
+		t:     &Transcript{CreatedAt: time.Now()},
+		calls: make(map[string]*ToolCall),
+	}
+	if prompt != "" {
+		r.t.Turns = append(r.t.Turns, &Turn{Role: "user", Text: prompt})
+	}
+	return r
+}
+
+// Handle records one event.
+func (r *Recorder) Handle(ev sdk.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch ev.Type {
+	case sdk.EventText:
+		// Text after tool calls belongs to the next model response
+		if r.current == nil || len(r.current.ToolCalls) > 0 {
+			r.newTurn()
+		}
+		r.current.Text += ev.Text
+
+	case sdk.EventToolCallStart:
+		if r.current == nil {
+			r.newTurn()
+		}
+		call := newToolCall(ev.Tool.ID, ev.Tool.Name, ev.Tool.Args)
+		r.current.ToolCalls = append(r.current.ToolCalls, call)
+		r.calls[r.callKey(ev.Tool)] = call
+
+	case sdk.EventToolCallEnd:
+		key := r.callKey(ev.Tool)
+		if call, ok := r.calls[key]; ok {
+			call.setResult(ev.Tool.Result)
+			if ev.Tool.Error != "" {
+				call.Error = ev.Tool.Error
+			}
+			delete(r.calls, key)
+		}
+
+	case sdk.EventUsage:
+		if r.t.Usage == nil {
+			r.t.Usage = &Usage{}
+		}
+		r.t.Usage.InputTokens += ev.Usage.InputTokens
+		r.t.Usage.OutputTokens += ev.Usage.OutputTokens
+		r.t.Usage.TotalTokens += ev.Usage.InputTokens + ev.Usage.OutputTokens
+
+	case sdk.EventDone:
+		r.t.Result = &Result{
+			Text:     ev.Result.Text,
+			Turns:    ev.Result.Turns,
+			Duration: ev.Result.Duration,
+			Error:    ev.Result.Error,
+		}
+		r.current = nil
+	}
+}
+
+func (r *Recorder) newTurn() {
+	r.current = &Turn{Role: "model"}
+	r.t.Turns = append(r.t.Turns, r.current)
+}
+
+func (r *Recorder) callKey(tool *sdk.ToolEvent) string {
+	if tool.ID != "" {
+		return tool.ID
+	}
+	return "name:" + tool.Name
+}
+
+// Transcript returns a copy of the transcript recorded so far.
+func (r *Recorder) Transcript() *Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := *r.t
+	t.Turns = make([]*Turn, len(r.t.Turns))
+	for i, turn := range r.t.Turns {
+		cp := *turn
+		cp.ToolCalls = make([]*ToolCall, len(turn.ToolCalls))
+		for j, call := range turn.ToolCalls {
+			c := *call
+			cp.ToolCalls[j] = &c
+		}
+		t.Turns[i] = &cp
+	}
+	if r.t.Usage != nil {
+		usage := *r.t.Usage
+		t.Usage = &usage
+	}
+	if r.t.Result != nil {
+		result := *r.t.Result
+		t.Result = &result
+	}
+	return &t
+}
@@ -65,6 +65,12 @@ type Response struct {
 
 // Collect collects all chunks from a streaming response into a single Response.
 func (sr *StreamResponse) Collect(ctx context.Context) (*Response, error) {
+	return sr.CollectWith(ctx, nil)
+}
+
+// CollectWith is like Collect but calls onChunk for every chunk received,
+// e.g. to forward text deltas while the response is still streaming.
+func (sr *StreamResponse) CollectWith(ctx context.Context, onChunk func(ResponseChunk)) (*Response, error) {
 	resp := &Response{}
 
 	for {
@@ -79,6 +85,9 @@ func (sr *StreamResponse) Collect(ctx context.Context) (*Response, error) {
 			if chunk.Error != nil {
 				return nil, chunk.Error
 			}
+			if onChunk != nil {
+				onChunk(chunk)
+			}
 
 			resp.Text += chunk.Text
 
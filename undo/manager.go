@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/ginkida/gokin-sdk/fileutil"
 )
@@ -71,6 +72,32 @@ func (m *Manager) Undo() (*FileChange, error) {
 	return change, nil
 }
 
+// UndoSince reverts, newest first, every change recorded after t. It
+// returns the reverted changes; on failure, changes reverted before the
+// failing one stay reverted and remain redoable.
+func (m *Manager) UndoSince(t time.Time) ([]FileChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reverted []FileChange
+	for {
+		last := m.tracker.GetLast()
+		if last == nil || !last.Timestamp.After(t) {
+			return reverted, nil
+		}
+		change := m.tracker.PopLast()
+		if err := m.revertChange(change); err != nil {
+			m.tracker.Record(*change)
+			return reverted, fmt.Errorf("failed to undo %s: %w", change.FilePath, err)
+		}
+		if len(m.undone) >= m.maxRedo {
+			m.undone = m.undone[1:]
+		}
+		m.undone = append(m.undone, *change)
+		reverted = append(reverted, *change)
+	}
+}
+
 // Redo re-applies the last undone change.
 func (m *Manager) Redo() (*FileChange, error) {
 	m.mu.Lock()
@@ -185,9 +185,9 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 		return
 	}
 
-	// Skip temporary files
+	// Skip temporary files, but report .gitignore so ignore rules can be reloaded
 	base := filepath.Base(path)
-	if len(base) > 0 && (base[0] == '.' || base[0] == '#' || base[len(base)-1] == '~') {
+	if base != ".gitignore" && len(base) > 0 && (base[0] == '.' || base[0] == '#' || base[len(base)-1] == '~') {
 		return
 	}
 